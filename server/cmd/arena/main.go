@@ -0,0 +1,358 @@
+// Command arena plays bots against each other headless, without WebSockets or timers,
+// and reports how they fared.
+//
+// Games are spread over the given map sizes and seeds and run in parallel. The bots
+// swap seats every game so neither profits from always moving first.
+//
+// Usage:
+//
+//	arena -a hard -b medium [-games 100] [-sizes small,medium,large] [-seed 1]
+//	      [-parallel 8] [-max-turns 200] [-format json|csv] [-balance data/balance.yaml]
+//	      [-think 500ms] [-personalities data/personalities.yaml]
+//
+// Besides the difficulties, every personality in the personalities file can be fielded
+// by name; it plays as a hard bot.
+//
+// JSON prints a summary: win rates, average game length, win reasons and troop usage.
+// CSV prints one row per game.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/teomiscia/hexbattle/internal/bot"
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/mapgen"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// bots lists the bots the arena can field, by name.
+var bots = map[string]func(playerID string, seed int64) game.BotPlayer{
+	"easy":   func(id string, seed int64) game.BotPlayer { return bot.New(id, bot.DifficultyEasy, seed) },
+	"medium": func(id string, seed int64) game.BotPlayer { return bot.New(id, bot.DifficultyMedium, seed) },
+	"hard":   func(id string, seed int64) game.BotPlayer { return bot.New(id, bot.DifficultyHard, seed) },
+	"expert": func(id string, seed int64) game.BotPlayer { return bot.NewSearch(id, seed, *thinkTime) },
+}
+
+var thinkTime = flag.Duration("think", bot.DefaultThinkTime, "think time per turn of search bots")
+
+// GameRecord is the outcome of one arena game. Bots are identified by side: "a" or "b".
+type GameRecord struct {
+	Game      int                                `json:"game"`
+	Seed      int64                              `json:"seed"`
+	MapSize   model.MapSize                      `json:"map_size"`
+	First     string                             `json:"first"`  // side that moved first
+	Winner    string                             `json:"winner"` // side that won, empty for a draw
+	Reason    model.WinReason                    `json:"reason"`
+	Turns     int                                `json:"turns"`
+	Purchases map[string]map[model.TroopType]int `json:"purchases"` // side -> troop type -> bought
+	Error     string                             `json:"error,omitempty"`
+}
+
+// SideSummary aggregates one side's results.
+type SideSummary struct {
+	Bot         string                  `json:"bot"`
+	Wins        int                     `json:"wins"`
+	WinRate     float64                 `json:"win_rate"`
+	WinsAsFirst int                     `json:"wins_as_first"`
+	Purchases   map[model.TroopType]int `json:"purchases"`
+}
+
+// SizeSummary aggregates the results on one map size.
+type SizeSummary struct {
+	Games    int            `json:"games"`
+	Wins     map[string]int `json:"wins"` // side -> wins
+	Draws    int            `json:"draws"`
+	AvgTurns float64        `json:"avg_turns"`
+}
+
+// Summary is the arena's JSON report.
+type Summary struct {
+	Games     int                            `json:"games"`
+	Errors    int                            `json:"errors"`
+	Sides     map[string]*SideSummary        `json:"sides"`
+	Draws     int                            `json:"draws"`
+	AvgTurns  float64                        `json:"avg_turns"`
+	Reasons   map[model.WinReason]int        `json:"reasons"`
+	ByMapSize map[model.MapSize]*SizeSummary `json:"by_map_size"`
+}
+
+type options struct {
+	botA, botB string
+	sizes      []model.MapSize
+	seed       int64
+	maxTurns   int
+}
+
+func main() {
+	botA := flag.String("a", "hard", "bot on side a: "+botNames()+", or a personality")
+	botB := flag.String("b", "medium", "bot on side b: "+botNames()+", or a personality")
+	games := flag.Int("games", 100, "games to play")
+	sizes := flag.String("sizes", "small,medium,large", "comma-separated map sizes to rotate through")
+	seed := flag.Int64("seed", 1, "seed of the first game; game i uses seed+i")
+	parallel := flag.Int("parallel", runtime.NumCPU(), "games to play at once")
+	maxTurns := flag.Int("max-turns", 200, "turns after which a game is a draw")
+	format := flag.String("format", "json", "output format: json (summary) or csv (one row per game)")
+	balancePath := flag.String("balance", "data/balance.yaml", "balance file")
+	personalitiesPath := flag.String("personalities", "data/personalities.yaml", "bot personalities file")
+	flag.Parse()
+
+	personalities, err := bot.LoadPersonalities(*personalitiesPath)
+	if err != nil {
+		fatal(err)
+	}
+	for name, p := range personalities {
+		if bots[name] == nil {
+			bots[name] = func(id string, seed int64) game.BotPlayer {
+				return bot.NewWithPersonality(id, bot.DifficultyHard, p, seed)
+			}
+		}
+	}
+
+	// Bots log every decision; keep the output to the report
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	for _, name := range []string{*botA, *botB} {
+		if bots[name] == nil {
+			fatal(fmt.Errorf("unknown bot %q (have %s)", name, botNames()))
+		}
+	}
+	opts := options{botA: *botA, botB: *botB, seed: *seed, maxTurns: *maxTurns}
+	for _, s := range strings.Split(*sizes, ",") {
+		size := model.MapSize(strings.TrimSpace(s))
+		if !slices.Contains(model.MapSizes, size) {
+			fatal(fmt.Errorf("unknown map size %q", s))
+		}
+		opts.sizes = append(opts.sizes, size)
+	}
+
+	balance, err := config.LoadBalance(*balancePath)
+	if err != nil {
+		fatal(err)
+	}
+	game.LoadBalance(balance)
+
+	records := run(opts, *games, *parallel)
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(summarize(opts, records))
+	case "csv":
+		err = writeCSV(os.Stdout, records, game.TroopTypes(nil))
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// run plays the games on a pool of workers and returns the records in game order.
+func run(opts options, games, parallel int) []GameRecord {
+	if parallel < 1 {
+		parallel = 1
+	}
+	records := make([]GameRecord, games)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				records[i] = playGame(opts, i)
+			}
+		}()
+	}
+	for i := 0; i < games; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return records
+}
+
+// playGame plays game i. Side a moves first in even games, side b in odd ones.
+func playGame(opts options, i int) GameRecord {
+	seed := opts.seed + int64(i)
+	size := opts.sizes[i%len(opts.sizes)]
+	sides := [2]string{"a", "b"}
+	if i%2 == 1 {
+		sides = [2]string{"b", "a"}
+	}
+	names := map[string]string{"a": opts.botA, "b": opts.botB}
+
+	rec := GameRecord{Game: i, Seed: seed, MapSize: size, First: sides[0]}
+	fail := func(err error) GameRecord {
+		rec.Error = err.Error()
+		return rec
+	}
+
+	settings := model.RoomSettings{MapSize: size, TurnTimer: 90, TurnMode: model.TurnModeAlternating}
+	p1 := model.PlayerState{ID: "p1", Nickname: names[sides[0]]}
+	p2 := model.PlayerState{ID: "p2", Nickname: names[sides[1]]}
+	gs := game.NewGameState(fmt.Sprintf("arena-%d", i), settings, p1, p2, seed)
+
+	m, err := mapgen.Generate(size, seed, gs.Rules)
+	if err != nil {
+		return fail(err)
+	}
+	structures := 0
+	err = game.ApplyMap(gs, m, func() string {
+		structures++
+		return "struct-" + strconv.Itoa(structures)
+	})
+	if err != nil {
+		return fail(err)
+	}
+	gs.Phase = model.PhaseGameStarted
+
+	players := [2]game.BotPlayer{
+		bots[names[sides[0]]]("p1", seed*2),
+		bots[names[sides[1]]]("p2", seed*2+1),
+	}
+	result, err := game.PlayMatch(gs, dice.NewRoller(seed), players, opts.maxTurns)
+	if err != nil {
+		return fail(err)
+	}
+
+	rec.Reason = result.GameOver.Reason
+	rec.Turns = result.Turns
+	switch result.GameOver.WinnerID {
+	case "p1":
+		rec.Winner = sides[0]
+	case "p2":
+		rec.Winner = sides[1]
+	}
+	rec.Purchases = map[string]map[model.TroopType]int{
+		sides[0]: result.Purchases[0],
+		sides[1]: result.Purchases[1],
+	}
+	return rec
+}
+
+func summarize(opts options, records []GameRecord) *Summary {
+	s := &Summary{
+		Sides: map[string]*SideSummary{
+			"a": {Bot: opts.botA, Purchases: map[model.TroopType]int{}},
+			"b": {Bot: opts.botB, Purchases: map[model.TroopType]int{}},
+		},
+		Reasons:   map[model.WinReason]int{},
+		ByMapSize: map[model.MapSize]*SizeSummary{},
+	}
+
+	totalTurns := 0
+	for _, rec := range records {
+		if rec.Error != "" {
+			s.Errors++
+			continue
+		}
+		s.Games++
+		totalTurns += rec.Turns
+		s.Reasons[rec.Reason]++
+
+		size := s.ByMapSize[rec.MapSize]
+		if size == nil {
+			size = &SizeSummary{Wins: map[string]int{}}
+			s.ByMapSize[rec.MapSize] = size
+		}
+		size.Games++
+		size.AvgTurns += float64(rec.Turns)
+
+		if rec.Winner == "" {
+			s.Draws++
+			size.Draws++
+		} else {
+			side := s.Sides[rec.Winner]
+			side.Wins++
+			if rec.Winner == rec.First {
+				side.WinsAsFirst++
+			}
+			size.Wins[rec.Winner]++
+		}
+
+		for name, bought := range rec.Purchases {
+			for t, n := range bought {
+				s.Sides[name].Purchases[t] += n
+			}
+		}
+	}
+
+	if s.Games > 0 {
+		s.AvgTurns = float64(totalTurns) / float64(s.Games)
+		for _, side := range s.Sides {
+			side.WinRate = float64(side.Wins) / float64(s.Games)
+		}
+	}
+	for _, size := range s.ByMapSize {
+		size.AvgTurns /= float64(size.Games)
+	}
+	return s
+}
+
+func writeCSV(out io.Writer, records []GameRecord, troops []model.TroopType) error {
+	w := csv.NewWriter(out)
+	header := []string{"game", "seed", "map_size", "first", "winner", "reason", "turns"}
+	for _, side := range []string{"a", "b"} {
+		for _, t := range troops {
+			header = append(header, side+"_"+string(t))
+		}
+	}
+	header = append(header, "error")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		row := []string{
+			strconv.Itoa(rec.Game),
+			strconv.FormatInt(rec.Seed, 10),
+			string(rec.MapSize),
+			rec.First,
+			rec.Winner,
+			string(rec.Reason),
+			strconv.Itoa(rec.Turns),
+		}
+		for _, side := range []string{"a", "b"} {
+			for _, t := range troops {
+				row = append(row, strconv.Itoa(rec.Purchases[side][t]))
+			}
+		}
+		row = append(row, rec.Error)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func botNames() string {
+	names := make([]string, 0, len(bots))
+	for name := range bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "arena:", err)
+	os.Exit(1)
+}
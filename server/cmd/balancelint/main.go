@@ -0,0 +1,71 @@
+// Command balancelint checks balance files for mistakes before they reach a server:
+// unparseable dice notation, missing structure stats, unordered noise thresholds,
+// map sizes without a sudden-death turn or structure count, and so on.
+//
+// Usage:
+//
+//	balancelint [file ...]
+//
+// With no arguments it checks data/balance.yaml. Exits non-zero if any file is invalid.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	flag.Parse()
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"data/balance.yaml"}
+	}
+
+	failed := false
+	for _, path := range paths {
+		problems, err := lint(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		if len(problems) == 0 {
+			fmt.Printf("%s: ok\n", path)
+			continue
+		}
+		failed = true
+		for _, p := range problems {
+			fmt.Printf("%s: %v\n", path, p)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// lint returns every problem in the balance file at path.
+// err is set only if the file cannot be read or parsed at all.
+func lint(path string) ([]error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var balance config.BalanceData
+	if err := yaml.Unmarshal(data, &balance); err != nil {
+		return nil, err
+	}
+
+	err = balance.Validate()
+	if err == nil {
+		return nil, nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap(), nil
+	}
+	return []error{err}, nil
+}
@@ -0,0 +1,434 @@
+// Command botclient is a reference bot account: it plays the challenges sent to it with
+// one of the built-in bots, using only the public REST and WebSocket API, the way a bot
+// written in another process or language would.
+//
+// Usage:
+//
+//	botclient -token <bot token> [-server http://localhost:8080] [-difficulty hard]
+//	          [-think 500ms] [-poll 2s] [-once] [-challenge <bot id>]
+//
+// Bot accounts are created with POST /api/v1/admin/bots. The client polls
+// GET /api/v1/bots/challenges and joins the oldest challenge with join_game; with
+// -challenge it instead challenges another bot account and plays that one game. On each
+// of its turns it asks for the game state, picks an action, sends it and waits for the
+// ack, and ends the turn once the bot has nothing left to do.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"nhooyr.io/websocket"
+
+	"github.com/teomiscia/hexbattle/internal/api"
+	"github.com/teomiscia/hexbattle/internal/bot"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+var (
+	server     = flag.String("server", "http://localhost:8080", "server base URL")
+	token      = flag.String("token", "", "bot account token")
+	difficulty = flag.String("difficulty", "hard", "bot to play with: easy, medium, hard or expert")
+	thinkTime  = flag.Duration("think", bot.DefaultThinkTime, "think time per turn of the expert bot")
+	pollEvery  = flag.Duration("poll", 2*time.Second, "how often to check for challenges")
+	once       = flag.Bool("once", false, "exit after one game")
+	challenge  = flag.String("challenge", "", "challenge this bot account and play one game against it")
+)
+
+// errGameOver stops a game's loops once game_over arrives.
+var errGameOver = errors.New("game over")
+
+func main() {
+	flag.Parse()
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "botclient: -token is required")
+		os.Exit(2)
+	}
+	switch bot.Difficulty(*difficulty) {
+	case bot.DifficultyEasy, bot.DifficultyMedium, bot.DifficultyHard, bot.DifficultyExpert:
+	default:
+		fmt.Fprintf(os.Stderr, "botclient: unknown difficulty %q\n", *difficulty)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *challenge != "" {
+		roomID, err := sendChallenge(ctx, *challenge)
+		if err != nil {
+			slog.Error("failed to send challenge", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("challenge sent", "room_id", roomID, "bot_id", *challenge)
+		if err := play(ctx, roomID, *challenge); err != nil {
+			slog.Error("game failed", "room_id", roomID, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	for ctx.Err() == nil {
+		challenge, err := nextChallenge(ctx)
+		if err != nil {
+			slog.Error("failed to fetch challenges", "error", err)
+		}
+		if challenge == nil {
+			sleep(ctx, *pollEvery)
+			continue
+		}
+
+		slog.Info("accepting challenge",
+			"room_id", challenge.RoomID,
+			"challenger", challenge.ChallengerNick,
+		)
+		if err := play(ctx, challenge.RoomID, challenge.ChallengerID); err != nil {
+			slog.Error("game failed", "room_id", challenge.RoomID, "error", err)
+			if *once {
+				return
+			}
+			// The challenge is likely still listed; don't hammer the server with it
+			sleep(ctx, *pollEvery)
+			continue
+		}
+		if *once {
+			return
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// nextChallenge returns the oldest challenge waiting for the bot, or nil if there is none.
+func nextChallenge(ctx context.Context) (*api.ChallengeResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(*server, "/")+"/api/v1/bots/challenges", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("challenges: %s", resp.Status)
+	}
+
+	var body struct {
+		Challenges []api.ChallengeResponse `json:"challenges"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if len(body.Challenges) == 0 {
+		return nil, nil
+	}
+	return &body.Challenges[0], nil
+}
+
+// sendChallenge challenges a bot account with the default room settings and returns
+// the room to join.
+func sendChallenge(ctx context.Context, botID string) (string, error) {
+	body, err := json.Marshal(api.ChallengeBotRequest{BotID: botID})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(*server, "/")+"/api/v1/rooms/challenge", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("challenge: %s", resp.Status)
+	}
+
+	var room struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&room); err != nil {
+		return "", err
+	}
+	return room.RoomID, nil
+}
+
+// client is one WebSocket connection to a game.
+type client struct {
+	conn     *websocket.Conn
+	incoming chan ws.Envelope
+	readErr  error
+	seq      int
+}
+
+// play joins the room's game and plays it to the end against the given opponent.
+func play(ctx context.Context, roomID, opponentID string) error {
+	wsURL, err := url.Parse(strings.TrimSuffix(*server, "/") + "/ws")
+	if err != nil {
+		return err
+	}
+	wsURL.Scheme = strings.Replace(wsURL.Scheme, "http", "ws", 1)
+	wsURL.RawQuery = url.Values{"token": {*token}}.Encode()
+
+	conn, _, err := websocket.Dial(ctx, wsURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 22) // full game states of large maps
+
+	c := &client{conn: conn, incoming: make(chan ws.Envelope, 64)}
+	go c.readLoop(ctx)
+
+	joined, err := c.call(ctx, ws.MsgJoinGame, ws.JoinGameData{RoomID: roomID})
+	if err != nil {
+		return fmt.Errorf("join_game: %w", err)
+	}
+	if !joined {
+		return errors.New("join_game rejected")
+	}
+
+	// The bot adds no entropy of its own, so the game need not wait out the seed window
+	if _, err := c.call(ctx, ws.MsgClientSeed, ws.ClientSeedData{}); err != nil {
+		return fmt.Errorf("client_seed: %w", err)
+	}
+
+	// The bot is whichever player is not the opponent
+	var player game.BotPlayer
+	for {
+		env, err := c.next(ctx)
+		if errors.Is(err, errGameOver) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if env.Type != ws.MsgTurnStart {
+			continue
+		}
+
+		var turn ws.TurnStartData
+		if err := json.Unmarshal(env.Data, &turn); err != nil {
+			return err
+		}
+		if turn.ActivePlayerID == opponentID {
+			continue
+		}
+		if player == nil {
+			player = bot.NewPlayer(turn.ActivePlayerID, bot.Difficulty(*difficulty), nil, time.Now().UnixNano(), *thinkTime)
+		}
+		err = c.playTurn(ctx, player, turn.ActivePlayerID)
+		if errors.Is(err, errGameOver) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// playTurn sends the bot's actions one at a time, each one chosen from a fresh game
+// state, and ends the turn when the bot is done or an action is rejected.
+func (c *client) playTurn(ctx context.Context, player game.BotPlayer, playerID string) error {
+	for {
+		gs, err := c.requestState(ctx)
+		if err != nil {
+			return err
+		}
+		if gs.Phase != model.PhasePlayerAction || gs.ActivePlayerID() != playerID {
+			return nil // the turn ran out
+		}
+
+		action := player.NextAction(gs)
+		if action == nil {
+			break
+		}
+		msgType, data := message(action)
+		ok, err := c.call(ctx, msgType, data)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+	}
+
+	_, err := c.call(ctx, ws.MsgEndTurn, struct{}{})
+	return err
+}
+
+// message turns a bot action into the client message that performs it.
+func message(action *game.BotAction) (string, interface{}) {
+	switch action.Type {
+	case game.BotActionBuy:
+		return ws.MsgBuy, ws.BuyData{UnitType: action.TroopType, StructureID: action.StructureID}
+	case game.BotActionAttack:
+		return ws.MsgAttack, ws.AttackData{UnitID: action.UnitID, TargetQ: action.Target.Q, TargetR: action.Target.R, TargetS: action.Target.S}
+	default:
+		return ws.MsgMove, ws.MoveData{UnitID: action.UnitID, TargetQ: action.Target.Q, TargetR: action.Target.R, TargetS: action.Target.S}
+	}
+}
+
+// rateLimitBackoff is how long to wait before sending a rate-limited message again.
+const rateLimitBackoff = 500 * time.Millisecond
+
+// requestState asks for the game state and waits for it.
+func (c *client) requestState(ctx context.Context) (*game.GameState, error) {
+	c.seq++
+	if err := c.send(ctx, c.seq, ws.MsgRequestState, struct{}{}); err != nil {
+		return nil, err
+	}
+	for {
+		env, err := c.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch env.Type {
+		case ws.MsgGameState:
+			return game.DeserializeGameState(env.Data)
+		case ws.MsgNack:
+			var nack ws.NackData
+			if err := json.Unmarshal(env.Data, &nack); err != nil || nack.Seq != c.seq {
+				continue
+			}
+			if nack.Error.Code != model.ErrRateLimited {
+				return nil, fmt.Errorf("request_state: %s", nack.Error.Message)
+			}
+			if err := c.resend(ctx, c.seq, ws.MsgRequestState, struct{}{}); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// call sends an action and waits for its ack or nack. A nack is logged and reported
+// as false. Rate-limited actions are sent again, with the same seq, after a pause.
+func (c *client) call(ctx context.Context, msgType string, data interface{}) (bool, error) {
+	c.seq++
+	seq := c.seq
+	if err := c.send(ctx, seq, msgType, data); err != nil {
+		return false, err
+	}
+	for {
+		env, err := c.next(ctx)
+		if err != nil {
+			return false, err
+		}
+		switch env.Type {
+		case ws.MsgAck:
+			var ack ws.AckData
+			if err := json.Unmarshal(env.Data, &ack); err == nil && ack.Seq == seq {
+				return true, nil
+			}
+		case ws.MsgNack:
+			var nack ws.NackData
+			if err := json.Unmarshal(env.Data, &nack); err != nil || nack.Seq != seq {
+				continue
+			}
+			if nack.Error.Code == model.ErrRateLimited {
+				if err := c.resend(ctx, seq, msgType, data); err != nil {
+					return false, err
+				}
+				continue
+			}
+			slog.Warn("action rejected", "type", msgType, "code", nack.Error.Code, "message", nack.Error.Message)
+			return false, nil
+		}
+	}
+}
+
+// send writes a message with the given sequence number.
+func (c *client) send(ctx context.Context, seq int, msgType string, data interface{}) error {
+	msg, err := ws.NewEnvelopeWithSeq(msgType, seq, data)
+	if err != nil {
+		return err
+	}
+	return c.conn.Write(ctx, websocket.MessageText, msg)
+}
+
+// resend sends a rate-limited message again once the limit has had time to recover.
+func (c *client) resend(ctx context.Context, seq int, msgType string, data interface{}) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(rateLimitBackoff):
+	}
+	return c.send(ctx, seq, msgType, data)
+}
+
+// next returns the next message from the server, answering pings on the way.
+// It fails with errGameOver once the game ends.
+func (c *client) next(ctx context.Context) (ws.Envelope, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return ws.Envelope{}, ctx.Err()
+		case env, ok := <-c.incoming:
+			if !ok {
+				return ws.Envelope{}, fmt.Errorf("connection closed: %w", c.readErr)
+			}
+			switch env.Type {
+			case ws.MsgPing:
+				msg, err := ws.NewEnvelope(ws.MsgPong, struct{}{})
+				if err != nil {
+					return ws.Envelope{}, err
+				}
+				if err := c.conn.Write(ctx, websocket.MessageText, msg); err != nil {
+					return ws.Envelope{}, err
+				}
+			case ws.MsgGameOver:
+				var over ws.GameOverData
+				if err := json.Unmarshal(env.Data, &over); err == nil {
+					slog.Info("game over", "winner_id", over.WinnerID, "reason", over.Reason)
+				}
+				return env, errGameOver
+			default:
+				return env, nil
+			}
+		}
+	}
+}
+
+// readLoop decodes incoming messages until the connection closes.
+func (c *client) readLoop(ctx context.Context) {
+	defer close(c.incoming)
+	for {
+		_, data, err := c.conn.Read(ctx)
+		if err != nil {
+			c.readErr = err
+			return
+		}
+		var env ws.Envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			slog.Warn("invalid message from server", "error", err)
+			continue
+		}
+		c.incoming <- env
+	}
+}
@@ -0,0 +1,135 @@
+// Command combatsim estimates combat odds by resolving each attack thousands of times
+// with the game's own combat code.
+//
+// Usage:
+//
+//	combatsim [-balance data/balance.yaml] [-trials 10000] [-seed 1]
+//	combatsim -attacker marine -defender sniper [-attacker-terrain hills] [-defender-terrain forest]
+//	combatsim -attacker mech -structure hq
+//
+// With no -attacker it prints the full matchup matrix: every troop against every troop
+// on every passable terrain, then every troop against every structure.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func main() {
+	balancePath := flag.String("balance", "data/balance.yaml", "balance file")
+	trials := flag.Int("trials", 10000, "attacks to simulate per matchup")
+	seed := flag.Int64("seed", 1, "dice seed")
+	attacker := flag.String("attacker", "", "attacking troop type (omit for the full matrix)")
+	defender := flag.String("defender", "", "defending troop type")
+	structure := flag.String("structure", "", "defending structure type")
+	attackerTerrain := flag.String("attacker-terrain", "plains", "terrain under the attacker")
+	defenderTerrain := flag.String("defender-terrain", "plains", "terrain under the defender")
+	flag.Parse()
+
+	balance, err := config.LoadBalance(*balancePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	game.LoadBalance(balance)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+
+	if *attacker != "" {
+		m := game.Matchup{
+			Attacker:        model.TroopType(*attacker),
+			Defender:        model.TroopType(*defender),
+			Structure:       model.StructureType(*structure),
+			AttackerTerrain: model.TerrainType(*attackerTerrain),
+			DefenderTerrain: model.TerrainType(*defenderTerrain),
+		}
+		odds, err := game.SimulateMatchup(balance, m, *trials, *seed)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		printHeader(w, "defender")
+		printRow(w, m, odds)
+		return
+	}
+
+	troops := make([]model.TroopType, 0, len(balance.Troops))
+	for name := range balance.Troops {
+		troops = append(troops, model.TroopType(name))
+	}
+	sort.Slice(troops, func(i, j int) bool { return troops[i] < troops[j] })
+
+	var terrains []model.TerrainType
+	for name, tc := range balance.Terrain {
+		if tc.IsPassable() {
+			terrains = append(terrains, model.TerrainType(name))
+		}
+	}
+	sort.Slice(terrains, func(i, j int) bool { return terrains[i] < terrains[j] })
+	if len(terrains) == 0 {
+		terrains = []model.TerrainType{model.TerrainPlains}
+	}
+
+	for _, terrain := range terrains {
+		fmt.Fprintf(w, "\nattacker on %s, defender on %s\n", *attackerTerrain, terrain)
+		printHeader(w, "defender")
+		for _, a := range troops {
+			for _, d := range troops {
+				m := game.Matchup{
+					Attacker:        a,
+					Defender:        d,
+					AttackerTerrain: model.TerrainType(*attackerTerrain),
+					DefenderTerrain: terrain,
+				}
+				odds, err := game.SimulateMatchup(balance, m, *trials, *seed)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				printRow(w, m, odds)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\nattacker on %s vs structures (kill = capture)\n", *attackerTerrain)
+	printHeader(w, "structure")
+	for _, a := range troops {
+		for _, s := range model.StructureTypes {
+			m := game.Matchup{Attacker: a, Structure: s, AttackerTerrain: model.TerrainType(*attackerTerrain)}
+			odds, err := game.SimulateMatchup(balance, m, *trials, *seed)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			printRow(w, m, odds)
+		}
+	}
+}
+
+func printHeader(w *tabwriter.Writer, target string) {
+	fmt.Fprintf(w, "attacker\t%s\thit\tcrit\tdamage\tkill\tcounter\tcounter dmg\tattacker dies\t\n", target)
+}
+
+func printRow(w *tabwriter.Writer, m game.Matchup, odds game.CombatOdds) {
+	target := string(m.Defender)
+	if target == "" {
+		target = string(m.Structure)
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\t%s\t%s\t%.2f\t%s\t\n",
+		m.Attacker, target,
+		pct(odds.HitChance), pct(odds.CritChance), odds.ExpectedDamage, pct(odds.KillChance),
+		pct(odds.CounterChance), odds.ExpectedCounterDamage, pct(odds.AttackerDeathChance))
+}
+
+func pct(p float64) string {
+	return fmt.Sprintf("%.1f%%", p*100)
+}
@@ -55,7 +55,14 @@ func main() {
 		os.Exit(1)
 	}
 	game.LoadBalance(balance)
-	slog.Info("balance data loaded", "file", cfg.BalanceFile)
+	slog.Info("balance data loaded", "file", cfg.BalanceFile, "version", balance.Version)
+
+	personalities, err := bot.LoadPersonalities(cfg.BotPersonalitiesFile)
+	if err != nil {
+		slog.Error("failed to load bot personalities", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("bot personalities loaded", "file", cfg.BotPersonalitiesFile, "count", len(personalities))
 
 	// 3. Connect to Redis
 	redisStore, err := store.NewRedisStore(cfg.RedisURL)
@@ -74,6 +81,34 @@ func main() {
 	matchQueue := lobby.NewMatchmakingQueue(lobbyManager)
 	gameManager := game.NewManager(st)
 
+	// Every player's WebSocket connection, for lobby notifications sent before join_game
+	lobbyHub := ws.NewHub()
+	matchQueue.OnMatch = func(result lobby.MatchResult) {
+		lobbyHub.SendMessageTo(result.Player1.PlayerID, ws.MsgMatchFound, ws.MatchFoundData{
+			RoomID:           result.RoomID,
+			RoomCode:         result.RoomCode,
+			OpponentNickname: result.Player2.Nickname,
+		})
+		lobbyHub.SendMessageTo(result.Player2.PlayerID, ws.MsgMatchFound, ws.MatchFoundData{
+			RoomID:           result.RoomID,
+			RoomCode:         result.RoomCode,
+			OpponentNickname: result.Player1.Nickname,
+		})
+	}
+	matchQueue.OnQueueStatus = func(playerID string, status lobby.QueueStatus) {
+		lobbyHub.SendMessageTo(playerID, ws.MsgQueueStatus, ws.QueueStatusData{
+			Position:             status.Position,
+			QueueSize:            status.QueueSize,
+			EstimatedWaitSeconds: status.EstimatedWaitSeconds(),
+		})
+	}
+	lobbyManager.OnGuestJoined = func(room lobby.Room) {
+		lobbyHub.SendMessageTo(room.HostPlayerID, ws.MsgOpponentJoined, ws.OpponentJoinedData{
+			RoomID:   room.ID,
+			Nickname: room.GuestNickname,
+		})
+	}
+
 	// Restore active games if persistence is enabled
 	if st != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -87,6 +122,7 @@ func main() {
 	wsHandler := ws.NewHandler(registry, cfg.WSPingInterval, cfg.WSPongTimeout, cfg.CORSOrigins)
 	wsHandler.OnConnect = func(conn *ws.Connection) {
 		slog.Debug("new websocket connection", "player_id", conn.PlayerID)
+		lobbyHub.Register(conn)
 
 		conn.OnMessage = func(playerID string, env ws.Envelope) {
 			slog.Debug("ws message received", "player_id", playerID, "type", env.Type)
@@ -127,50 +163,47 @@ func main() {
 					room.GameID = newGameID
 					lobbyManager.SetGameInProgress(room.ID, newGameID)
 
+					// Until the engine exists, a failure hands the room back for another try
+					failSetup := func(msg string) {
+						lobbyManager.ResetGame(room.ID)
+						conn.SendNack(env.Seq, env.Type, string(model.ErrInvalidMessage), msg)
+					}
+
 					// Build players
 					p1Session := registry.GetByID(room.HostPlayerID)
 					p2Session := registry.GetByID(room.GuestPlayerID)
 
 					// Fallbacks if sessions are somehow missing (shouldn't happen)
-					p1Name := room.HostNickname
+					p1 := model.PlayerState{ID: room.HostPlayerID, Nickname: room.HostNickname}
 					if p1Session != nil {
-						p1Name = p1Session.Nickname
+						p1.Nickname = p1Session.Nickname
+						p1.IsBot = p1Session.IsBot
 					}
-					p2Name := room.GuestNickname
+					p2 := model.PlayerState{ID: room.GuestPlayerID, Nickname: room.GuestNickname}
 					if p2Session != nil {
-						p2Name = p2Session.Nickname
+						p2.Nickname = p2Session.Nickname
+						p2.IsBot = p2Session.IsBot
 					}
 
-					p1 := model.PlayerState{ID: room.HostPlayerID, Nickname: p1Name}
-					p2 := model.PlayerState{ID: room.GuestPlayerID, Nickname: p2Name}
-
 					// Create Game State
 					seed := time.Now().UnixNano()
 					state := game.NewGameState(newGameID, room.Settings, p1, p2, seed)
+					if err := state.CommitSeed(); err != nil {
+						failSetup("failed to seed game")
+						return
+					}
 
 					// Generate map
-					mapResult, err := mapgen.Generate(state.MapSize, seed, balance)
+					mapResult, err := mapgen.Generate(state.MapSize, seed, state.Rules)
 					if err != nil {
-						conn.SendNack(env.Seq, env.Type, string(model.ErrInvalidMessage), "failed to generate map")
+						failSetup("failed to generate map")
 						return
 					}
 
-					// Apply map to state
-					state.Terrain = mapResult.Terrain
-					// Apply structures (HQs and Neutral)
-					for _, sp := range mapResult.Structures {
-						owner := sp.OwnerID
-						if sp.Type == model.StructureHQ {
-							// First HQ goes to P1, second to P2
-							if state.PlayerHQ(p1.ID) == nil {
-								owner = p1.ID
-							} else {
-								owner = p2.ID
-							}
-						}
-						id := player.GenerateStructureID()
-						s, _ := game.NewStructureFromBalance(id, sp.Type, owner, sp.Position)
-						state.AddStructure(s)
+					// Apply map to state: terrain, HQs and neutral structures
+					if err := game.ApplyMap(state, mapResult, player.GenerateStructureID); err != nil {
+						failSetup("failed to place structures")
+						return
 					}
 
 					hub := ws.NewHub()
@@ -178,22 +211,31 @@ func main() {
 
 					// If this is a bot game, attach the bot to the engine
 					if room.IsBotGame {
-						difficulty := bot.DifficultyEasy
-						switch room.BotDifficulty {
-						case "medium":
-							difficulty = bot.DifficultyMedium
-						case "hard":
-							difficulty = bot.DifficultyHard
-						}
-						botPlayer := bot.New(room.GuestPlayerID, difficulty, seed)
+						difficulty := bot.Difficulty(room.BotDifficulty)
+						personality := personalities[room.BotPersonality]
+						botPlayer := bot.NewPlayer(room.GuestPlayerID, difficulty, personality, seed, cfg.BotThinkTime)
 						engine.Bot = botPlayer
 						slog.Info("bot attached to engine",
 							"bot_id", room.GuestPlayerID,
 							"difficulty", room.BotDifficulty,
+							"personality", room.BotPersonality,
 							"game_id", newGameID,
 						)
 					}
 
+					// Players may mix their own entropy into the dice seed before the first roll
+					engine.SeedWindow = cfg.SeedWindow
+
+					// Rooms with bot takeover hand a disconnected player's turns to a bot
+					engine.TakeoverTimeout = cfg.BotTakeoverTimeout
+					engine.StandIn = func(playerID string) game.BotPlayer {
+						return bot.New(playerID, bot.DifficultyHard, seed)
+					}
+
+					// Finished challenges drop off the bot's list of pending ones
+					roomID := room.ID
+					engine.OnGameOver = func() { lobbyManager.SetGameOver(roomID) }
+
 					gameManager.AddEngine(engine)
 				} else {
 					engine = gameManager.GetEngine(room.GameID)
@@ -244,6 +286,7 @@ func main() {
 				// Notify engine to resume connection
 				engine.NotifyReconnect(game.ReconnectEvent{
 					PlayerID: playerID,
+					Seq:      env.Seq,
 					Conn:     conn,
 				})
 				return
@@ -273,6 +316,7 @@ func main() {
 
 		conn.OnDisconnect = func(playerID string) {
 			slog.Info("player disconnected", "player_id", playerID, "game_id", conn.GameID)
+			lobbyHub.UnregisterConn(conn)
 			if conn.GameID != "" {
 				engine := gameManager.GetEngine(conn.GameID)
 				if engine != nil {
@@ -286,13 +330,20 @@ func main() {
 	// 6. Set up HTTP router
 	startTime := time.Now()
 	router := api.NewRouter(api.RouterConfig{
-		Registry:    registry,
-		Lobby:       lobbyManager,
-		Queue:       matchQueue,
-		Store:       st,
-		WSHandler:   wsHandler,
+		Registry:         registry,
+		Lobby:            lobbyManager,
+		Queue:            matchQueue,
+		Store:            st,
+		WSHandler:        wsHandler,
+		Balance:          game.CurrentBalance,
+		BotPersonalities: personalities,
+		ReloadBalance: func() (*config.BalanceData, error) {
+			return game.ReloadBalance(cfg.BalanceFile)
+		},
+		AdminToken:  cfg.AdminToken,
 		CORSOrigins: cfg.CORSOrigins,
 		StartTime:   startTime,
+		TrustProxy:  cfg.TrustProxy,
 	})
 
 	// 7. Create HTTP server
@@ -314,6 +365,20 @@ func main() {
 		}
 	}()
 
+	// Reload balance data on SIGHUP; games already running keep their ruleset
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			b, err := game.ReloadBalance(cfg.BalanceFile)
+			if err != nil {
+				slog.Error("balance reload failed", "file", cfg.BalanceFile, "error", err)
+				continue
+			}
+			slog.Info("balance data reloaded", "file", cfg.BalanceFile, "version", b.Version)
+		}
+	}()
+
 	// 9. Wait for shutdown signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,130 @@
+// Command verifyrolls checks that a finished game's dice were not tampered with.
+//
+// It takes the game's exported replay (GET /api/v1/games/{id}/replay), checks the
+// seeds revealed at game over against the commitment published at game start, then
+// replays the game from the derived seed. If a capture of the messages a client
+// received is given (one WebSocket envelope per line), every combat_result,
+// structure_attacked and structure_fires roll in it must match the replay.
+//
+// Usage:
+//
+//	verifyrolls -replay game.json [-received messages.ndjson] [-commitment <hex>] [-balance data/balance.yaml]
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+func main() {
+	replayPath := flag.String("replay", "", "exported replay JSON (required)")
+	receivedPath := flag.String("received", "", "messages received by a client, one envelope per line")
+	commitment := flag.String("commitment", "", "seed commitment seen at game start (defaults to the one in the replay)")
+	balancePath := flag.String("balance", "data/balance.yaml", "balance file the game was played with")
+	flag.Parse()
+
+	if *replayPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*replayPath, *receivedPath, *commitment, *balancePath); err != nil {
+		fmt.Fprintln(os.Stderr, "FAIL:", err)
+		os.Exit(1)
+	}
+}
+
+func run(replayPath, receivedPath, commitment, balancePath string) error {
+	balance, err := config.LoadBalance(balancePath)
+	if err != nil {
+		return err
+	}
+	game.LoadBalance(balance)
+
+	data, err := os.ReadFile(replayPath)
+	if err != nil {
+		return err
+	}
+	var log game.ActionLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return fmt.Errorf("invalid replay: %w", err)
+	}
+
+	// 1. The revealed seeds must match the commitment and the seed the game used
+	reveal, err := log.SeedReveal()
+	if err != nil {
+		return err
+	}
+	initial, err := game.DeserializeGameState(log.Initial)
+	if err != nil {
+		return fmt.Errorf("invalid initial state: %w", err)
+	}
+	if commitment == "" {
+		commitment = initial.SeedCommitment
+	}
+	if reveal.Commitment != commitment {
+		return fmt.Errorf("revealed commitment %s differs from the published %s", reveal.Commitment, commitment)
+	}
+	if err := game.VerifySeedReveal(reveal, log.Seed); err != nil {
+		return err
+	}
+	fmt.Printf("seed: commitment %s verified, seed %d\n", commitment, reveal.Seed)
+
+	// 2. Replaying the log from that seed must reproduce the game
+	replayed, err := game.ReplayRolls(&log)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("replay: %d entries, %d roll messages reproduced\n", len(log.Entries), len(replayed))
+
+	// 3. Every roll the client was shown must be one the replay produced
+	if receivedPath == "" {
+		return nil
+	}
+	received, err := readReceived(receivedPath)
+	if err != nil {
+		return err
+	}
+	if i := game.MatchRolls(replayed, received); i >= 0 {
+		return fmt.Errorf("received %s #%d does not match the replayed rolls: %+v", received[i].Type, i, received[i].Data)
+	}
+	fmt.Printf("received: all %d roll messages match\n", len(received))
+	return nil
+}
+
+// readReceived reads the roll messages from a capture of WebSocket envelopes.
+func readReceived(path string) ([]game.RollMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var msgs []game.RollMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var env ws.Envelope
+		if err := json.Unmarshal(scanner.Bytes(), &env); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid envelope: %w", path, line, err)
+		}
+		rolls, err := game.ParseRollMessages(env)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		msgs = append(msgs, rolls...)
+	}
+	return msgs, scanner.Err()
+}
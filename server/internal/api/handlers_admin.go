@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/player"
+	"github.com/teomiscia/hexbattle/internal/store"
+)
+
+// AdminHandler serves operator-only endpoints.
+type AdminHandler struct {
+	ReloadBalance func() (*config.BalanceData, error)
+	Store         store.Store
+	Registry      *player.Registry
+}
+
+// AdminMiddleware only lets through requests bearing the admin token.
+func AdminMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := extractToken(r)
+			if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HandleReloadBalance handles POST /api/v1/admin/balance/reload.
+// New games use the reloaded ruleset; games already running keep theirs.
+func (h *AdminHandler) HandleReloadBalance(w http.ResponseWriter, r *http.Request) {
+	if h.ReloadBalance == nil {
+		respondError(w, http.StatusServiceUnavailable, "RELOAD_UNAVAILABLE", "balance reload is not configured")
+		return
+	}
+
+	balance, err := h.ReloadBalance()
+	if err != nil {
+		slog.Error("balance reload failed", "error", err)
+		respondError(w, http.StatusUnprocessableEntity, "INVALID_BALANCE", err.Error())
+		return
+	}
+
+	slog.Info("balance data reloaded", "version", balance.Version)
+	respondJSON(w, http.StatusOK, map[string]string{
+		"version": balance.Version,
+	})
+}
+
+// HandleInfluence handles GET /api/v1/admin/games/{id}/influence.
+// Dumps the influence maps bots play by, computed from the game's last snapshot, for
+// both players or the one named by the player_id query parameter.
+func (h *AdminHandler) HandleInfluence(w http.ResponseWriter, r *http.Request) {
+	if h.Store == nil {
+		respondError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "influence maps require persistence")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	data, err := h.Store.LoadGameState(ctx, r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if data == nil {
+		respondError(w, http.StatusNotFound, string(model.ErrGameNotFound), "game not found")
+		return
+	}
+	gs, err := game.DeserializeGameState(data)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	playerIDs := []string{gs.Players[0].ID, gs.Players[1].ID}
+	if id := r.URL.Query().Get("player_id"); id != "" {
+		if gs.PlayerIndex(id) < 0 {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "player is not in this game")
+			return
+		}
+		playerIDs = []string{id}
+	}
+
+	maps := make(map[string][]game.HexInfluence, len(playerIDs))
+	for _, id := range playerIDs {
+		maps[id] = game.NewInfluenceMap(gs, id).Hexes(gs)
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"game_id":     gs.ID,
+		"turn_number": gs.TurnNumber,
+		"players":     maps,
+	})
+}
+
+// CreateBotAccountRequest is the request body for creating a bot account.
+type CreateBotAccountRequest struct {
+	Nickname string `json:"nickname"`
+}
+
+// HandleCreateBotAccount handles POST /api/v1/admin/bots.
+// Bot accounts are sessions like guests, flagged as bots so players can challenge them.
+// The token in the response is what the bot program authenticates with.
+func (h *AdminHandler) HandleCreateBotAccount(w http.ResponseWriter, r *http.Request) {
+	var req CreateBotAccountRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	session, err := player.NewBotSession(req.Nickname)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	h.Registry.Register(session)
+
+	slog.Info("bot account created", "player_id", session.ID, "nickname", session.Nickname)
+	respondJSON(w, http.StatusCreated, session.ToResponse())
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/teomiscia/hexbattle/internal/bot"
+	"github.com/teomiscia/hexbattle/internal/lobby"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// BotsHandler lists the personalities bot games can be created with, and the
+// challenges waiting for bot accounts.
+type BotsHandler struct {
+	Personalities map[string]*bot.Personality
+	Lobby         *lobby.Manager
+}
+
+// HandlePersonalities handles GET /api/v1/bots/personalities.
+func (h *BotsHandler) HandlePersonalities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "only GET is allowed")
+		return
+	}
+
+	personalities := make([]*bot.Personality, 0, len(h.Personalities))
+	for _, p := range h.Personalities {
+		personalities = append(personalities, p)
+	}
+	sort.Slice(personalities, func(i, j int) bool {
+		return personalities[i].Name < personalities[j].Name
+	})
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"personalities": personalities,
+	})
+}
+
+// ChallengeResponse is a room a bot account has been challenged to.
+type ChallengeResponse struct {
+	RoomCode       string             `json:"room_code"`
+	RoomID         string             `json:"room_id"`
+	Settings       model.RoomSettings `json:"settings"`
+	ChallengerID   string             `json:"challenger_id"`
+	ChallengerNick string             `json:"challenger_nickname"`
+	ChallengedAt   time.Time          `json:"challenged_at"`
+}
+
+// HandleChallenges handles GET /api/v1/bots/challenges.
+// Lists the rooms the calling bot account has been challenged to, oldest first. The bot
+// accepts one by connecting to /ws and sending join_game with the room ID.
+func (h *BotsHandler) HandleChallenges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "only GET is allowed")
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing session")
+		return
+	}
+	if !session.IsBot {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "only bot accounts receive challenges")
+		return
+	}
+
+	rooms := h.Lobby.PendingChallenges(session.ID)
+	challenges := make([]ChallengeResponse, 0, len(rooms))
+	for _, room := range rooms {
+		challenges = append(challenges, ChallengeResponse{
+			RoomCode:       room.Code,
+			RoomID:         room.ID,
+			Settings:       room.Settings,
+			ChallengerID:   room.HostPlayerID,
+			ChallengerNick: room.HostNickname,
+			ChallengedAt:   room.CreatedAt,
+		})
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"challenges": challenges,
+	})
+}
@@ -40,9 +40,14 @@ func (h *MatchmakingHandler) HandleJoin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Queued, waiting for opponent
+	// Queued, waiting for opponent. The match is pushed over the player's WebSocket
+	// connection as match_found, along with queue_status updates meanwhile.
+	status, _ := h.Queue.Status(session.ID)
 	respondJSON(w, http.StatusAccepted, map[string]interface{}{
-		"status": "queued",
+		"status":                 "queued",
+		"position":               status.Position,
+		"queue_size":             status.QueueSize,
+		"estimated_wait_seconds": status.EstimatedWaitSeconds(),
 	})
 }
 
@@ -70,7 +75,8 @@ func (h *MatchmakingHandler) HandleLeave(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-// HandleStatus handles GET /api/v1/matchmaking/status.
+// HandleStatus handles GET /api/v1/matchmaking/status. Clients with a WebSocket
+// connection open are sent the same information as it changes and need not poll.
 func (h *MatchmakingHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "only GET is allowed")
@@ -83,7 +89,7 @@ func (h *MatchmakingHandler) HandleStatus(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	queued := h.Queue.IsQueued(session.ID)
+	status, queued := h.Queue.Status(session.ID)
 
 	// Check if the player is in any room (not just "ready" state)
 	var matchedRoomID string
@@ -109,8 +115,18 @@ func (h *MatchmakingHandler) HandleStatus(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if queued {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"queued":                 true,
+			"queue_size":             status.QueueSize,
+			"position":               status.Position,
+			"estimated_wait_seconds": status.EstimatedWaitSeconds(),
+		})
+		return
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"queued":     queued,
+		"queued":     false,
 		"queue_size": h.Queue.Size(),
 	})
 }
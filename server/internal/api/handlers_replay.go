@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/store"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// ReplayHandler serves the action logs of finished games.
+type ReplayHandler struct {
+	Store store.Store
+}
+
+// HandleExport handles GET /api/v1/games/{id}/replay.
+// Returns the complete action log as a downloadable JSON file.
+func (h *ReplayHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	log, ok := h.loadLog(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="replay-%s.json"`, log.GameID))
+	respondJSON(w, http.StatusOK, log)
+}
+
+// HandleStream handles GET /api/v1/games/{id}/replay/stream.
+// Rebuilds the match step by step and streams the resulting messages as
+// newline-delimited JSON, in the same envelope format as the live WebSocket feed.
+func (h *ReplayHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	log, ok := h.loadLog(w, r)
+	if !ok {
+		return
+	}
+
+	replay, err := game.NewReplay(log)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		step, err := replay.Next()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			// Headers are already sent; report the failure in-band and stop.
+			slog.Warn("replay stream failed", "game_id", log.GameID, "error", err)
+			if env, encErr := ws.NewEnvelope(ws.MsgError, ws.ErrorData{
+				Code:    model.ErrInvalidMessage,
+				Message: err.Error(),
+			}); encErr == nil {
+				w.Write(append(env, '\n'))
+			}
+			return
+		}
+
+		envs, err := step.Envelopes()
+		if err != nil {
+			slog.Warn("replay stream failed", "game_id", log.GameID, "error", err)
+			return
+		}
+		for _, env := range envs {
+			if _, err := w.Write(append(env, '\n')); err != nil {
+				return // client went away
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// loadLog fetches and parses the action log named in the request path.
+// Only finished games can be replayed, so a live game's hidden information
+// (fog of war, queued orders) is never exposed.
+func (h *ReplayHandler) loadLog(w http.ResponseWriter, r *http.Request) (*game.ActionLog, bool) {
+	if h.Store == nil {
+		respondError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "replays require persistence")
+		return nil, false
+	}
+
+	gameID := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	records, err := h.Store.LoadReplay(ctx, gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return nil, false
+	}
+	if records == nil {
+		respondError(w, http.StatusNotFound, string(model.ErrGameNotFound), "replay not found")
+		return nil, false
+	}
+
+	log, err := game.ParseActionLog(records)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return nil, false
+	}
+	if !log.IsComplete() {
+		respondError(w, http.StatusConflict, "GAME_IN_PROGRESS", "replay is available once the game is over")
+		return nil, false
+	}
+
+	return log, true
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/teomiscia/hexbattle/internal/bot"
+	"github.com/teomiscia/hexbattle/internal/game"
 	"github.com/teomiscia/hexbattle/internal/lobby"
 	"github.com/teomiscia/hexbattle/internal/model"
 	"github.com/teomiscia/hexbattle/internal/player"
@@ -11,14 +13,20 @@ import (
 
 // RoomsHandler handles room creation, joining, and status.
 type RoomsHandler struct {
-	Lobby *lobby.Manager
+	Lobby    *lobby.Manager
+	Registry *player.Registry
+	// BotPersonalities are the personalities bot games may be created with, by name.
+	BotPersonalities map[string]*bot.Personality
 }
 
 // CreateRoomRequest is the request body for creating a room.
 type CreateRoomRequest struct {
-	MapSize   string `json:"map_size"`
-	TurnTimer int    `json:"turn_timer"`
-	TurnMode  string `json:"turn_mode"`
+	MapSize     string               `json:"map_size"`
+	TurnTimer   int                  `json:"turn_timer"`
+	TurnMode    string               `json:"turn_mode"`
+	FogOfWar    bool                 `json:"fog_of_war"`
+	BotTakeover bool                 `json:"bot_takeover"`
+	Rules       *model.RuleOverrides `json:"rules,omitempty"`
 }
 
 // JoinRoomRequest is the request body for joining a room.
@@ -66,13 +74,25 @@ func (h *RoomsHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if req.TurnMode != "" {
-		if model.TurnMode(req.TurnMode) == model.TurnModeAlternating {
-			settings.TurnMode = model.TurnModeAlternating
-		} else {
-			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "only alternating turn mode is supported")
+		switch model.TurnMode(req.TurnMode) {
+		case model.TurnModeAlternating, model.TurnModeSimultaneous:
+			settings.TurnMode = model.TurnMode(req.TurnMode)
+		default:
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "turn mode must be alternating or simultaneous")
 			return
 		}
 	}
+	settings.FogOfWar = req.FogOfWar
+	if req.BotTakeover && settings.TurnMode != model.TurnModeAlternating {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "bot takeover requires alternating turns")
+		return
+	}
+	settings.BotTakeover = req.BotTakeover
+	settings.Rules = req.Rules
+	if _, err := game.ResolveRules(game.CurrentBalance(), settings); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
 
 	room, err := h.Lobby.CreateRoom(session.ID, session.Nickname, settings)
 	if err != nil {
@@ -166,9 +186,11 @@ func (h *RoomsHandler) HandleGetStatus(w http.ResponseWriter, r *http.Request) {
 
 // CreateBotGameRequest is the request body for creating a bot game.
 type CreateBotGameRequest struct {
-	MapSize    string `json:"map_size"`
-	TurnTimer  int    `json:"turn_timer"`
-	Difficulty string `json:"difficulty"` // "easy", "medium", "hard"
+	MapSize     string               `json:"map_size"`
+	TurnTimer   int                  `json:"turn_timer"`
+	Difficulty  string               `json:"difficulty"`            // "easy", "medium", "hard", "expert"
+	Personality string               `json:"personality,omitempty"` // see GET /api/v1/bots/personalities; not for expert
+	Rules       *model.RuleOverrides `json:"rules,omitempty"`
 }
 
 // HandleCreateBotGame handles POST /api/v1/rooms/bot.
@@ -211,14 +233,32 @@ func (h *RoomsHandler) HandleCreateBotGame(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	settings.Rules = req.Rules
+	if _, err := game.ResolveRules(game.CurrentBalance(), settings); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
 	// Parse difficulty
 	difficulty := "easy"
 	if req.Difficulty != "" {
 		switch req.Difficulty {
-		case "easy", "medium", "hard":
+		case "easy", "medium", "hard", "expert":
 			difficulty = req.Difficulty
 		default:
-			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "difficulty must be easy, medium, or hard")
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "difficulty must be easy, medium, hard, or expert")
+			return
+		}
+	}
+
+	// Parse personality: it tunes the greedy bots, the expert bot searches instead
+	if req.Personality != "" {
+		if difficulty == "expert" {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "personalities apply to easy, medium, and hard bots")
+			return
+		}
+		if h.BotPersonalities[req.Personality] == nil {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "unknown personality")
 			return
 		}
 	}
@@ -226,17 +266,113 @@ func (h *RoomsHandler) HandleCreateBotGame(w http.ResponseWriter, r *http.Reques
 	// Generate a bot player ID
 	botPlayerID := player.GenerateUnitID() // reuse UUID generator
 
-	room, err := h.Lobby.CreateBotRoom(session.ID, session.Nickname, botPlayerID, difficulty, settings)
+	room, err := h.Lobby.CreateBotRoom(session.ID, session.Nickname, botPlayerID, difficulty, req.Personality, settings)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"room_code":       room.Code,
+		"room_id":         room.ID,
+		"settings":        room.Settings,
+		"bot_player_id":   botPlayerID,
+		"bot_difficulty":  difficulty,
+		"bot_personality": req.Personality,
+	})
+}
+
+// defaultMoveTimeLimit is the time a challenged bot account has for each action, in seconds.
+const defaultMoveTimeLimit = 10
+
+// ChallengeBotRequest is the request body for challenging a bot account.
+type ChallengeBotRequest struct {
+	BotID         string               `json:"bot_id"`
+	MapSize       string               `json:"map_size"`
+	TurnTimer     int                  `json:"turn_timer"`
+	FogOfWar      bool                 `json:"fog_of_war"`
+	MoveTimeLimit *int                 `json:"move_time_limit,omitempty"` // seconds per bot action, 0 for none; default 10
+	Rules         *model.RuleOverrides `json:"rules,omitempty"`
+}
+
+// HandleChallengeBot handles POST /api/v1/rooms/challenge.
+// Creates a room against a bot account; the bot finds it through GET /api/v1/bots/challenges
+// and both sides then send join_game as in any other room. Challenges use alternating turns.
+func (h *RoomsHandler) HandleChallengeBot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "only POST is allowed")
+		return
+	}
+
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing session")
+		return
+	}
+
+	var req ChallengeBotRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	botSession := h.Registry.GetByID(req.BotID)
+	if botSession == nil || !botSession.IsBot {
+		respondError(w, http.StatusNotFound, "BOT_NOT_FOUND", "bot not found")
+		return
+	}
+	if botSession.ID == session.ID {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "cannot challenge yourself")
+		return
+	}
+
+	// Parse settings with defaults
+	settings := model.DefaultRoomSettings()
+	if req.MapSize != "" {
+		switch model.MapSize(req.MapSize) {
+		case model.MapSizeSmall, model.MapSizeMedium, model.MapSizeLarge:
+			settings.MapSize = model.MapSize(req.MapSize)
+		default:
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid map size")
+			return
+		}
+	}
+	if req.TurnTimer > 0 {
+		switch req.TurnTimer {
+		case 60, 90, 120:
+			settings.TurnTimer = req.TurnTimer
+		default:
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "turn timer must be 60, 90, or 120")
+			return
+		}
+	}
+	settings.TurnMode = model.TurnModeAlternating
+	settings.FogOfWar = req.FogOfWar
+	settings.MoveTimeLimit = defaultMoveTimeLimit
+	if req.MoveTimeLimit != nil {
+		if *req.MoveTimeLimit < 0 || *req.MoveTimeLimit > settings.TurnTimer {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "move time limit must be between 0 and the turn timer")
+			return
+		}
+		settings.MoveTimeLimit = *req.MoveTimeLimit
+	}
+	settings.Rules = req.Rules
+	if _, err := game.ResolveRules(game.CurrentBalance(), settings); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	room, err := h.Lobby.CreateChallengeRoom(session.ID, session.Nickname, botSession.ID, botSession.Nickname, settings)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
-		"room_code":      room.Code,
-		"room_id":        room.ID,
-		"settings":       room.Settings,
-		"bot_player_id":  botPlayerID,
-		"bot_difficulty": difficulty,
+		"room_code":    room.Code,
+		"room_id":      room.ID,
+		"settings":     room.Settings,
+		"bot_id":       botSession.ID,
+		"bot_nickname": botSession.Nickname,
 	})
 }
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+)
+
+// RulesHandler serves the active ruleset, so clients learn the troop roster,
+// structures and terrain from the server instead of hardcoding them.
+type RulesHandler struct {
+	// Balance returns the ruleset new games start with; it changes when the balance file is reloaded.
+	Balance func() *config.BalanceData
+}
+
+// ServeHTTP handles GET /api/v1/rules.
+func (h *RulesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "only GET is allowed")
+		return
+	}
+
+	var balance *config.BalanceData
+	if h.Balance != nil {
+		balance = h.Balance()
+	}
+	if balance == nil {
+		respondError(w, http.StatusServiceUnavailable, "RULES_UNAVAILABLE", "no ruleset loaded")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, balance)
+}
@@ -9,7 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/teomiscia/hexbattle/internal/model"
 	"github.com/teomiscia/hexbattle/internal/player"
+	"github.com/teomiscia/hexbattle/internal/ratelimit"
 )
 
 // respondJSON writes a JSON response with the given status code.
@@ -132,6 +134,34 @@ func AuthMiddleware(registry *player.Registry) func(http.Handler) http.Handler {
 	}
 }
 
+// RateLimitMiddleware answers 429 to clients over the limiter's rate, by client IP.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Allow(clientIP(r, trustProxy)) {
+				respondError(w, http.StatusTooManyRequests, string(model.ErrRateLimited), "too many requests, slow down")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the address the request came from: the X-Real-IP header set by the
+// reverse proxy if it is trusted, the connection's remote address otherwise.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if ip := r.Header.Get("X-Real-IP"); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // statusWriter wraps http.ResponseWriter to capture the status code.
 type statusWriter struct {
 	http.ResponseWriter
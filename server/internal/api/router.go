@@ -3,13 +3,25 @@ package api
 import (
 	"net/http"
 
+	"github.com/teomiscia/hexbattle/internal/bot"
+	"github.com/teomiscia/hexbattle/internal/config"
 	"github.com/teomiscia/hexbattle/internal/lobby"
 	"github.com/teomiscia/hexbattle/internal/player"
+	"github.com/teomiscia/hexbattle/internal/ratelimit"
 	"github.com/teomiscia/hexbattle/internal/store"
 	"github.com/teomiscia/hexbattle/internal/ws"
 	"time"
 )
 
+// Rate limits of the REST endpoints, per client IP.
+var (
+	GuestLimit       = ratelimit.Rule{Rate: 5, Per: time.Minute, Burst: 2}
+	RoomsLimit       = ratelimit.Rule{Rate: 10, Per: time.Minute, Burst: 3} // creating rooms of any kind
+	JoinLimit        = ratelimit.Rule{Rate: 10, Per: time.Minute, Burst: 3}
+	MatchmakingLimit = ratelimit.Rule{Rate: 10, Per: time.Minute, Burst: 3}
+	HealthLimit      = ratelimit.Rule{Rate: 60, Per: time.Minute, Burst: 10}
+)
+
 // Router sets up all HTTP routes for the server.
 type Router struct {
 	Mux       *http.ServeMux
@@ -22,13 +34,21 @@ type Router struct {
 
 // RouterConfig holds the dependencies needed to create the router.
 type RouterConfig struct {
-	Registry    *player.Registry
-	Lobby       *lobby.Manager
-	Queue       *lobby.MatchmakingQueue
-	Store       store.Store
-	WSHandler   *ws.Handler
-	CORSOrigins []string
-	StartTime   time.Time
+	Registry  *player.Registry
+	Lobby     *lobby.Manager
+	Queue     *lobby.MatchmakingQueue
+	Store     store.Store
+	WSHandler *ws.Handler
+	Balance   func() *config.BalanceData
+	// BotPersonalities are the personalities bot games may be created with, by name.
+	BotPersonalities map[string]*bot.Personality
+	// ReloadBalance re-reads the balance file; used by the admin reload endpoint.
+	ReloadBalance func() (*config.BalanceData, error)
+	AdminToken    string
+	CORSOrigins   []string
+	StartTime     time.Time
+	// TrustProxy takes client IPs for rate limiting from X-Real-IP, as set by nginx.
+	TrustProxy bool
 }
 
 // NewRouter creates and configures the HTTP router with all routes.
@@ -46,8 +66,12 @@ func NewRouter(cfg RouterConfig) *Router {
 
 	// Create handlers
 	guestHandler := &GuestHandler{Registry: cfg.Registry}
-	roomsHandler := &RoomsHandler{Lobby: cfg.Lobby}
+	roomsHandler := &RoomsHandler{Lobby: cfg.Lobby, Registry: cfg.Registry, BotPersonalities: cfg.BotPersonalities}
+	botsHandler := &BotsHandler{Personalities: cfg.BotPersonalities, Lobby: cfg.Lobby}
 	matchmakingHandler := &MatchmakingHandler{Queue: cfg.Queue}
+	replayHandler := &ReplayHandler{Store: cfg.Store}
+	rulesHandler := &RulesHandler{Balance: cfg.Balance}
+	adminHandler := &AdminHandler{ReloadBalance: cfg.ReloadBalance, Store: cfg.Store, Registry: cfg.Registry}
 	healthHandler := &HealthHandler{
 		Registry:  cfg.Registry,
 		Lobby:     cfg.Lobby,
@@ -59,20 +83,45 @@ func NewRouter(cfg RouterConfig) *Router {
 	// Auth middleware wrapper
 	authMW := AuthMiddleware(cfg.Registry)
 
+	// Each group of endpoints has its own limiter, shared by its routes
+	limit := func(rule ratelimit.Rule) func(http.Handler) http.Handler {
+		return RateLimitMiddleware(ratelimit.NewLimiter(rule), cfg.TrustProxy)
+	}
+	guestMW := limit(GuestLimit)
+	roomsMW := limit(RoomsLimit)
+	joinMW := limit(JoinLimit)
+	matchmakingMW := limit(MatchmakingLimit)
+
 	// --- Public routes ---
-	mux.Handle("POST /api/v1/guest", guestHandler)
-	mux.Handle("GET /health", healthHandler)
+	mux.Handle("POST /api/v1/guest", guestMW(guestHandler))
+	mux.Handle("GET /health", limit(HealthLimit)(healthHandler))
+	mux.Handle("GET /api/v1/rules", rulesHandler)
+	mux.Handle("GET /api/v1/bots/personalities", http.HandlerFunc(botsHandler.HandlePersonalities))
 
 	// --- Protected routes ---
-	mux.Handle("POST /api/v1/rooms", authMW(http.HandlerFunc(roomsHandler.HandleCreate)))
-	mux.Handle("POST /api/v1/rooms/join", authMW(http.HandlerFunc(roomsHandler.HandleJoin)))
-	mux.Handle("POST /api/v1/rooms/bot", authMW(http.HandlerFunc(roomsHandler.HandleCreateBotGame)))
+	mux.Handle("POST /api/v1/rooms", roomsMW(authMW(http.HandlerFunc(roomsHandler.HandleCreate))))
+	mux.Handle("POST /api/v1/rooms/join", joinMW(authMW(http.HandlerFunc(roomsHandler.HandleJoin))))
+	mux.Handle("POST /api/v1/rooms/bot", roomsMW(authMW(http.HandlerFunc(roomsHandler.HandleCreateBotGame))))
+	mux.Handle("POST /api/v1/rooms/challenge", roomsMW(authMW(http.HandlerFunc(roomsHandler.HandleChallengeBot))))
 	mux.Handle("GET /api/v1/rooms/", authMW(http.HandlerFunc(roomsHandler.HandleGetStatus)))
 
-	mux.Handle("POST /api/v1/matchmaking/join", authMW(http.HandlerFunc(matchmakingHandler.HandleJoin)))
+	mux.Handle("POST /api/v1/matchmaking/join", matchmakingMW(authMW(http.HandlerFunc(matchmakingHandler.HandleJoin))))
 	mux.Handle("DELETE /api/v1/matchmaking/leave", authMW(http.HandlerFunc(matchmakingHandler.HandleLeave)))
 	mux.Handle("GET /api/v1/matchmaking/status", authMW(http.HandlerFunc(matchmakingHandler.HandleStatus)))
 
+	mux.Handle("GET /api/v1/bots/challenges", authMW(http.HandlerFunc(botsHandler.HandleChallenges)))
+
+	mux.Handle("GET /api/v1/games/{id}/replay", authMW(http.HandlerFunc(replayHandler.HandleExport)))
+	mux.Handle("GET /api/v1/games/{id}/replay/stream", authMW(http.HandlerFunc(replayHandler.HandleStream)))
+
+	// --- Admin routes (disabled unless ADMIN_TOKEN is set) ---
+	if cfg.AdminToken != "" {
+		adminMW := AdminMiddleware(cfg.AdminToken)
+		mux.Handle("POST /api/v1/admin/balance/reload", adminMW(http.HandlerFunc(adminHandler.HandleReloadBalance)))
+		mux.Handle("GET /api/v1/admin/games/{id}/influence", adminMW(http.HandlerFunc(adminHandler.HandleInfluence)))
+		mux.Handle("POST /api/v1/admin/bots", adminMW(http.HandlerFunc(adminHandler.HandleCreateBotAccount)))
+	}
+
 	// --- WebSocket ---
 	mux.Handle("GET /ws", cfg.WSHandler)
 
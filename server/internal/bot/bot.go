@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"math/rand"
 	"sort"
+	"time"
 
 	"github.com/teomiscia/hexbattle/internal/game"
 	"github.com/teomiscia/hexbattle/internal/hex"
@@ -17,19 +18,37 @@ const (
 	DifficultyEasy   Difficulty = "easy"
 	DifficultyMedium Difficulty = "medium"
 	DifficultyHard   Difficulty = "hard"
+	DifficultyExpert Difficulty = "expert" // searches ahead, see SearchBot
 )
 
+// NewPlayer creates the bot for a difficulty: a SearchBot thinking for up to thinkTime
+// per turn for expert, the greedy Bot with the given personality otherwise (the default
+// one if nil). Unknown difficulties play as easy.
+func NewPlayer(playerID string, difficulty Difficulty, personality *Personality, seed int64, thinkTime time.Duration) game.BotPlayer {
+	switch difficulty {
+	case DifficultyExpert:
+		return NewSearch(playerID, seed, thinkTime)
+	case DifficultyMedium, DifficultyHard:
+		return NewWithPersonality(playerID, difficulty, personality, seed)
+	default:
+		return NewWithPersonality(playerID, DifficultyEasy, personality, seed)
+	}
+}
+
 // Bot implements game.BotPlayer with a simple greedy AI.
 type Bot struct {
-	id         string
-	difficulty Difficulty
-	rng        *rand.Rand
-	logger     *slog.Logger
+	id          string
+	difficulty  Difficulty
+	personality *Personality
+	rng         *rand.Rand
+	logger      *slog.Logger
 
 	// Per-turn state to track which actions have been yielded.
 	turnNumber int
 	phase      botPhase
 	acted      map[string]bool // unit IDs that have already been processed this turn
+
+	influence *game.InfluenceMap // of the state being acted on, built when first needed
 }
 
 type botPhase int
@@ -43,12 +62,23 @@ const (
 
 // New creates a new Bot with the given player ID and difficulty.
 func New(playerID string, difficulty Difficulty, seed int64) *Bot {
+	return NewWithPersonality(playerID, difficulty, nil, seed)
+}
+
+// NewWithPersonality creates a Bot that plays with the given personality,
+// or DefaultPersonality if nil.
+func NewWithPersonality(playerID string, difficulty Difficulty, personality *Personality, seed int64) *Bot {
+	if personality == nil {
+		personality = DefaultPersonality
+	}
 	return &Bot{
-		id:         playerID,
-		difficulty: difficulty,
-		rng:        rand.New(rand.NewSource(seed)),
-		logger:     slog.Default().With("component", "bot", "player_id", playerID, "difficulty", difficulty),
-		acted:      make(map[string]bool),
+		id:          playerID,
+		difficulty:  difficulty,
+		personality: personality,
+		rng:         rand.New(rand.NewSource(seed)),
+		logger: slog.Default().With("component", "bot", "player_id", playerID,
+			"difficulty", difficulty, "personality", personality.Name),
+		acted: make(map[string]bool),
 	}
 }
 
@@ -66,6 +96,8 @@ func (b *Bot) NextAction(gs *game.GameState) *game.BotAction {
 		b.phase = phaseBuy
 		b.acted = make(map[string]bool)
 	}
+	// The state has changed since the last action
+	b.influence = nil
 
 	for b.phase != phaseDone {
 		switch b.phase {
@@ -126,9 +158,18 @@ func (b *Bot) planBuy(gs *game.GameState) *game.BotAction {
 		return nil
 	}
 
-	// Pick the spawner closest to the enemy HQ.
+	// Pick the spawner closest to the enemy HQ where the new troop would survive the enemy's next turn.
 	enemyHQ := b.enemyHQ(gs)
+	exposed := make(map[string]bool, len(spawners))
+	for _, s := range spawners {
+		if troop, err := game.NewTroopFromBalance(gs, "", troopType, b.id, s.Hex); err == nil {
+			exposed[s.ID] = !b.safe(gs, troop, s.Hex)
+		}
+	}
 	sort.Slice(spawners, func(i, j int) bool {
+		if exposed[spawners[i].ID] != exposed[spawners[j].ID] {
+			return !exposed[spawners[i].ID]
+		}
 		return spawners[i].Hex.Distance(enemyHQ) < spawners[j].Hex.Distance(enemyHQ)
 	})
 	spawner := spawners[0]
@@ -141,56 +182,96 @@ func (b *Bot) planBuy(gs *game.GameState) *game.BotAction {
 	}
 }
 
+// Troop roles the bot understands, as tagged in the balance file's troop roster.
+const (
+	RoleInfantry = "infantry"
+	RoleRanged   = "ranged"
+	RoleScout    = "scout"
+	RoleSiege    = "siege"
+)
+
+// buyRoles lists the roles each difficulty buys. Hard buys any troop.
+var buyRoles = map[Difficulty][]string{
+	DifficultyEasy:   {RoleInfantry},
+	DifficultyMedium: {RoleInfantry, RoleRanged, RoleScout},
+}
+
+// canBuy returns whether the bot's personality, or else its difficulty, allows buying the troop type.
+func (b *Bot) canBuy(gs *game.GameState, t model.TroopType) bool {
+	if len(b.personality.BuyWeights) > 0 {
+		return b.buyWeight(gs, t) > 0
+	}
+	roles, ok := buyRoles[b.difficulty]
+	if !ok {
+		return true
+	}
+	for _, role := range roles {
+		if game.TroopHasRole(gs, t, role) {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *Bot) chooseTroopType(gs *game.GameState, coins int) (model.TroopType, int) {
-	// Simple strategy: buy the most expensive troop we can afford.
-	// Easy bot: only marines. Medium: marines + snipers. Hard: all types.
+	// The roster comes from balance data; the difficulty decides which roles are bought.
 	type troopOption struct {
 		t    model.TroopType
 		cost int
 	}
 
-	var options []troopOption
-	switch b.difficulty {
-	case DifficultyEasy:
-		options = []troopOption{
-			{model.TroopMarine, game.TroopCost(model.TroopMarine)},
-		}
-	case DifficultyMedium:
-		options = []troopOption{
-			{model.TroopMarine, game.TroopCost(model.TroopMarine)},
-			{model.TroopSniper, game.TroopCost(model.TroopSniper)},
-			{model.TroopHoverbike, game.TroopCost(model.TroopHoverbike)},
-		}
-	default: // hard
-		options = []troopOption{
-			{model.TroopMarine, game.TroopCost(model.TroopMarine)},
-			{model.TroopSniper, game.TroopCost(model.TroopSniper)},
-			{model.TroopHoverbike, game.TroopCost(model.TroopHoverbike)},
-			{model.TroopMech, game.TroopCost(model.TroopMech)},
-		}
-	}
-
-	// Filter affordable options.
+	// Filter affordable options, in a stable order so the same seed buys the same troops.
 	var affordable []troopOption
-	for _, opt := range options {
-		if opt.cost > 0 && opt.cost <= coins {
-			affordable = append(affordable, opt)
+	for _, t := range game.TroopTypes(gs) {
+		cost := game.TroopCost(gs, t)
+		if b.canBuy(gs, t) && cost > 0 && cost <= coins {
+			affordable = append(affordable, troopOption{t, cost})
 		}
 	}
 	if len(affordable) == 0 {
 		return "", 0
 	}
 
-	// For easy: always marine. For medium/hard: weighted random.
+	// A personality picks by its weights
+	if len(b.personality.BuyWeights) > 0 {
+		total := 0.0
+		for _, o := range affordable {
+			total += b.buyWeight(gs, o.t)
+		}
+		r := b.rng.Float64() * total
+		for _, o := range affordable {
+			r -= b.buyWeight(gs, o.t)
+			if r < 0 {
+				return o.t, o.cost
+			}
+		}
+		last := affordable[len(affordable)-1]
+		return last.t, last.cost
+	}
+
+	// For easy: always the cheapest. For medium/hard: random.
 	if b.difficulty == DifficultyEasy {
+		sort.SliceStable(affordable, func(i, j int) bool {
+			return affordable[i].cost < affordable[j].cost
+		})
 		return affordable[0].t, affordable[0].cost
 	}
 
-	// Pick a random affordable troop, weighted toward cheaper ones.
 	pick := b.rng.Intn(len(affordable))
 	return affordable[pick].t, affordable[pick].cost
 }
 
+// buyWeight returns the personality's weight for the troop type: that of its most wanted role.
+func (b *Bot) buyWeight(gs *game.GameState, t model.TroopType) float64 {
+	best := 0.0
+	for role, w := range b.personality.BuyWeights {
+		if w > best && game.TroopHasRole(gs, t, role) {
+			best = w
+		}
+	}
+	return best
+}
+
 // ---------------------------------------------------------------------------
 // Attack phase
 // ---------------------------------------------------------------------------
@@ -206,6 +287,12 @@ func (b *Bot) planAttack(gs *game.GameState) *game.BotAction {
 			continue
 		}
 
+		// Wounded troops avoid combat so they can heal.
+		if b.retreating(troop) {
+			b.acted[troop.ID+"_atk"] = true
+			continue
+		}
+
 		// First-turn restriction check.
 		if gs.FirstTurnRestriction && gs.TurnNumber == 1 && gs.ActivePlayer == 0 {
 			b.acted[troop.ID+"_atk"] = true
@@ -246,10 +333,11 @@ func (b *Bot) findAttackTarget(gs *game.GameState, troop *model.Troop) hex.Coord
 
 func (b *Bot) findBestAttackTarget(gs *game.GameState, troop *model.Troop) (hex.Coord, bool) {
 	type candidate struct {
-		pos      hex.Coord
-		priority int // lower is better
+		pos   hex.Coord
+		score float64 // higher is better
 	}
 	var candidates []candidate
+	priority := b.personality.TargetPriority
 
 	// Check all hexes in attack range.
 	for _, h := range troop.Hex.Spiral(troop.Range) {
@@ -264,16 +352,20 @@ func (b *Bot) findBestAttackTarget(gs *game.GameState, troop *model.Troop) (hex.
 		// Enemy troop?
 		enemy := gs.TroopAtHex(h)
 		if enemy != nil && enemy.OwnerID != b.id {
-			prio := enemy.CurrentHP // prefer low-HP targets
-			candidates = append(candidates, candidate{pos: h, priority: prio})
+			score := priority.Troops*100 - float64(enemy.CurrentHP) // prefer low-HP targets
+			candidates = append(candidates, candidate{pos: h, score: score})
 			continue
 		}
 
 		// Enemy/neutral structure?
 		structure := gs.StructureAtHex(h)
 		if structure != nil && !structure.IsOwnedBy(b.id) {
-			prio := 100 + structure.CurrentHP // troops first, then structures
-			candidates = append(candidates, candidate{pos: h, priority: prio})
+			weight := priority.Structures
+			if structure.Type == model.StructureHQ {
+				weight = priority.HQ
+			}
+			score := weight*100 - float64(structure.CurrentHP)
+			candidates = append(candidates, candidate{pos: h, score: score})
 		}
 	}
 
@@ -282,7 +374,7 @@ func (b *Bot) findBestAttackTarget(gs *game.GameState, troop *model.Troop) (hex.
 	}
 
 	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].priority < candidates[j].priority
+		return candidates[i].score > candidates[j].score
 	})
 	return candidates[0].pos, true
 }
@@ -302,8 +394,8 @@ func (b *Bot) planMove(gs *game.GameState) *game.BotAction {
 			continue
 		}
 
-		target := b.findMoveTarget(gs, troop)
-		if target == (hex.Coord{}) {
+		target, ok := b.findMoveTarget(gs, troop)
+		if !ok {
 			b.acted[troop.ID+"_mv"] = true
 			continue
 		}
@@ -319,41 +411,88 @@ func (b *Bot) planMove(gs *game.GameState) *game.BotAction {
 	return nil
 }
 
-func (b *Bot) findMoveTarget(gs *game.GameState, troop *model.Troop) hex.Coord {
+// findMoveTarget returns where the troop should move this turn, and false if it should
+// stay where it is.
+func (b *Bot) findMoveTarget(gs *game.GameState, troop *model.Troop) (hex.Coord, bool) {
 	reachable := game.ReachableHexes(gs, troop)
 	if len(reachable) == 0 {
-		return hex.Coord{}
+		return hex.Coord{}, false
 	}
 
 	// Determine objective: move toward the nearest high-value target.
 	objective := b.chooseObjective(gs, troop)
 
-	// Find the reachable hex that minimizes distance to the objective.
+	// Follow the planned route around terrain and units: go as far along it as this turn
+	// allows without stepping into fire the troop isn't expected to survive.
+	if path := game.PlanPath(gs, troop, objective); path != nil {
+		var furthest hex.Coord
+		found := false
+		for i := len(path.Steps) - 1; i >= 0; i-- {
+			h := path.Steps[i].Hex
+			if _, ok := reachable[h]; !ok {
+				continue
+			}
+			if b.safe(gs, troop, h) {
+				return h, true
+			}
+			if !found {
+				furthest, found = h, true
+			}
+		}
+		if found && !b.safe(gs, troop, troop.Hex) {
+			// Under fire where it stands: take cover if it can, else press on.
+			if cover, ok := b.closestSafeHex(gs, troop, reachable, objective); ok {
+				return cover, true
+			}
+			return furthest, true
+		}
+		if found {
+			return hex.Coord{}, false // hold until the way is clear
+		}
+	}
+
+	// No route: find the safe reachable hex that minimizes distance to the objective.
+	bestHex, ok := b.closestSafeHex(gs, troop, reachable, objective)
+	if !ok {
+		return hex.Coord{}, false
+	}
+
+	// Don't move if we're already adjacent or closer to objective than our best move.
+	if bestHex.Distance(objective) >= troop.Hex.Distance(objective) {
+		return hex.Coord{}, false // no improvement
+	}
+
+	return bestHex, true
+}
+
+// closestSafeHex returns the reachable hex nearest the objective where the troop is
+// expected to survive the enemy's next turn.
+func (b *Bot) closestSafeHex(gs *game.GameState, troop *model.Troop, reachable map[hex.Coord]int, objective hex.Coord) (hex.Coord, bool) {
 	bestHex := hex.Coord{}
 	bestDist := 999999
 	for h := range reachable {
 		dist := h.Distance(objective)
-		if dist < bestDist {
+		if dist < bestDist && b.safe(gs, troop, h) {
 			bestDist = dist
 			bestHex = h
 		}
 	}
-
-	// Don't move if we're already adjacent or closer to objective than our best move.
-	currentDist := troop.Hex.Distance(objective)
-	if bestDist >= currentDist {
-		return hex.Coord{} // no improvement
-	}
-
-	return bestHex
+	return bestHex, bestDist < 999999
 }
 
 func (b *Bot) chooseObjective(gs *game.GameState, troop *model.Troop) hex.Coord {
 	// Priority list:
-	// 1. Nearby enemy troops (if within reasonable range)
+	// 0. Own structures, for wounded troops falling back
+	// 1. Nearby enemy troops (within the personality's aggression radius)
 	// 2. Uncaptured/enemy structures
 	// 3. Enemy HQ
 
+	if b.retreating(troop) {
+		if pos, ok := b.retreatTarget(gs, troop); ok {
+			return pos
+		}
+	}
+
 	// Look for nearby enemy troops.
 	nearestEnemyDist := 999
 	nearestEnemyPos := hex.Coord{}
@@ -368,8 +507,8 @@ func (b *Bot) chooseObjective(gs *game.GameState, troop *model.Troop) hex.Coord
 		}
 	}
 
-	// If an enemy is within 5 hexes, go for it.
-	if nearestEnemyDist <= 5 {
+	// If an enemy is close enough, go for it.
+	if nearestEnemyDist <= b.personality.AggressionRadius {
 		return nearestEnemyPos
 	}
 
@@ -406,3 +545,45 @@ func (b *Bot) enemyHQ(gs *game.GameState) hex.Coord {
 	}
 	return hex.Coord{}
 }
+
+// retreating returns whether the troop is wounded enough to fall back, per the personality.
+func (b *Bot) retreating(troop *model.Troop) bool {
+	return float64(troop.CurrentHP) < b.personality.RetreatHP*float64(troop.MaxHP)
+}
+
+// retreatTarget returns the nearest owned structure where the troop would be safe,
+// or the nearest one if it would be safe at none. Ties go to the lowest structure ID
+// so a game replays the same from its seed.
+func (b *Bot) retreatTarget(gs *game.GameState, troop *model.Troop) (hex.Coord, bool) {
+	ids := make([]string, 0, len(gs.Structures))
+	for id, s := range gs.Structures {
+		if s.IsOwnedBy(b.id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	best, bestDist, bestSafe := hex.Coord{}, -1, false
+	for _, id := range ids {
+		s := gs.Structures[id]
+		dist, safe := troop.Hex.Distance(s.Hex), b.safe(gs, troop, s.Hex)
+		if bestDist < 0 || (safe && !bestSafe) || (safe == bestSafe && dist < bestDist) {
+			best, bestDist, bestSafe = s.Hex, dist, safe
+		}
+	}
+	return best, bestDist >= 0
+}
+
+// safe returns whether the troop can stand on h through the enemy's next turn: the
+// enemies that can reach the hex aren't expected to kill it, or the rest of the bot's
+// side can make them pay for it. Easy bots don't look and take every hex to be safe.
+func (b *Bot) safe(gs *game.GameState, troop *model.Troop, h hex.Coord) bool {
+	if b.difficulty == DifficultyEasy {
+		return true
+	}
+	if b.influence == nil {
+		b.influence = game.NewInfluenceMap(gs, b.id)
+	}
+	threat := b.influence.ThreatTo(gs, troop, h)
+	return threat < float64(troop.CurrentHP) || b.influence.SupportFor(gs, troop, h) >= threat
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func TestBot_RoutesAroundWater(t *testing.T) {
+	gs := newGreedyTestState(t)
+	// A lake along q = 1 between the troop and the outpost, passable only from r = 3 down.
+	// No hex the troop can reach this turn is closer to the outpost as the crow flies.
+	for r := -7; r <= 2; r++ {
+		gs.Terrain[hex.NewCoord(1, r, -1-r)] = model.TerrainWater
+	}
+	outpost, err := game.NewStructureFromBalance(gs, "s1", model.StructureOutpost, "", hex.NewCoord(3, 0, -3))
+	require.NoError(t, err)
+	gs.AddStructure(outpost)
+	marine := addTroop(t, gs, "p1", "marine", hex.NewCoord(0, 0, 0))
+
+	action := New("p1", DifficultyHard, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, game.BotActionMove, action.Type)
+	assert.Equal(t, hex.NewCoord(0, 3, -3), action.Target, "heads for the way around the lake")
+
+	result := game.ExecuteBotAction(gs, nil, "p1", action)
+	require.True(t, result.Ack)
+	assert.Equal(t, action.Target, marine.Hex)
+}
+
+func TestBot_StopsOnTheMapCentre(t *testing.T) {
+	gs := newGreedyTestState(t)
+	outpost, err := game.NewStructureFromBalance(gs, "s1", model.StructureOutpost, "", hex.NewCoord(0, -6, 6))
+	require.NoError(t, err)
+	gs.AddStructure(outpost)
+	marine := addTroop(t, gs, "p1", "marine", hex.NewCoord(0, 3, -3))
+
+	// The furthest step along the route this turn is the origin
+	action := New("p1", DifficultyHard, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, game.BotActionMove, action.Type)
+	assert.Equal(t, marine.ID, action.UnitID)
+	assert.Equal(t, hex.NewCoord(0, 0, 0), action.Target)
+}
+
+func TestBot_StaysOutOfFire(t *testing.T) {
+	gs := newGreedyTestState(t)
+	center, err := game.NewStructureFromBalance(gs, "s1", model.StructureCommandCenter, "p2", hex.NewCoord(0, -6, 6))
+	require.NoError(t, err)
+	gs.AddStructure(center)
+	marine := addTroop(t, gs, "p1", "marine", hex.NewCoord(0, 0, 0))
+	marine.CurrentHP = 3 // one volley from the command center is expected to finish it
+
+	// Easy bots walk into range
+	action := New("p1", DifficultyEasy, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, center.Range, action.Target.Distance(center.Hex))
+
+	action = New("p1", DifficultyHard, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, game.BotActionMove, action.Type)
+	assert.Equal(t, center.Range+1, action.Target.Distance(center.Hex), "stops just out of range")
+}
+
+func TestBot_BuysAwayFromFire(t *testing.T) {
+	gs := newGreedyTestState(t)
+	gs.Players[0].Coins = 1000
+	hq, err := game.NewStructureFromBalance(gs, "hq1", model.StructureHQ, "p1", hex.NewCoord(0, 5, -5))
+	require.NoError(t, err)
+	gs.AddStructure(hq)
+	outpost, err := game.NewStructureFromBalance(gs, "s1", model.StructureOutpost, "p1", hex.NewCoord(0, -1, 1))
+	require.NoError(t, err)
+	gs.AddStructure(outpost)
+	for _, pos := range []hex.Coord{hex.NewCoord(1, -3, 2), hex.NewCoord(0, -3, 3), hex.NewCoord(-1, -2, 3)} {
+		addTroop(t, gs, "p2", "mech", pos)
+	}
+
+	// The outpost is nearer the front, but the mechs have it covered
+	action := New("p1", DifficultyEasy, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, outpost.ID, action.StructureID)
+
+	action = New("p1", DifficultyHard, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, game.BotActionBuy, action.Type)
+	assert.Equal(t, hq.ID, action.StructureID)
+}
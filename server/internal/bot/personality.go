@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Personality tunes how the greedy Bot fights and what it buys. Personalities are
+// defined in a YAML file, see data/personalities.yaml.
+type Personality struct {
+	Name        string `yaml:"-" json:"name"`
+	Description string `yaml:"description" json:"description"`
+
+	// TargetPriority weighs the kinds of target in attack range.
+	TargetPriority TargetPriority `yaml:"target_priority" json:"target_priority"`
+	// BuyWeights is how often each troop role is bought; roles left out are never bought.
+	// Empty buys what the bot's difficulty allows.
+	BuyWeights map[string]float64 `yaml:"buy_weights" json:"buy_weights,omitempty"`
+	// AggressionRadius is the distance within which the bot chases enemy troops.
+	AggressionRadius int `yaml:"aggression_radius" json:"aggression_radius"`
+	// RetreatHP is the fraction of max HP below which a troop falls back to heal. 0 never retreats.
+	RetreatHP float64 `yaml:"retreat_hp" json:"retreat_hp"`
+}
+
+// TargetPriority weighs the kinds of target the bot may attack. The kind with the
+// highest weight is attacked first, and within a kind the target with the lowest HP.
+type TargetPriority struct {
+	Troops     float64 `yaml:"troops" json:"troops"`
+	Structures float64 `yaml:"structures" json:"structures"` // outposts and command centers
+	HQ         float64 `yaml:"hq" json:"hq"`
+}
+
+// DefaultPersonality is how bots play unless given another personality:
+// troops first, enemies chased within 5 hexes, no retreat.
+var DefaultPersonality = &Personality{
+	Name:             "default",
+	TargetPriority:   TargetPriority{Troops: 1},
+	AggressionRadius: 5,
+}
+
+// buyableRoles lists the troop roles a personality may weigh.
+var buyableRoles = []string{RoleInfantry, RoleRanged, RoleScout, RoleSiege}
+
+// LoadPersonalities reads and validates a personalities YAML file, returning the
+// personalities by name.
+func LoadPersonalities(path string) (map[string]*Personality, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("bot: failed to read personalities file %s: %w", path, err)
+	}
+
+	var file struct {
+		Personalities map[string]*Personality `yaml:"personalities"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("bot: failed to parse personalities file %s: %w", path, err)
+	}
+
+	var errs []error
+	for _, name := range sortedNames(file.Personalities) {
+		p := file.Personalities[name]
+		if p == nil {
+			p = &Personality{}
+			file.Personalities[name] = p
+		}
+		p.Name = name
+		if err := p.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("bot: invalid personalities file %s:\n%w", path, err)
+	}
+	return file.Personalities, nil
+}
+
+// Validate checks the personality's weights. Every problem found is reported, one per joined error.
+func (p *Personality) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("personalities.%s."+format, append([]interface{}{p.Name}, args...)...))
+	}
+
+	tp := p.TargetPriority
+	if tp.Troops < 0 || tp.Structures < 0 || tp.HQ < 0 {
+		fail("target_priority: weights must not be negative")
+	}
+
+	total := 0.0
+	for _, role := range sortedNames(p.BuyWeights) {
+		w := p.BuyWeights[role]
+		if !slices.Contains(buyableRoles, role) {
+			fail("buy_weights.%s: unknown role, expected one of %v", role, buyableRoles)
+		}
+		if w < 0 {
+			fail("buy_weights.%s: must not be negative", role)
+		}
+		total += w
+	}
+	if len(p.BuyWeights) > 0 && total <= 0 {
+		fail("buy_weights: at least one role must have a positive weight")
+	}
+
+	if p.AggressionRadius < 0 {
+		fail("aggression_radius: must not be negative")
+	}
+	if p.RetreatHP < 0 || p.RetreatHP >= 1 {
+		fail("retreat_hp: must be at least 0 and below 1")
+	}
+	return errors.Join(errs...)
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
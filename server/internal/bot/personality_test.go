@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func TestLoadPersonalities(t *testing.T) {
+	personalities, err := LoadPersonalities("../../data/personalities.yaml")
+	require.NoError(t, err)
+
+	for _, name := range []string{"rusher", "economist", "turtle", "sniper_heavy"} {
+		require.Contains(t, personalities, name)
+		assert.Equal(t, name, personalities[name].Name)
+	}
+}
+
+func TestLoadPersonalities_ReportsEveryProblem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "personalities.yaml")
+	data := `
+personalities:
+  broken:
+    target_priority: {troops: -1}
+    buy_weights: {cavalry: 1}
+    aggression_radius: -2
+    retreat_hp: 1.5
+`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	_, err := LoadPersonalities(path)
+	require.Error(t, err)
+	for _, field := range []string{"target_priority", "buy_weights.cavalry", "aggression_radius", "retreat_hp"} {
+		assert.Contains(t, err.Error(), "personalities.broken."+field)
+	}
+}
+
+func newGreedyTestState(t *testing.T) *game.GameState {
+	t.Helper()
+	gs := newTestState(t, 1)
+	gs.Phase = model.PhasePlayerAction
+	gs.TurnNumber = 2
+	gs.Players[0].Coins = 0 // nothing to buy unless a test says so
+	return gs
+}
+
+func TestBot_TargetPriority(t *testing.T) {
+	gs := newGreedyTestState(t)
+	addTroop(t, gs, "p1", "mech", hex.NewCoord(0, 0, 0))
+	addTroop(t, gs, "p2", "marine", hex.NewCoord(1, 0, -1))
+	outpost, err := game.NewStructureFromBalance(gs, "s1", model.StructureOutpost, "", hex.NewCoord(-2, 0, 2))
+	require.NoError(t, err)
+	gs.AddStructure(outpost)
+
+	action := New("p1", DifficultyHard, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, hex.NewCoord(1, 0, -1), action.Target, "troops come first by default")
+
+	grabber := &Personality{Name: "grabber", TargetPriority: TargetPriority{Troops: 1, Structures: 2}}
+	action = NewWithPersonality("p1", DifficultyHard, grabber, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, outpost.Hex, action.Target)
+}
+
+func TestBot_BuyWeights(t *testing.T) {
+	gs := newGreedyTestState(t)
+	hq, err := game.NewStructureFromBalance(gs, "hq1", model.StructureHQ, "p1", hex.NewCoord(0, 5, -5))
+	require.NoError(t, err)
+	gs.AddStructure(hq)
+	gs.Players[0].Coins = 1000
+
+	snipers := &Personality{Name: "snipers", BuyWeights: map[string]float64{RoleRanged: 1}}
+	for seed := int64(0); seed < 10; seed++ {
+		action := NewWithPersonality("p1", DifficultyEasy, snipers, seed).NextAction(gs)
+		require.NotNil(t, action)
+		assert.Equal(t, game.BotActionBuy, action.Type)
+		assert.Equal(t, model.TroopType("sniper"), action.TroopType)
+	}
+}
+
+func TestBot_RetreatsWhenWounded(t *testing.T) {
+	gs := newGreedyTestState(t)
+	outpost, err := game.NewStructureFromBalance(gs, "s1", model.StructureOutpost, "p1", hex.NewCoord(0, 4, -4))
+	require.NoError(t, err)
+	gs.AddStructure(outpost)
+
+	marine := addTroop(t, gs, "p1", "marine", hex.NewCoord(0, 0, 0))
+	marine.CurrentHP = 3
+	addTroop(t, gs, "p2", "marine", hex.NewCoord(1, -1, 0))
+
+	// The default personality fights to the end
+	action := New("p1", DifficultyHard, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, game.BotActionAttack, action.Type)
+
+	cautious := &Personality{Name: "cautious", TargetPriority: TargetPriority{Troops: 1}, AggressionRadius: 5, RetreatHP: 0.5}
+	action = NewWithPersonality("p1", DifficultyHard, cautious, 1).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, game.BotActionMove, action.Type)
+	assert.Less(t, action.Target.Distance(outpost.Hex), marine.Hex.Distance(outpost.Hex))
+}
+
+func TestBot_RetreatTargetTiesGoToLowestID(t *testing.T) {
+	gs := newGreedyTestState(t)
+	for _, s := range []struct {
+		id  string
+		pos hex.Coord
+	}{{"s2", hex.NewCoord(4, 0, -4)}, {"s1", hex.NewCoord(-4, 0, 4)}, {"s3", hex.NewCoord(0, 4, -4)}} {
+		outpost, err := game.NewStructureFromBalance(gs, s.id, model.StructureOutpost, "p1", s.pos)
+		require.NoError(t, err)
+		gs.AddStructure(outpost)
+	}
+	marine := addTroop(t, gs, "p1", "marine", hex.NewCoord(0, 0, 0))
+
+	// All three are as near and as safe; map order must not decide
+	for i := 0; i < 20; i++ {
+		target, ok := New("p1", DifficultyHard, 1).retreatTarget(gs, marine)
+		require.True(t, ok)
+		assert.Equal(t, hex.NewCoord(-4, 0, 4), target)
+	}
+}
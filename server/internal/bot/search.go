@@ -0,0 +1,640 @@
+package bot
+
+import (
+	"cmp"
+	"log/slog"
+	"maps"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// DefaultThinkTime is how long the expert bot may think per turn unless configured otherwise.
+const DefaultThinkTime = 500 * time.Millisecond
+
+const (
+	attackSamples = 8 // dice rollers every attack is played out against
+	movesPerTroop = 4 // destinations searched per troop, besides those it can attack from
+	minCandidates = 4 // candidates still searched once the think time is spent
+	minGain       = 1 // score an action must add over ending the turn
+	crowdRadius   = 2 // troops this close to an objective are already on it...
+	crowdLimit    = 3 // ...and once this many are, it counts a hex further for each more
+)
+
+// Evaluation weights, in coins.
+const (
+	winScore        = 1e6
+	coinWeight      = 0.8  // unspent coins are worth less than the troops they buy
+	hqWeight        = 1000 // per HQ, plus as much again scaled by its HP
+	structureWeight = 200  // per structure, plus its income over incomeTurns
+	incomeTurns     = 8
+	dominanceWeight = 400  // for holding most structures, per turn held
+	advanceWeight   = 4    // per hex closer to the nearest objective
+	threatWeight    = 0.6  // share of a troop's value lost when it is sure to die next turn
+	blockWeight     = 50   // per own spawner a troop stands on, keeping purchases out
+	rangeBonus      = 0.15 // extra rating per hex of range beyond melee
+)
+
+// SearchBot implements game.BotPlayer by trying every candidate action on a copy of the
+// game state and playing whichever leaves the best position. Attacks are played out against
+// the same set of seeded dice rollers, so all candidates face the same luck, and the
+// opponent's reply is estimated from the damage its troops and structures can deal next turn.
+type SearchBot struct {
+	id        string
+	thinkTime time.Duration
+	rng       *rand.Rand
+	logger    *slog.Logger
+
+	turnNumber int
+	deadline   time.Time // end of the think time for the current turn
+
+	ratings      map[model.TroopType]float64
+	ratingsRules *config.BalanceData // ruleset the ratings were computed for
+}
+
+// NewSearch creates a search bot that thinks for at most thinkTime per turn. Once that is
+// spent, each further action of the turn only considers its most promising candidates.
+func NewSearch(playerID string, seed int64, thinkTime time.Duration) *SearchBot {
+	if thinkTime <= 0 {
+		thinkTime = DefaultThinkTime
+	}
+	return &SearchBot{
+		id:        playerID,
+		thinkTime: thinkTime,
+		rng:       rand.New(rand.NewSource(seed)),
+		logger:    slog.Default().With("component", "bot", "player_id", playerID, "difficulty", DifficultyExpert),
+	}
+}
+
+// PlayerID returns the bot's player ID.
+func (b *SearchBot) PlayerID() string {
+	return b.id
+}
+
+// NextAction returns the best action found, or nil when no action improves on ending the turn.
+func (b *SearchBot) NextAction(gs *game.GameState) *game.BotAction {
+	if gs.TurnNumber != b.turnNumber {
+		b.turnNumber = gs.TurnNumber
+		b.deadline = time.Now().Add(b.thinkTime)
+	}
+
+	s := b.newSearch(gs)
+	if s == nil {
+		return nil
+	}
+
+	var best *game.BotAction
+	baseline := s.evaluate(gs)
+	bestScore := baseline + minGain
+	candidates := s.candidates()
+	searched := 0
+	for _, c := range candidates {
+		if searched >= minCandidates && time.Now().After(b.deadline) {
+			break
+		}
+		searched++
+		if score := s.score(c); score > bestScore {
+			best, bestScore = c.action, score
+		}
+	}
+
+	// Out of time: rather than leave troops idle, make the best move by its estimate
+	if best == nil {
+		for _, c := range candidates[searched:] {
+			if c.action.Type == game.BotActionMove && c.estimate > minGain {
+				best, bestScore = c.action, baseline+c.estimate
+				break
+			}
+		}
+	}
+
+	if best != nil {
+		b.logger.Debug("bot search",
+			"action", best.Type, "unit_id", best.UnitID, "troop_type", best.TroopType,
+			"gain", bestScore-baseline, "searched", searched, "candidates", len(candidates))
+	}
+	return best
+}
+
+// search holds what one decision needs: the position, the opponent's threat and the dice.
+type search struct {
+	gs      *game.GameState
+	me, opp string
+	seeds   []int64                       // roller seeds shared by all candidates
+	threats map[string]map[hex.Coord]bool // enemy unit ID -> hexes it can attack next turn
+	damage  map[damageKey]float64         // expected damage, by attacker and target position
+	ratings map[model.TroopType]float64   // what each troop type is worth, see rateTroops
+	reach   float64                       // longest distance on the map
+}
+
+// damageKey identifies an attack for the expected damage cache. Enemy troops don't move
+// during the bot's turn, but structures change hands.
+type damageKey struct {
+	from, to, owner string
+	at              hex.Coord
+}
+
+// candidate is an action to search. For moves, estimate is the gain in the troop's own
+// score, which orders the search and stands in for it when time runs short.
+type candidate struct {
+	action   *game.BotAction
+	estimate float64
+	followUp bool // for moves: also try the unit's attacks from its destination
+}
+
+func (b *SearchBot) newSearch(gs *game.GameState) *search {
+	idx := gs.PlayerIndex(b.id)
+	if idx < 0 {
+		return nil
+	}
+	s := &search{
+		gs:      gs,
+		me:      b.id,
+		opp:     gs.Players[1-idx].ID,
+		seeds:   make([]int64, attackSamples),
+		damage:  make(map[damageKey]float64),
+		ratings: b.troopRatings(gs),
+		reach:   float64(2*gs.MapSize.Radius() + 1),
+	}
+	for i := range s.seeds {
+		s.seeds[i] = b.rng.Int63()
+	}
+
+	// Where each enemy troop can strike from next turn, at full mobility
+	s.threats = make(map[string]map[hex.Coord]bool)
+	for _, e := range sortedTroops(gs) {
+		if e.OwnerID == b.id {
+			continue
+		}
+		probe := *e
+		probe.RemainingMobility = probe.Mobility
+		zone := make(map[hex.Coord]bool)
+		for _, from := range append(slices.Collect(maps.Keys(game.ReachableHexes(gs, &probe))), e.Hex) {
+			for _, h := range from.Spiral(e.Range) {
+				zone[h] = true
+			}
+		}
+		s.threats[e.ID] = zone
+	}
+	return s
+}
+
+// troopRatings returns the ratings for the game's ruleset, computing them once per game.
+func (b *SearchBot) troopRatings(gs *game.GameState) map[model.TroopType]float64 {
+	if b.ratings == nil || b.ratingsRules != gs.Rules {
+		b.ratings, b.ratingsRules = rateTroops(gs), gs.Rules
+	}
+	return b.ratings
+}
+
+// ---------------------------------------------------------------------------
+// Candidates
+// ---------------------------------------------------------------------------
+
+// candidates lists the actions worth searching, attacks first, then purchases, then moves
+// ordered by how good their destination looks on its own.
+func (s *search) candidates() []candidate {
+	var attacks, buys, moves []candidate
+
+	canAttack := !(s.gs.FirstTurnRestriction && s.gs.TurnNumber == 1 && s.gs.ActivePlayer == 0)
+	for _, t := range s.gs.PlayerTroops(s.me) {
+		if canAttack && t.CanAttack() {
+			for _, a := range s.attacks(s.gs, t) {
+				attacks = append(attacks, candidate{action: a})
+			}
+		}
+	}
+	buys = s.buys()
+	for _, t := range s.gs.PlayerTroops(s.me) {
+		if t.CanMove() {
+			moves = append(moves, s.moves(t, canAttack && t.CanAttack())...)
+		}
+	}
+
+	byAction := func(a, b candidate) int {
+		return cmp.Or(
+			cmp.Compare(a.action.UnitID, b.action.UnitID),
+			cmp.Compare(a.action.Target.Q, b.action.Target.Q),
+			cmp.Compare(a.action.Target.R, b.action.Target.R),
+			cmp.Compare(a.action.TroopType, b.action.TroopType),
+		)
+	}
+	slices.SortFunc(attacks, byAction)
+	slices.SortFunc(moves, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(b.estimate, a.estimate), byAction(a, b))
+	})
+	return append(append(attacks, buys...), moves...)
+}
+
+// attacks lists the troop's attacks on enemy troops and on structures it doesn't own.
+func (s *search) attacks(gs *game.GameState, t *model.Troop) []*game.BotAction {
+	var actions []*game.BotAction
+	for _, h := range t.Hex.Spiral(t.Range) {
+		if h == t.Hex {
+			continue
+		}
+		target := false
+		if enemy := gs.TroopAtHex(h); enemy != nil {
+			target = enemy.OwnerID != s.me
+		} else if st := gs.StructureAtHex(h); st != nil {
+			target = !st.IsOwnedBy(s.me)
+		}
+		if target {
+			actions = append(actions, &game.BotAction{Type: game.BotActionAttack, UnitID: t.ID, Target: h})
+		}
+	}
+	return actions
+}
+
+// buys lists one purchase per affordable troop type, at the free spawner nearest the enemy HQ.
+func (s *search) buys() []candidate {
+	coins := s.gs.Players[s.gs.PlayerIndex(s.me)].Coins
+	var enemyHQ hex.Coord
+	if hq := s.gs.PlayerHQ(s.opp); hq != nil {
+		enemyHQ = hq.Hex
+	}
+
+	var spawner *model.Structure
+	for _, st := range s.gs.PlayerStructures(s.me) {
+		if !st.CanSpawn || s.gs.TroopAtHex(st.Hex) != nil {
+			continue
+		}
+		if spawner == nil || cmp.Or(
+			cmp.Compare(st.Hex.Distance(enemyHQ), spawner.Hex.Distance(enemyHQ)),
+			cmp.Compare(st.ID, spawner.ID),
+		) < 0 {
+			spawner = st
+		}
+	}
+	if spawner == nil {
+		return nil
+	}
+
+	var buys []candidate
+	for _, t := range game.TroopTypes(s.gs) {
+		if cost := game.TroopCost(s.gs, t); cost > 0 && cost <= coins {
+			buys = append(buys, candidate{action: &game.BotAction{
+				Type:        game.BotActionBuy,
+				TroopType:   t,
+				StructureID: spawner.ID,
+			}})
+		}
+	}
+	return buys
+}
+
+// moves ranks the troop's destinations by the gain in its score, and keeps the best few
+// plus, if it can still attack, the best two hexes to attack each target from.
+func (s *search) moves(t *model.Troop, canAttack bool) []candidate {
+	probe := s.gs.Clone()
+	pt := probe.GetTroop(t.ID)
+	p := s.position(probe)
+	here := s.troopScore(p, pt)
+
+	type destination struct {
+		hex   hex.Coord
+		score float64
+	}
+	var dests []destination
+	for h := range game.ReachableHexes(s.gs, t) {
+		pt.Hex = h
+		dests = append(dests, destination{h, s.troopScore(p, pt) - here})
+	}
+	slices.SortFunc(dests, func(a, b destination) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.hex.Q, b.hex.Q), cmp.Compare(a.hex.R, b.hex.R))
+	})
+
+	keep := make(map[hex.Coord]bool)
+	for i := 0; i < len(dests) && i < movesPerTroop; i++ {
+		keep[dests[i].hex] = true
+	}
+	if canAttack {
+		fromTarget := make(map[hex.Coord]int)
+		for _, d := range dests {
+			pt.Hex = d.hex
+			for _, a := range s.attacks(probe, pt) {
+				if fromTarget[a.Target] < 2 {
+					fromTarget[a.Target]++
+					keep[d.hex] = true
+				}
+			}
+		}
+	}
+
+	var moves []candidate
+	for _, d := range dests {
+		if keep[d.hex] {
+			moves = append(moves, candidate{
+				action:   &game.BotAction{Type: game.BotActionMove, UnitID: t.ID, Target: d.hex},
+				estimate: d.score,
+				followUp: canAttack,
+			})
+		}
+	}
+	return moves
+}
+
+// ---------------------------------------------------------------------------
+// Scoring
+// ---------------------------------------------------------------------------
+
+// score plays the candidate on a copy of the state and evaluates the result.
+func (s *search) score(c candidate) float64 {
+	if c.action.Type == game.BotActionAttack {
+		return s.scoreAttack(s.gs, c.action)
+	}
+
+	// Moves and purchases roll no dice
+	next := s.gs.Clone()
+	if result := game.ExecuteBotAction(next, nil, s.me, c.action); !result.Ack {
+		return math.Inf(-1)
+	}
+	score := s.evaluate(next)
+	if c.followUp {
+		for _, a := range s.attacks(next, next.GetTroop(c.action.UnitID)) {
+			score = max(score, s.scoreAttack(next, a))
+		}
+	}
+	return score
+}
+
+// scoreAttack returns the average score of the attack over the search's dice rollers.
+func (s *search) scoreAttack(gs *game.GameState, action *game.BotAction) float64 {
+	var total float64
+	for _, seed := range s.seeds {
+		next := gs.Clone()
+		result := game.ExecuteAttack(next, dice.NewRoller(seed), s.me, action.UnitID, action.Target)
+		switch {
+		case !result.Ack:
+			return math.Inf(-1)
+		case result.GameOver != nil && result.GameOver.WinnerID == s.me:
+			total += winScore
+		case result.GameOver != nil:
+			total -= winScore
+		default:
+			total += s.evaluate(next)
+		}
+	}
+	return total / float64(len(s.seeds))
+}
+
+// position is a game state prepared for evaluation.
+type position struct {
+	gs         *game.GameState
+	troops     []*model.Troop     // living troops, in ID order
+	structures []*model.Structure // in ID order
+	exposed    map[string]int     // enemy unit ID -> bot troops it can strike next turn
+}
+
+func (s *search) position(gs *game.GameState) *position {
+	p := &position{
+		gs:         gs,
+		troops:     sortedTroops(gs),
+		structures: sortedStructures(gs),
+		exposed:    make(map[string]int),
+	}
+	for _, e := range p.troops {
+		if e.OwnerID == s.me {
+			continue
+		}
+		for _, t := range p.troops {
+			if t.OwnerID == s.me && s.threats[e.ID][t.Hex] {
+				p.exposed[e.ID]++
+			}
+		}
+	}
+	return p
+}
+
+// evaluate scores a position from the bot's side: material, structures and progress
+// towards them, less the troops the opponent threatens to kill next turn.
+func (s *search) evaluate(gs *game.GameState) float64 {
+	p := s.position(gs)
+	me, opp := &gs.Players[gs.PlayerIndex(s.me)], &gs.Players[gs.PlayerIndex(s.opp)]
+	score := coinWeight * float64(me.Coins-opp.Coins)
+
+	var mine, theirs int
+	for _, st := range p.structures {
+		v := structureValue(st)
+		switch {
+		case st.IsOwnedBy(s.me):
+			score += v
+			mine++
+		case st.OwnerID == s.opp:
+			score -= v
+			theirs++
+		default:
+			// Damage to a neutral structure is progress towards capturing it
+			score += v / 2 * (1 - fraction(st.CurrentHP, st.MaxHP))
+		}
+	}
+
+	// Holding most structures wins once held for long enough
+	if mine > len(p.structures)/2 {
+		score += dominanceWeight * float64(1+me.DominanceTurnCounter)
+	}
+	if theirs > len(p.structures)/2 {
+		score -= dominanceWeight * float64(1+opp.DominanceTurnCounter)
+	}
+
+	for _, t := range p.troops {
+		if t.OwnerID == s.me {
+			score += s.troopScore(p, t)
+		} else {
+			score -= s.troopValue(t)
+		}
+	}
+	return score
+}
+
+// troopScore is what one of the bot's troops adds to the evaluation: its value and
+// position, less what it stands to lose next turn.
+func (s *search) troopScore(p *position, t *model.Troop) float64 {
+	v := s.troopValue(t)
+	score := v + advanceWeight*(s.reach-s.objectiveDistance(p, t))
+	if st := p.gs.StructureAtHex(t.Hex); st != nil && st.CanSpawn && st.IsOwnedBy(s.me) {
+		score -= blockWeight
+	}
+	if t.CurrentHP > 0 {
+		score -= threatWeight * v * math.Min(1, s.incomingDamage(p, t)/float64(t.CurrentHP))
+	}
+	return score
+}
+
+// objectiveDistance returns the distance to the nearest structure the bot doesn't own.
+// Structures that enough of the bot's other troops are already close to count as further
+// away, so the army spreads over the objectives instead of queueing for one.
+func (s *search) objectiveDistance(p *position, t *model.Troop) float64 {
+	nearest := s.reach
+	for _, st := range p.structures {
+		if st.IsOwnedBy(s.me) {
+			continue
+		}
+		crowd := 0
+		for _, other := range p.troops {
+			if other != t && other.OwnerID == s.me && other.Hex.Distance(st.Hex) <= crowdRadius {
+				crowd++
+			}
+		}
+		nearest = min(nearest, float64(t.Hex.Distance(st.Hex)+max(0, crowd-crowdLimit)))
+	}
+	return nearest
+}
+
+// incomingDamage estimates the damage the troop takes before the bot's next turn: from
+// the structure fire that ends this turn, the enemy troops that can reach it and the
+// sudden-death storm. An enemy troop attacks once, so its damage is shared between all
+// the bot's troops it can reach.
+func (s *search) incomingDamage(p *position, t *model.Troop) float64 {
+	damage := stormDamage(p.gs, t.Hex)
+	for _, e := range p.troops {
+		if e.OwnerID != s.me && s.threats[e.ID][t.Hex] {
+			damage += s.expectedDamage(damageKey{from: e.ID, to: t.ID, at: t.Hex}, func() float64 {
+				return game.ExpectedDamage(p.gs, e, t)
+			}) / float64(p.exposed[e.ID])
+		}
+	}
+
+	// A structure fires at the nearest troop it may target, picking at random among ties
+	for _, st := range p.structures {
+		if st.IsOwnedBy(s.me) || t.Hex.Distance(st.Hex) > st.Range {
+			continue
+		}
+		dist, nearest, ties := t.Hex.Distance(st.Hex), true, 0
+		for _, other := range p.troops {
+			if !st.IsNeutral() && other.OwnerID == st.OwnerID {
+				continue
+			}
+			if d := other.Hex.Distance(st.Hex); d < dist {
+				nearest = false
+				break
+			} else if d == dist {
+				ties++
+			}
+		}
+		if nearest {
+			damage += s.expectedDamage(damageKey{from: st.ID, to: t.ID, owner: st.OwnerID, at: t.Hex}, func() float64 {
+				return game.ExpectedStructureFire(p.gs, st, t)
+			}) / float64(ties)
+		}
+	}
+	return damage
+}
+
+// expectedDamage returns the cached expected damage of an attack, computing it on first use.
+func (s *search) expectedDamage(key damageKey, compute func() float64) float64 {
+	d, ok := s.damage[key]
+	if !ok {
+		d = compute()
+		s.damage[key] = d
+	}
+	return d
+}
+
+// stormDamage is the sudden-death damage a troop at h takes before the bot's next turn:
+// the safe zone shrinks and the storm strikes at the start of each of the two turns.
+func stormDamage(gs *game.GameState, h hex.Coord) float64 {
+	shrink := 1
+	if gs.Rules != nil {
+		shrink = gs.Rules.SuddenDeath.ShrinkRate
+	}
+	radius, damage := gs.SafeZoneRadius, 0
+	for turn := gs.TurnNumber + 1; turn <= gs.TurnNumber+2; turn++ {
+		stormTurn := turn - game.SuddenDeathThreshold(gs)
+		if stormTurn <= 0 {
+			continue
+		}
+		radius = max(1, radius-shrink)
+		if h.DistanceToOrigin() > radius {
+			damage += stormTurn
+		}
+	}
+	return float64(damage)
+}
+
+// troopValue is the troop's rating, scaled down as it loses HP.
+func (s *search) troopValue(t *model.Troop) float64 {
+	return s.ratings[t.Type] * (0.4 + 0.6*fraction(t.CurrentHP, t.MaxHP))
+}
+
+// rateTroops rates each troop type in the roster by how much fighting it does: the damage
+// it deals per attack, more for ranged troops, times the attacks it survives, both against
+// the average of the roster. Ratings are scaled to the roster's average cost, so a troop
+// that is cheap for its stats is worth more than it costs.
+func rateTroops(gs *game.GameState) map[model.TroopType]float64 {
+	types := game.TroopTypes(gs)
+	scratch := &game.GameState{Rules: gs.Rules} // no terrain: everyone stands on plains
+	roster := make([]*model.Troop, 0, len(types))
+	for _, tt := range types {
+		if t, err := game.NewTroopFromBalance(scratch, "", tt, "", hex.Coord{}); err == nil {
+			roster = append(roster, t)
+		}
+	}
+
+	ratings := make(map[model.TroopType]float64, len(roster))
+	var totalRating, totalCost float64
+	for _, t := range roster {
+		var dealt, taken float64
+		for _, other := range roster {
+			dealt += game.ExpectedDamage(scratch, t, other)
+			taken += game.ExpectedDamage(scratch, other, t)
+		}
+		if taken <= 0 {
+			taken = 1
+		}
+		dealt *= 1 + rangeBonus*float64(t.Range-1)
+		ratings[t.Type] = math.Sqrt(dealt * float64(t.MaxHP) / taken)
+		totalRating += ratings[t.Type]
+		totalCost += float64(game.TroopCost(gs, t.Type))
+	}
+	for tt := range ratings {
+		if totalRating > 0 {
+			ratings[tt] *= totalCost / totalRating
+		}
+	}
+	return ratings
+}
+
+// structureValue weighs what holding the structure is worth, scaled down as it loses HP.
+// Losing an HQ loses the game, so HQs are worth far more than anything else.
+func structureValue(st *model.Structure) float64 {
+	hp := fraction(st.CurrentHP, st.MaxHP)
+	if st.Type == model.StructureHQ {
+		return hqWeight * (1 + hp)
+	}
+	return (structureWeight + incomeTurns*float64(st.Income)) * (0.5 + 0.5*hp)
+}
+
+func fraction(a, b int) float64 {
+	if b <= 0 {
+		return 1
+	}
+	return float64(a) / float64(b)
+}
+
+// sortedTroops returns the living troops in ID order, so scores don't depend on map order.
+func sortedTroops(gs *game.GameState) []*model.Troop {
+	troops := make([]*model.Troop, 0, len(gs.Troops))
+	for _, id := range slices.Sorted(maps.Keys(gs.Troops)) {
+		if t := gs.Troops[id]; t.IsAlive() {
+			troops = append(troops, t)
+		}
+	}
+	return troops
+}
+
+// sortedStructures returns the structures in ID order.
+func sortedStructures(gs *game.GameState) []*model.Structure {
+	structures := make([]*model.Structure, 0, len(gs.Structures))
+	for _, id := range slices.Sorted(maps.Keys(gs.Structures)) {
+		structures = append(structures, gs.Structures[id])
+	}
+	return structures
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/game"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/mapgen"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// Long enough that the search always completes, so results don't depend on the machine.
+const testThinkTime = time.Minute
+
+func newTestState(t *testing.T, seed int64) *game.GameState {
+	t.Helper()
+	balance, err := config.LoadBalance("../../data/balance.yaml")
+	require.NoError(t, err)
+	game.LoadBalance(balance)
+
+	settings := model.RoomSettings{MapSize: model.MapSizeSmall, TurnTimer: 90, TurnMode: model.TurnModeAlternating}
+	return game.NewGameState("bot_test", settings, model.PlayerState{ID: "p1"}, model.PlayerState{ID: "p2"}, seed)
+}
+
+func addTroop(t *testing.T, gs *game.GameState, owner string, troopType model.TroopType, pos hex.Coord) *model.Troop {
+	t.Helper()
+	troop, err := game.NewTroopFromBalance(gs, gs.NextUnitID(), troopType, owner, pos)
+	require.NoError(t, err)
+	troop.IsReady = true
+	gs.AddTroop(troop)
+	return troop
+}
+
+func TestSearchBot_FinishesWoundedTroop(t *testing.T) {
+	gs := newTestState(t, 1)
+	gs.Phase = model.PhasePlayerAction
+	gs.TurnNumber = 2
+	gs.Players[0].Coins = 0 // nothing to buy instead
+	hq, err := game.NewStructureFromBalance(gs, "hq1", model.StructureHQ, "p1", hex.NewCoord(0, 5, -5))
+	require.NoError(t, err)
+	gs.AddStructure(hq)
+	hq, err = game.NewStructureFromBalance(gs, "hq2", model.StructureHQ, "p2", hex.NewCoord(0, -5, 5))
+	require.NoError(t, err)
+	gs.AddStructure(hq)
+
+	mech := addTroop(t, gs, "p1", "mech", hex.NewCoord(0, 0, 0))
+	mech.HasMoved = true // attacking is its only option
+	addTroop(t, gs, "p2", "marine", hex.NewCoord(2, 0, -2))
+	wounded := addTroop(t, gs, "p2", "marine", hex.NewCoord(-2, 0, 2))
+	wounded.CurrentHP = 2
+
+	action := NewSearch("p1", 1, testThinkTime).NextAction(gs)
+	require.NotNil(t, action)
+	assert.Equal(t, game.BotActionAttack, action.Type)
+	assert.Equal(t, wounded.Hex, action.Target)
+}
+
+func TestSearchBot_BeatsGreedyBot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("plays full games")
+	}
+	for _, seed := range []int64{1} {
+		gs := newTestState(t, seed)
+		m, err := mapgen.Generate(gs.MapSize, seed, gs.Rules)
+		require.NoError(t, err)
+		n := 0
+		require.NoError(t, game.ApplyMap(gs, m, func() string { n++; return string(rune('a' + n)) }))
+		gs.Phase = model.PhaseGameStarted
+
+		// The search bot moves second
+		bots := [2]game.BotPlayer{New("p1", DifficultyHard, seed), NewSearch("p2", seed, testThinkTime)}
+		result, err := game.PlayMatch(gs, dice.NewRoller(seed), bots, 200)
+		require.NoError(t, err)
+		assert.Equal(t, "p2", result.GameOver.WinnerID, "seed %d: %s", seed, result.GameOver.Reason)
+	}
+}
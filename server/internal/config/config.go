@@ -1,6 +1,8 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strings"
@@ -16,11 +18,17 @@ type Config struct {
 	LogLevel             string        `json:"log_level"`
 	CORSOrigins          []string      `json:"cors_origins"`
 	BalanceFile          string        `json:"balance_file"`
+	BotPersonalitiesFile string        `json:"bot_personalities_file"`
 	WSPingInterval       time.Duration `json:"ws_ping_interval"`
 	WSPongTimeout        time.Duration `json:"ws_pong_timeout"`
 	ReconnectTimeout     time.Duration `json:"reconnect_timeout"`
 	RoomTTL              time.Duration `json:"room_ttl"`
 	ShutdownDrainTimeout time.Duration `json:"shutdown_drain_timeout"`
+	BotThinkTime         time.Duration `json:"bot_think_time"`       // per turn, for expert bots
+	BotTakeoverTimeout   time.Duration `json:"bot_takeover_timeout"` // reconnect cap while a bot plays for a player
+	SeedWindow           time.Duration `json:"seed_window"`          // for client seeds, once both players joined
+	AdminToken           string        `json:"-"`                    // enables the admin endpoints when set
+	TrustProxy           bool          `json:"trust_proxy"`          // rate limit by X-Real-IP instead of the remote address
 }
 
 // Load reads configuration from environment variables with sensible defaults.
@@ -31,11 +39,17 @@ func Load() *Config {
 		LogLevel:             envOrDefault("LOG_LEVEL", "INFO"),
 		CORSOrigins:          strings.Split(envOrDefault("CORS_ORIGINS", "*"), ","),
 		BalanceFile:          envOrDefault("BALANCE_FILE", "data/balance.yaml"),
+		BotPersonalitiesFile: envOrDefault("BOT_PERSONALITIES_FILE", "data/personalities.yaml"),
 		WSPingInterval:       durationOrDefault("WS_PING_INTERVAL", 15*time.Second),
 		WSPongTimeout:        durationOrDefault("WS_PONG_TIMEOUT", 10*time.Second),
 		ReconnectTimeout:     durationOrDefault("RECONNECT_TIMEOUT", 60*time.Second),
 		RoomTTL:              durationOrDefault("ROOM_TTL", 5*time.Minute),
 		ShutdownDrainTimeout: durationOrDefault("SHUTDOWN_DRAIN_TIMEOUT", 30*time.Second),
+		BotThinkTime:         durationOrDefault("BOT_THINK_TIME", 500*time.Millisecond),
+		BotTakeoverTimeout:   durationOrDefault("BOT_TAKEOVER_TIMEOUT", 10*time.Minute),
+		SeedWindow:           durationOrDefault("SEED_WINDOW", 5*time.Second),
+		AdminToken:           os.Getenv("ADMIN_TOKEN"),
+		TrustProxy:           os.Getenv("TRUST_PROXY") == "true",
 	}
 }
 
@@ -58,88 +72,101 @@ func durationOrDefault(key string, defaultVal time.Duration) time.Duration {
 
 // BalanceData holds all game balance constants loaded from YAML.
 type BalanceData struct {
-	Economy     EconomyConfig              `yaml:"economy"`
-	Troops      map[string]TroopConfig     `yaml:"troops"`
-	Structures  map[string]StructureConfig `yaml:"structures"`
-	NeutralMod  NeutralModConfig           `yaml:"neutral_modifiers"`
-	Terrain     map[string]TerrainConfig   `yaml:"terrain"`
-	Healing     HealingConfig              `yaml:"healing"`
-	SuddenDeath SuddenDeathConfig          `yaml:"sudden_death"`
-	MapGen      MapGenConfig               `yaml:"map_generation"`
-	Matchmaking MatchmakingConfig          `yaml:"matchmaking"`
-	WinCond     WinCondConfig              `yaml:"win_conditions"`
+	// Version identifies the ruleset: a hash of the balance file, plus a suffix
+	// for rooms with custom rules. Not read from the file.
+	Version string `yaml:"-" json:"version"`
+
+	Economy     EconomyConfig              `yaml:"economy" json:"economy"`
+	Troops      map[string]TroopConfig     `yaml:"troops" json:"troops"`
+	Structures  map[string]StructureConfig `yaml:"structures" json:"structures"`
+	NeutralMod  NeutralModConfig           `yaml:"neutral_modifiers" json:"neutral_modifiers"`
+	Terrain     map[string]TerrainConfig   `yaml:"terrain" json:"terrain"`
+	Healing     HealingConfig              `yaml:"healing" json:"healing"`
+	SuddenDeath SuddenDeathConfig          `yaml:"sudden_death" json:"sudden_death"`
+	MapGen      MapGenConfig               `yaml:"map_generation" json:"map_generation"`
+	Matchmaking MatchmakingConfig          `yaml:"matchmaking" json:"matchmaking"`
+	WinCond     WinCondConfig              `yaml:"win_conditions" json:"win_conditions"`
 }
 
 type EconomyConfig struct {
-	StartingCoins   int `yaml:"starting_coins"`
-	PassiveIncome   int `yaml:"passive_income"`
-	StructureIncome int `yaml:"structure_income"`
+	StartingCoins   int `yaml:"starting_coins" json:"starting_coins"`
+	PassiveIncome   int `yaml:"passive_income" json:"passive_income"`
+	StructureIncome int `yaml:"structure_income" json:"structure_income"`
 }
 
 type TroopConfig struct {
-	Cost                    int    `yaml:"cost"`
-	HP                      int    `yaml:"hp"`
-	ATK                     int    `yaml:"atk"`
-	DEF                     int    `yaml:"def"`
-	Mobility                int    `yaml:"mobility"`
-	Range                   int    `yaml:"range"`
-	Damage                  string `yaml:"damage"`
-	AntiStructureMultiplier int    `yaml:"anti_structure_multiplier,omitempty"`
+	Cost                    int      `yaml:"cost" json:"cost"`
+	HP                      int      `yaml:"hp" json:"hp"`
+	ATK                     int      `yaml:"atk" json:"atk"`
+	DEF                     int      `yaml:"def" json:"def"`
+	Mobility                int      `yaml:"mobility" json:"mobility"`
+	Range                   int      `yaml:"range" json:"range"`
+	Damage                  string   `yaml:"damage" json:"damage"`
+	Vision                  int      `yaml:"vision" json:"vision"`
+	Roles                   []string `yaml:"roles" json:"roles"` // tags the bot uses to pick troops, e.g. "infantry"
+	AntiStructureMultiplier int      `yaml:"anti_structure_multiplier,omitempty" json:"anti_structure_multiplier,omitempty"`
 }
 
 type StructureConfig struct {
-	HP     int    `yaml:"hp"`
-	ATK    int    `yaml:"atk"`
-	DEF    int    `yaml:"def"`
-	Range  int    `yaml:"range"`
-	Damage string `yaml:"damage"`
-	Income int    `yaml:"income"`
-	Spawn  bool   `yaml:"spawn"`
+	HP     int    `yaml:"hp" json:"hp"`
+	ATK    int    `yaml:"atk" json:"atk"`
+	DEF    int    `yaml:"def" json:"def"`
+	Range  int    `yaml:"range" json:"range"`
+	Damage string `yaml:"damage" json:"damage"`
+	Vision int    `yaml:"vision" json:"vision"`
+	Income int    `yaml:"income" json:"income"`
+	Spawn  bool   `yaml:"spawn" json:"spawn"`
 }
 
 type NeutralModConfig struct {
-	ATKReduction   int `yaml:"atk_reduction"`
-	DamageStepDown int `yaml:"damage_step_down"`
+	ATKReduction   int `yaml:"atk_reduction" json:"atk_reduction"`
+	DamageStepDown int `yaml:"damage_step_down" json:"damage_step_down"`
 }
 
 type TerrainConfig struct {
-	MovementCost int   `yaml:"movement_cost,omitempty"`
-	ATKModifier  int   `yaml:"atk_modifier,omitempty"`
-	DEFModifier  int   `yaml:"def_modifier,omitempty"`
-	Passable     *bool `yaml:"passable,omitempty"` // nil means passable (default true)
+	MovementCost int   `yaml:"movement_cost,omitempty" json:"movement_cost,omitempty"`
+	ATKModifier  int   `yaml:"atk_modifier,omitempty" json:"atk_modifier,omitempty"`
+	DEFModifier  int   `yaml:"def_modifier,omitempty" json:"def_modifier,omitempty"`
+	Passable     *bool `yaml:"passable,omitempty" json:"passable,omitempty"` // nil means passable (default true)
+}
+
+// IsPassable returns whether units can enter the terrain. Terrain is passable unless stated otherwise.
+func (tc TerrainConfig) IsPassable() bool {
+	return tc.Passable == nil || *tc.Passable
 }
 
 type HealingConfig struct {
-	PassiveRate int `yaml:"passive_rate"`
+	PassiveRate int `yaml:"passive_rate" json:"passive_rate"`
 }
 
 type SuddenDeathConfig struct {
-	TurnThresholds map[string]int `yaml:"turn_thresholds"`
-	ShrinkRate     int            `yaml:"shrink_rate"`
+	TurnThresholds map[string]int `yaml:"turn_thresholds" json:"turn_thresholds"`
+	ShrinkRate     int            `yaml:"shrink_rate" json:"shrink_rate"`
 }
 
 type MapGenConfig struct {
-	NoiseThresholds  map[string]float64 `yaml:"noise_thresholds"`
-	StructureCounts  map[string]int     `yaml:"structure_counts"`
-	MinPassableRatio float64            `yaml:"min_passable_ratio"`
-	MaxRetries       int                `yaml:"max_retries"`
+	NoiseThresholds  map[string]float64 `yaml:"noise_thresholds" json:"noise_thresholds"`
+	StructureCounts  map[string]int     `yaml:"structure_counts" json:"structure_counts"`
+	MinPassableRatio float64            `yaml:"min_passable_ratio" json:"min_passable_ratio"`
+	MaxRetries       int                `yaml:"max_retries" json:"max_retries"`
 }
 
 type MatchmakingConfig struct {
-	QuickMatchDefaults QuickMatchDefaults `yaml:"quick_match_defaults"`
+	QuickMatchDefaults QuickMatchDefaults `yaml:"quick_match_defaults" json:"quick_match_defaults"`
 }
 
 type QuickMatchDefaults struct {
-	MapSize   string `yaml:"map_size"`
-	TurnTimer int    `yaml:"turn_timer"`
-	TurnMode  string `yaml:"turn_mode"`
+	MapSize   string `yaml:"map_size" json:"map_size"`
+	TurnTimer int    `yaml:"turn_timer" json:"turn_timer"`
+	TurnMode  string `yaml:"turn_mode" json:"turn_mode"`
 }
 
 type WinCondConfig struct {
-	DominanceTurnsRequired int `yaml:"dominance_turns_required"`
+	DominanceTurnsRequired int `yaml:"dominance_turns_required" json:"dominance_turns_required"`
+	SurrenderAfterTurn     int `yaml:"surrender_after_turn" json:"surrender_after_turn"`
 }
 
-// LoadBalance reads and parses the balance YAML file.
+// LoadBalance reads, parses and validates the balance YAML file.
 func LoadBalance(path string) (*BalanceData, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -151,5 +178,12 @@ func LoadBalance(path string) (*BalanceData, error) {
 		return nil, fmt.Errorf("config: failed to parse balance file %s: %w", path, err)
 	}
 
+	if err := balance.Validate(); err != nil {
+		return nil, fmt.Errorf("config: invalid balance file %s:\n%w", path, err)
+	}
+
+	sum := sha256.Sum256(data)
+	balance.Version = hex.EncodeToString(sum[:])[:12]
+
 	return &balance, nil
 }
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// Validate checks the balance data for mistakes that would otherwise only show up
+// mid-game, such as unparseable dice notation or a map size with no sudden-death turn.
+// Every problem found is reported, one per joined error.
+func (b *BalanceData) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if b.Economy.StartingCoins < 0 || b.Economy.PassiveIncome < 0 || b.Economy.StructureIncome < 0 {
+		fail("economy: coin amounts must not be negative")
+	}
+
+	// Troops: the roster is data-driven, but it must not be empty and every entry must be playable
+	if len(b.Troops) == 0 {
+		fail("troops: roster is empty")
+	}
+	for _, name := range sortedKeys(b.Troops) {
+		tc := b.Troops[name]
+		if tc.Cost <= 0 {
+			fail("troops.%s.cost: must be positive", name)
+		}
+		if tc.HP <= 0 {
+			fail("troops.%s.hp: must be positive", name)
+		}
+		if tc.Mobility <= 0 {
+			fail("troops.%s.mobility: must be positive", name)
+		}
+		if tc.Range <= 0 {
+			fail("troops.%s.range: must be positive", name)
+		}
+		if tc.Vision < 0 {
+			fail("troops.%s.vision: must not be negative", name)
+		}
+		if tc.AntiStructureMultiplier < 0 {
+			fail("troops.%s.anti_structure_multiplier: must not be negative", name)
+		}
+		if !isDamageDice(tc.Damage) {
+			fail("troops.%s.damage: invalid dice notation %q", name, tc.Damage)
+		}
+	}
+
+	// Structures: every structure type the map generator places needs stats
+	for _, st := range model.StructureTypes {
+		if _, ok := b.Structures[string(st)]; !ok {
+			fail("structures.%s: missing", st)
+		}
+	}
+	for _, name := range sortedKeys(b.Structures) {
+		sc := b.Structures[name]
+		if sc.HP <= 0 {
+			fail("structures.%s.hp: must be positive", name)
+		}
+		if sc.Income < 0 {
+			fail("structures.%s.income: must not be negative", name)
+		}
+		if !isDamageDice(sc.Damage) {
+			fail("structures.%s.damage: invalid dice notation %q", name, sc.Damage)
+		}
+	}
+
+	// Terrain: unknown terrain plays as plains, so plains must be defined
+	if len(b.Terrain) > 0 {
+		if _, ok := b.Terrain[string(model.TerrainPlains)]; !ok {
+			fail("terrain.%s: missing", model.TerrainPlains)
+		}
+	}
+	for _, name := range sortedKeys(b.Terrain) {
+		if b.Terrain[name].MovementCost < 0 {
+			fail("terrain.%s.movement_cost: must not be negative", name)
+		}
+	}
+
+	// Noise thresholds: upper bounds in (0, 1], strictly increasing
+	errs = append(errs, b.validateNoiseThresholds()...)
+
+	// Every map size needs a sudden-death turn and a structure count
+	for _, size := range model.MapSizes {
+		if turn, ok := b.SuddenDeath.TurnThresholds[string(size)]; !ok {
+			fail("sudden_death.turn_thresholds.%s: missing", size)
+		} else if turn < 1 {
+			fail("sudden_death.turn_thresholds.%s: must be at least 1", size)
+		}
+		if count, ok := b.MapGen.StructureCounts[string(size)]; !ok {
+			fail("map_generation.structure_counts.%s: missing", size)
+		} else if count < 0 {
+			fail("map_generation.structure_counts.%s: must not be negative", size)
+		}
+	}
+	if b.SuddenDeath.ShrinkRate < 0 {
+		fail("sudden_death.shrink_rate: must not be negative")
+	}
+	if r := b.MapGen.MinPassableRatio; r < 0 || r > 1 {
+		fail("map_generation.min_passable_ratio: must be between 0 and 1")
+	}
+	if b.MapGen.MaxRetries < 1 {
+		fail("map_generation.max_retries: must be at least 1")
+	}
+
+	if size := b.Matchmaking.QuickMatchDefaults.MapSize; size != "" && !isMapSize(size) {
+		fail("matchmaking.quick_match_defaults.map_size: unknown map size %q", size)
+	}
+	switch model.TurnMode(b.Matchmaking.QuickMatchDefaults.TurnMode) {
+	case "", model.TurnModeAlternating, model.TurnModeSimultaneous:
+	default:
+		fail("matchmaking.quick_match_defaults.turn_mode: unknown turn mode %q", b.Matchmaking.QuickMatchDefaults.TurnMode)
+	}
+
+	if b.WinCond.DominanceTurnsRequired < 1 {
+		fail("win_conditions.dominance_turns_required: must be at least 1")
+	}
+	if b.WinCond.SurrenderAfterTurn < 0 {
+		fail("win_conditions.surrender_after_turn: must not be negative")
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateNoiseThresholds checks that the noise bands split [0, 1] into
+// non-empty, ordered ranges of known terrain.
+func (b *BalanceData) validateNoiseThresholds() []error {
+	thresholds := b.MapGen.NoiseThresholds
+	if len(thresholds) == 0 {
+		return nil
+	}
+
+	names := sortedKeys(thresholds)
+	sort.SliceStable(names, func(i, j int) bool { return thresholds[names[i]] < thresholds[names[j]] })
+
+	var errs []error
+	for i, name := range names {
+		bound := thresholds[name]
+		if bound <= 0 || bound > 1 {
+			errs = append(errs, fmt.Errorf("map_generation.noise_thresholds.%s: %g is outside (0, 1]", name, bound))
+		}
+		if i > 0 && bound == thresholds[names[i-1]] {
+			errs = append(errs, fmt.Errorf("map_generation.noise_thresholds.%s: %g equals the bound of %s, so one of them is never generated", name, bound, names[i-1]))
+		}
+		if len(b.Terrain) > 0 {
+			if _, ok := b.Terrain[name]; !ok {
+				errs = append(errs, fmt.Errorf("map_generation.noise_thresholds.%s: terrain type is not defined", name))
+			}
+		}
+	}
+	return errs
+}
+
+// isDamageDice reports whether s is dice notation that rolls at least one die of at least one side.
+func isDamageDice(s string) bool {
+	dn, err := dice.ParseDiceNotation(s)
+	return err == nil && dn.Count >= 1 && dn.Sides >= 1
+}
+
+func isMapSize(s string) bool {
+	for _, size := range model.MapSizes {
+		if string(size) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the map's keys in order, so problems are reported deterministically.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBalance_ShippedFileIsValid(t *testing.T) {
+	b, err := LoadBalance("../../data/balance.yaml")
+	require.NoError(t, err)
+	assert.NoError(t, b.Validate())
+	assert.NotEmpty(t, b.Version)
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	b, err := LoadBalance("../../data/balance.yaml")
+	require.NoError(t, err)
+
+	marine := b.Troops["marine"]
+	marine.Damage = "1d6+"
+	b.Troops["marine"] = marine
+	sniper := b.Troops["sniper"]
+	sniper.Damage = "0D6"
+	b.Troops["sniper"] = sniper
+	outpost := b.Structures["outpost"]
+	outpost.Damage = "1D0"
+	b.Structures["outpost"] = outpost
+	delete(b.Structures, "hq")
+	delete(b.SuddenDeath.TurnThresholds, "large")
+	delete(b.MapGen.StructureCounts, "small")
+	b.MapGen.NoiseThresholds["forest"] = b.MapGen.NoiseThresholds["plains"]
+	b.MapGen.NoiseThresholds["lava"] = 0.95
+
+	err = b.Validate()
+	require.Error(t, err)
+	msg := err.Error()
+	assert.Contains(t, msg, `troops.marine.damage: invalid dice notation "1d6+"`)
+	assert.Contains(t, msg, `troops.sniper.damage: invalid dice notation "0D6"`)
+	assert.Contains(t, msg, `structures.outpost.damage: invalid dice notation "1D0"`)
+	assert.Contains(t, msg, "structures.hq: missing")
+	assert.Contains(t, msg, "sudden_death.turn_thresholds.large: missing")
+	assert.Contains(t, msg, "map_generation.structure_counts.small: missing")
+	assert.Contains(t, msg, "map_generation.noise_thresholds.plains: 0.55 equals the bound of forest")
+	assert.Contains(t, msg, "map_generation.noise_thresholds.lava: terrain type is not defined")
+}
@@ -0,0 +1,50 @@
+package dice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// Commit-reveal seeding: the server picks a secret seed and publishes its hash
+// (the commitment) before play starts. Players may add their own entropy, which
+// is mixed into the dice seed. Once the game is over the server seed is revealed,
+// so anyone can check it against the commitment and re-derive every roll.
+
+// MaxClientSeedLen is the longest client seed accepted, in bytes.
+const MaxClientSeedLen = 64
+
+// NewServerSeed returns a fresh secret seed: 32 random bytes, hex encoded.
+func NewServerSeed() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("dice: failed to generate server seed: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CommitSeed returns the commitment published for a server seed: its hex SHA-256.
+func CommitSeed(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyCommitment returns true if serverSeed is the seed behind commitment.
+func VerifyCommitment(serverSeed, commitment string) bool {
+	return CommitSeed(serverSeed) == commitment
+}
+
+// DeriveSeed mixes the server seed with the players' client seeds, in player order,
+// and returns the Roller seed: the first 8 bytes of the SHA-256 of all seeds
+// joined by NUL bytes, read big-endian.
+func DeriveSeed(serverSeed string, clientSeeds ...string) int64 {
+	h := sha256.New()
+	h.Write([]byte(serverSeed))
+	for _, cs := range clientSeeds {
+		h.Write([]byte{0})
+		h.Write([]byte(cs))
+	}
+	return int64(binary.BigEndian.Uint64(h.Sum(nil)[:8]))
+}
@@ -11,15 +11,50 @@ import (
 // Each game gets its own Roller with a unique seed for reproducibility.
 type Roller struct {
 	rng *rand.Rand
+	src *countingSource
+}
+
+// countingSource wraps a rand.Source and counts the values drawn from it,
+// so a Roller's position in its sequence can be saved and restored.
+type countingSource struct {
+	src   rand.Source
+	draws uint64
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.draws = 0
 }
 
 // NewRoller creates a new Roller with the given seed.
 func NewRoller(seed int64) *Roller {
+	return NewRollerAt(seed, 0)
+}
+
+// NewRollerAt creates a Roller with the given seed that continues after
+// the first draws values of its sequence, as reported by Draws.
+func NewRollerAt(seed int64, draws uint64) *Roller {
+	src := &countingSource{src: rand.NewSource(seed)}
+	for src.draws < draws {
+		src.Int63()
+	}
 	return &Roller{
-		rng: rand.New(rand.NewSource(seed)),
+		rng: rand.New(src),
+		src: src,
 	}
 }
 
+// Draws returns how many values the Roller has drawn from its source.
+// A single roll may take more than one draw.
+func (r *Roller) Draws() uint64 {
+	return r.src.draws
+}
+
 // Roll returns a random integer in [1, sides].
 func (r *Roller) Roll(sides int) int {
 	if sides < 1 {
@@ -83,6 +118,44 @@ func (dn DiceNotation) String() string {
 	return fmt.Sprintf("%dD%d", dn.Count, dn.Sides)
 }
 
+// Min returns the lowest total the notation can roll.
+func (dn DiceNotation) Min() int {
+	return dn.Count + dn.Modifier
+}
+
+// Max returns the highest total the notation can roll.
+func (dn DiceNotation) Max() int {
+	return dn.Count*dn.Sides + dn.Modifier
+}
+
+// ChanceAtLeast returns the probability that a roll totals n or more.
+func (dn DiceNotation) ChanceAtLeast(n int) float64 {
+	if n <= dn.Min() {
+		return 1
+	}
+	if n > dn.Max() || dn.Sides < 1 {
+		return 0
+	}
+
+	// dist[i] is the probability of the dice (without modifier) summing to i
+	dist := []float64{1}
+	for i := 0; i < dn.Count; i++ {
+		next := make([]float64, len(dist)+dn.Sides)
+		for sum, p := range dist {
+			for face := 1; face <= dn.Sides; face++ {
+				next[sum+face] += p / float64(dn.Sides)
+			}
+		}
+		dist = next
+	}
+
+	chance := 0.0
+	for sum := n - dn.Modifier; sum < len(dist); sum++ {
+		chance += dist[sum]
+	}
+	return chance
+}
+
 // StepDown reduces the die size by one step: D8->D6, D6->D4, D4->D4 (minimum).
 func (dn DiceNotation) StepDown() DiceNotation {
 	newSides := dn.Sides
@@ -91,6 +91,20 @@ func TestDiceNotation_StepDown(t *testing.T) {
 	}
 }
 
+func TestDiceNotation_ChanceAtLeast(t *testing.T) {
+	dn := DiceNotation{Count: 2, Sides: 6, Modifier: 2} // 2D6+2
+
+	assert.Equal(t, 4, dn.Min())
+	assert.Equal(t, 14, dn.Max())
+	assert.Equal(t, 1.0, dn.ChanceAtLeast(4))
+	assert.Equal(t, 0.0, dn.ChanceAtLeast(15))
+	assert.InDelta(t, 1.0/36, dn.ChanceAtLeast(14), 1e-9)
+	assert.InDelta(t, 21.0/36, dn.ChanceAtLeast(9), 1e-9) // 2D6 >= 7
+
+	d8 := DiceNotation{Count: 1, Sides: 8}
+	assert.InDelta(t, 0.5, d8.ChanceAtLeast(5), 1e-9)
+}
+
 func TestRoller_Damage(t *testing.T) {
 	r := NewRoller(42) // Fixed seed for deterministic behavior
 
@@ -112,3 +126,33 @@ func TestRoller_Damage(t *testing.T) {
 		assert.LessOrEqual(t, half, 7)    // Maximum: 14 / 2 = 7
 	}
 }
+
+func TestRoller_ResumeAt(t *testing.T) {
+	r1 := NewRoller(42)
+	for i := 0; i < 50; i++ {
+		r1.Roll(20)
+		r1.Roll(6)
+	}
+
+	r2 := NewRollerAt(42, r1.Draws())
+	assert.Equal(t, r1.Draws(), r2.Draws())
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, r1.Roll(20), r2.Roll(20), "Resumed roller should continue the same sequence")
+	}
+}
+
+func TestCommitReveal(t *testing.T) {
+	serverSeed, err := NewServerSeed()
+	assert.NoError(t, err)
+	assert.Len(t, serverSeed, 64)
+
+	commitment := CommitSeed(serverSeed)
+	assert.True(t, VerifyCommitment(serverSeed, commitment))
+	assert.False(t, VerifyCommitment(serverSeed+"0", commitment))
+
+	// The derived seed depends on every contribution and on player order
+	seed := DeriveSeed(serverSeed, "alice", "bob")
+	assert.Equal(t, seed, DeriveSeed(serverSeed, "alice", "bob"))
+	assert.NotEqual(t, seed, DeriveSeed(serverSeed, "bob", "alice"))
+	assert.NotEqual(t, seed, DeriveSeed(serverSeed, "alice", ""))
+}
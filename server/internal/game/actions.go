@@ -4,7 +4,6 @@ import (
 	"github.com/teomiscia/hexbattle/internal/dice"
 	"github.com/teomiscia/hexbattle/internal/hex"
 	"github.com/teomiscia/hexbattle/internal/model"
-	"github.com/teomiscia/hexbattle/internal/player"
 	"github.com/teomiscia/hexbattle/internal/ws"
 )
 
@@ -83,37 +82,12 @@ func ExecuteAttack(gs *GameState, roller *dice.Roller, playerID string, unitID s
 		deltaTypes = append(deltaTypes, ws.MsgCombatResult)
 
 		// Track stats
-		attackerIdx := gs.PlayerIndex(attacker.OwnerID)
-		defenderIdx := gs.PlayerIndex(enemyTroop.OwnerID)
-
-		if combatResult.Hit {
-			if attackerIdx >= 0 {
-				gs.Stats[attackerIdx].TotalDamageDealt += combatResult.Damage
-			}
-		}
+		trackCombatStats(gs, attacker, enemyTroop, combatResult, destroyed)
 
 		for _, d := range destroyed {
 			deltas = append(deltas, d)
 			deltaTypes = append(deltaTypes, ws.MsgTroopDestroyed)
-
-			// Update stats
-			if d.UnitID == enemyTroop.ID {
-				if attackerIdx >= 0 {
-					gs.Stats[attackerIdx].TroopsKilled++
-				}
-				if defenderIdx >= 0 {
-					gs.Stats[defenderIdx].TroopsLost++
-				}
-				gs.RemoveTroop(d.UnitID)
-			} else if d.UnitID == attacker.ID {
-				if defenderIdx >= 0 {
-					gs.Stats[defenderIdx].TroopsKilled++
-				}
-				if attackerIdx >= 0 {
-					gs.Stats[attackerIdx].TroopsLost++
-				}
-				gs.RemoveTroop(d.UnitID)
-			}
+			gs.RemoveTroop(d.UnitID)
 		}
 	} else {
 		// Troop vs Structure combat
@@ -157,6 +131,12 @@ func ExecuteBuy(gs *GameState, playerID string, troopType model.TroopType, struc
 		}
 	}
 
+	return purchaseTroop(gs, playerID, troopType, structureID)
+}
+
+// purchaseTroop spawns a troop next to an already-validated spawn structure
+// and deducts its cost.
+func purchaseTroop(gs *GameState, playerID string, troopType model.TroopType, structureID string) *ActionResult {
 	structure := gs.GetStructure(structureID)
 
 	// Find nearest empty hex for spawn
@@ -179,8 +159,8 @@ func ExecuteBuy(gs *GameState, playerID string, troopType model.TroopType, struc
 	DeductCost(gs, playerID, troopType)
 
 	// Create troop
-	unitID := player.GenerateUnitID()
-	troop, err := NewTroopFromBalance(unitID, troopType, playerID, spawnHex)
+	unitID := gs.NextUnitID()
+	troop, err := NewTroopFromBalance(gs, unitID, troopType, playerID, spawnHex)
 	if err != nil {
 		return &ActionResult{
 			Ack:   false,
@@ -216,6 +196,34 @@ func ExecuteBuy(gs *GameState, playerID string, troopType model.TroopType, struc
 	}
 }
 
+// trackCombatStats updates per-player damage and kill stats after a troop-vs-troop exchange.
+func trackCombatStats(gs *GameState, attacker, defender *model.Troop, result *ws.CombatResultData, destroyed []*ws.TroopDestroyedData) {
+	attackerIdx := gs.PlayerIndex(attacker.OwnerID)
+	defenderIdx := gs.PlayerIndex(defender.OwnerID)
+
+	if result.Hit && attackerIdx >= 0 {
+		gs.Stats[attackerIdx].TotalDamageDealt += result.Damage
+	}
+
+	for _, d := range destroyed {
+		if d.UnitID == defender.ID {
+			if attackerIdx >= 0 {
+				gs.Stats[attackerIdx].TroopsKilled++
+			}
+			if defenderIdx >= 0 {
+				gs.Stats[defenderIdx].TroopsLost++
+			}
+		} else if d.UnitID == attacker.ID {
+			if defenderIdx >= 0 {
+				gs.Stats[defenderIdx].TroopsKilled++
+			}
+			if attackerIdx >= 0 {
+				gs.Stats[attackerIdx].TroopsLost++
+			}
+		}
+	}
+}
+
 // ExecuteEndTurn processes an end_turn action and transitions to the next turn.
 func ExecuteEndTurn(gs *GameState, roller *dice.Roller, playerID string) *ActionResult {
 	// Validate
@@ -256,3 +264,19 @@ func ExecuteEndTurn(gs *GameState, roller *dice.Roller, playerID string) *Action
 		GameOver:   gameOver,
 	}
 }
+
+// ExecuteSurrender processes a surrender: the surrendering player forfeits the game.
+func ExecuteSurrender(gs *GameState, playerID string) *ActionResult {
+	errCode, errMsg := ValidateSurrender(gs, playerID)
+	if errCode != "" {
+		return &ActionResult{
+			Ack:   false,
+			Error: &ws.ErrorData{Code: errCode, Message: errMsg},
+		}
+	}
+
+	return &ActionResult{
+		Ack:      true,
+		GameOver: CheckForfeit(gs, playerID),
+	}
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// ActionType identifies the type of an action passed to Apply.
+type ActionType string
+
+const (
+	ActionMove    ActionType = "move"
+	ActionAttack  ActionType = "attack"
+	ActionBuy     ActionType = "buy"
+	ActionEndTurn ActionType = "end_turn"
+)
+
+// Action is a single player action in alternating mode.
+type Action struct {
+	Type        ActionType
+	PlayerID    string
+	UnitID      string          // move, attack
+	Target      hex.Coord       // move, attack
+	TroopType   model.TroopType // buy
+	StructureID string          // buy
+}
+
+// Event is a change caused by an action: a message type and its payload,
+// as the Engine would broadcast them.
+type Event struct {
+	Type string
+	Data interface{}
+}
+
+// ActionError is returned by Apply when an action is rejected.
+type ActionError struct {
+	Code    model.ErrorCode
+	Message string
+}
+
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Apply plays the action on a copy of the game state and returns the new state with
+// the events it caused; gs is never modified. Dice are drawn from the state's own seed
+// after its RollCount, so the result depends only on gs and the action. Lookahead bots
+// must not search with the game's secret seed: set another on the state they pass.
+//
+// Ending the turn runs structure combat afterwards, as the Engine does. When the action
+// ends the game, the new state is in PhaseGameOver and the last event is game_over.
+func Apply(gs *GameState, action Action) (*GameState, []Event, error) {
+	next := gs.Clone()
+	roller := dice.NewRollerAt(next.Seed, next.RollCount)
+
+	var results []*ActionResult
+	switch action.Type {
+	case ActionMove:
+		results = append(results, ExecuteMove(next, action.PlayerID, action.UnitID, action.Target))
+	case ActionAttack:
+		results = append(results, ExecuteAttack(next, roller, action.PlayerID, action.UnitID, action.Target))
+	case ActionBuy:
+		results = append(results, ExecuteBuy(next, action.PlayerID, action.TroopType, action.StructureID))
+	case ActionEndTurn:
+		end := ExecuteEndTurn(next, roller, action.PlayerID)
+		if end.Ack {
+			// The Engine broadcasts structure fire before the new turn
+			results = append(results, RunStructureCombat(next, roller))
+		}
+		results = append(results, end)
+	default:
+		return nil, nil, &ActionError{Code: model.ErrInvalidMessage, Message: fmt.Sprintf("unknown action type %q", action.Type)}
+	}
+
+	var events []Event
+	var gameOver *ws.GameOverData
+	for _, result := range results {
+		if !result.Ack {
+			return nil, nil, &ActionError{Code: result.Error.Code, Message: result.Error.Message}
+		}
+		for i, delta := range result.Deltas {
+			events = append(events, Event{Type: result.DeltaTypes[i], Data: delta})
+		}
+		if result.GameOver != nil {
+			gameOver = result.GameOver
+		}
+	}
+	if gameOver != nil {
+		next.Phase = model.PhaseGameOver
+		events = append(events, Event{Type: ws.MsgGameOver, Data: gameOver})
+	}
+
+	next.RollCount = roller.Draws()
+	return next, events, nil
+}
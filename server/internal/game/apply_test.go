@@ -0,0 +1,256 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+func newApplyTestGame() *GameState {
+	return NewTestGame().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopMech, hex.NewCoord(0, 2, -2), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(1, 0, -1), true).
+		WithTroop("p2", troopSniper, hex.NewCoord(-2, -1, 3), true).
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		WithStructure(model.StructureOutpost, "", hex.NewCoord(3, -1, -2)).
+		Build()
+}
+
+// snapshot serialises everything the clients see of the state.
+func snapshot(t *testing.T, gs *GameState) string {
+	t.Helper()
+	data, err := json.Marshal(gs)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestApply_LeavesStateUntouched(t *testing.T) {
+	gs := newApplyTestGame()
+	before := snapshot(t, gs)
+	marine := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+
+	next, events, err := Apply(gs, Action{Type: ActionMove, PlayerID: "p1", UnitID: marine.ID, Target: hex.NewCoord(-1, 0, 1)})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, ws.MsgTroopMoved, events[0].Type)
+	assert.Equal(t, hex.NewCoord(-1, 0, 1), next.GetTroop(marine.ID).Hex)
+
+	next, _, err = Apply(next, Action{Type: ActionBuy, PlayerID: "p1", TroopType: troopMarine, StructureID: gs.PlayerHQ("p1").ID})
+	require.NoError(t, err)
+	assert.Len(t, next.Troops, 5)
+
+	assert.Equal(t, before, snapshot(t, gs))
+	assert.Equal(t, hex.NewCoord(0, 0, 0), marine.Hex)
+}
+
+func TestApply_IsDeterministic(t *testing.T) {
+	gs := newApplyTestGame()
+	mech := gs.TroopAtHex(hex.NewCoord(0, 2, -2))
+	attack := Action{Type: ActionAttack, PlayerID: "p1", UnitID: mech.ID, Target: hex.NewCoord(1, 0, -1)}
+
+	a, eventsA, err := Apply(gs, attack)
+	require.NoError(t, err)
+	b, eventsB, err := Apply(gs, attack)
+	require.NoError(t, err)
+
+	assert.Equal(t, eventsA, eventsB)
+	assert.Equal(t, snapshot(t, a), snapshot(t, b))
+	assert.Greater(t, a.RollCount, gs.RollCount)
+	assert.Equal(t, ws.MsgCombatResult, eventsA[0].Type)
+}
+
+func TestApply_EndTurn(t *testing.T) {
+	gs := newApplyTestGame()
+	// In range of the neutral outpost, which fires once the turn ends
+	sniper := gs.TroopAtHex(hex.NewCoord(-2, -1, 3))
+	sniper.Hex = hex.NewCoord(3, -2, -1)
+
+	next, events, err := Apply(gs, Action{Type: ActionEndTurn, PlayerID: "p1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, next.TurnNumber)
+	assert.Equal(t, 1, next.ActivePlayer)
+	assert.Equal(t, model.PhasePlayerAction, next.Phase)
+	types := make([]string, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	assert.Equal(t, ws.MsgStructureFires, types[0])
+	assert.Equal(t, ws.MsgTurnStart, types[len(types)-1])
+	assert.Equal(t, 1, gs.TurnNumber)
+}
+
+func TestApply_GameOver(t *testing.T) {
+	gs := newApplyTestGame()
+	mech := gs.TroopAtHex(hex.NewCoord(0, 2, -2))
+	mech.Hex = hex.NewCoord(0, -3, 3)
+	gs.PlayerHQ("p2").CurrentHP = 1
+	attack := Action{Type: ActionAttack, PlayerID: "p1", UnitID: mech.ID, Target: hex.NewCoord(0, -5, 5)}
+
+	// The dice decide whether the attack hits: try seeds until one does
+	for seed := int64(1); seed <= 100; seed++ {
+		gs.Seed = seed
+		next, events, err := Apply(gs, attack)
+		require.NoError(t, err)
+		if next.Phase != model.PhaseGameOver {
+			continue
+		}
+		last := events[len(events)-1]
+		assert.Equal(t, ws.MsgGameOver, last.Type)
+		assert.Equal(t, "p1", last.Data.(*ws.GameOverData).WinnerID)
+		assert.Equal(t, model.PhasePlayerAction, gs.Phase)
+		return
+	}
+	t.Fatal("no seed captured the HQ")
+}
+
+func TestApply_RejectsInvalidAction(t *testing.T) {
+	gs := newApplyTestGame()
+	marine := gs.TroopAtHex(hex.NewCoord(1, 0, -1))
+
+	next, events, err := Apply(gs, Action{Type: ActionMove, PlayerID: "p2", UnitID: marine.ID, Target: hex.NewCoord(2, 0, -2)})
+	assert.Nil(t, next)
+	assert.Nil(t, events)
+	var actionErr *ActionError
+	require.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, model.ErrNotYourTurn, actionErr.Code)
+
+	_, _, err = Apply(gs, Action{Type: "teleport", PlayerID: "p1"})
+	require.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, model.ErrInvalidMessage, actionErr.Code)
+}
+
+// FuzzApply plays random actions with Apply and checks that no state ever shares memory
+// with the state it was derived from, and that the earlier state is left as it was.
+func FuzzApply(f *testing.F) {
+	f.Add(int64(1), uint8(20))
+	f.Add(int64(7), uint8(60))
+	f.Add(int64(42), uint8(120))
+
+	f.Fuzz(func(t *testing.T, seed int64, steps uint8) {
+		rng := rand.New(rand.NewSource(seed))
+		gs := newApplyTestGame()
+		gs.Seed = seed
+		// Exercise the fog of war and order fields too
+		gs.Explored[0] = map[hex.Coord]bool{hex.NewCoord(0, 0, 0): true}
+		gs.Orders[1] = &OrderSet{Moves: map[string]hex.Coord{}, Attacks: map[string]hex.Coord{}, Buys: []BuyOrder{{TroopType: troopMarine}}}
+		gs.vision[0] = gs.computeVision("p1")
+
+		for i := 0; i < int(steps) && gs.Phase != model.PhaseGameOver; i++ {
+			before := snapshot(t, gs)
+			next, _, err := Apply(gs, randomAction(gs, rng))
+			if err != nil {
+				var actionErr *ActionError
+				require.ErrorAs(t, err, &actionErr)
+				continue
+			}
+			assertNoAliasing(t, gs, next)
+			assert.Equal(t, before, snapshot(t, gs), "Apply modified its input")
+			gs = next
+		}
+	})
+}
+
+// randomAction picks a move, attack, buy or end of turn for the active player.
+// Some of the actions it picks are invalid, which Apply must reject cleanly.
+func randomAction(gs *GameState, rng *rand.Rand) Action {
+	playerID := gs.Players[gs.ActivePlayer].ID
+	troops := gs.PlayerTroops(playerID)
+	slices.SortFunc(troops, func(a, b *model.Troop) int { return strings.Compare(a.ID, b.ID) })
+	structures := gs.PlayerStructures(playerID)
+	slices.SortFunc(structures, func(a, b *model.Structure) int { return strings.Compare(a.ID, b.ID) })
+
+	switch n := rng.Intn(10); {
+	case n < 4 && len(troops) > 0:
+		troop := troops[rng.Intn(len(troops))]
+		targets := gs.Grid.AllHexes()
+		if reachable := ReachableHexes(gs, troop); len(reachable) > 0 && rng.Intn(4) > 0 {
+			targets = targets[:0:0]
+			for h := range reachable {
+				targets = append(targets, h)
+			}
+			slices.SortFunc(targets, compareCoords)
+		}
+		return Action{Type: ActionMove, PlayerID: playerID, UnitID: troop.ID, Target: targets[rng.Intn(len(targets))]}
+	case n < 7 && len(troops) > 0:
+		troop := troops[rng.Intn(len(troops))]
+		var targets []hex.Coord
+		for _, h := range gs.Grid.AllHexes() {
+			if CanAttackTarget(troop, h) {
+				targets = append(targets, h)
+			}
+		}
+		return Action{Type: ActionAttack, PlayerID: playerID, UnitID: troop.ID, Target: targets[rng.Intn(len(targets))]}
+	case n < 9 && len(structures) > 0:
+		types := []model.TroopType{troopMarine, troopSniper, troopHoverbike, troopMech}
+		return Action{
+			Type:        ActionBuy,
+			PlayerID:    playerID,
+			TroopType:   types[rng.Intn(len(types))],
+			StructureID: structures[rng.Intn(len(structures))].ID,
+		}
+	default:
+		return Action{Type: ActionEndTurn, PlayerID: playerID}
+	}
+}
+
+func compareCoords(a, b hex.Coord) int {
+	if a.Q != b.Q {
+		return a.Q - b.Q
+	}
+	return a.R - b.R
+}
+
+// assertNoAliasing fails if any map, slice or pointer reachable from a is also reachable from b.
+func assertNoAliasing(t *testing.T, a, b *GameState) {
+	t.Helper()
+	assertDistinct(t, "troops", a.Troops, b.Troops)
+	assertDistinct(t, "structures", a.Structures, b.Structures)
+	assertDistinct(t, "terrain", a.Terrain, b.Terrain)
+	for id, troop := range a.Troops {
+		if other := b.Troops[id]; other != nil {
+			assert.NotSame(t, troop, other, "troop %s", id)
+		}
+	}
+	for id, structure := range a.Structures {
+		if other := b.Structures[id]; other != nil {
+			assert.NotSame(t, structure, other, "structure %s", id)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		assertDistinct(t, "explored", a.Explored[i], b.Explored[i])
+		if a.Orders[i] != nil && b.Orders[i] != nil {
+			assert.NotSame(t, a.Orders[i], b.Orders[i], "orders")
+			assertDistinct(t, "order moves", a.Orders[i].Moves, b.Orders[i].Moves)
+			assertDistinct(t, "order attacks", a.Orders[i].Attacks, b.Orders[i].Attacks)
+			assertDistinct(t, "order buys", a.Orders[i].Buys, b.Orders[i].Buys)
+		}
+		if a.vision[i] != nil && b.vision[i] != nil {
+			assert.NotSame(t, a.vision[i], b.vision[i], "vision")
+			assertDistinct(t, "vision hexes", a.vision[i].hexes, b.vision[i].hexes)
+			assertDistinct(t, "vision troops", a.vision[i].troops, b.vision[i].troops)
+			assertDistinct(t, "vision structures", a.vision[i].structures, b.vision[i].structures)
+		}
+	}
+}
+
+// assertDistinct fails if two non-empty maps or slices share their storage.
+func assertDistinct(t *testing.T, name string, a, b interface{}) {
+	t.Helper()
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.IsNil() || vb.IsNil() || (va.Kind() == reflect.Slice && (va.Cap() == 0 || vb.Cap() == 0)) {
+		return
+	}
+	assert.NotEqual(t, va.Pointer(), vb.Pointer(), "%s are shared", name)
+}
@@ -1,8 +1,10 @@
 package game
 
 import (
+	"github.com/teomiscia/hexbattle/internal/dice"
 	"github.com/teomiscia/hexbattle/internal/hex"
 	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
 )
 
 // BotActionType identifies the type of bot action.
@@ -35,3 +37,20 @@ type BotPlayer interface {
 	// PlayerID returns the bot's player ID.
 	PlayerID() string
 }
+
+// ExecuteBotAction executes a bot's action as the given player.
+func ExecuteBotAction(gs *GameState, roller *dice.Roller, playerID string, action *BotAction) *ActionResult {
+	switch action.Type {
+	case BotActionBuy:
+		return ExecuteBuy(gs, playerID, action.TroopType, action.StructureID)
+	case BotActionMove:
+		return ExecuteMove(gs, playerID, action.UnitID, action.Target)
+	case BotActionAttack:
+		return ExecuteAttack(gs, roller, playerID, action.UnitID, action.Target)
+	default:
+		return &ActionResult{
+			Ack:   false,
+			Error: &ws.ErrorData{Code: model.ErrInvalidMessage, Message: "unknown bot action"},
+		}
+	}
+}
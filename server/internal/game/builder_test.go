@@ -8,6 +8,14 @@ import (
 	"github.com/teomiscia/hexbattle/internal/model"
 )
 
+// Troop types of the test balance roster.
+const (
+	troopMarine    model.TroopType = "marine"
+	troopSniper    model.TroopType = "sniper"
+	troopHoverbike model.TroopType = "hoverbike"
+	troopMech      model.TroopType = "mech"
+)
+
 // TestBuilder is a helper for constructing game states programmatically.
 type TestBuilder struct {
 	state *GameState
@@ -16,7 +24,7 @@ type TestBuilder struct {
 // NewTestGame creates a new test game builder with reasonable defaults.
 func NewTestGame() *TestBuilder {
 	// Provide a default dummy balance for tests
-	if Balance == nil {
+	if CurrentBalance() == nil {
 		LoadBalance(&config.BalanceData{
 			Economy: config.EconomyConfig{
 				StartingCoins:   1000,
@@ -54,6 +62,7 @@ func NewTestGame() *TestBuilder {
 			},
 			WinCond: config.WinCondConfig{
 				DominanceTurnsRequired: 3,
+				SurrenderAfterTurn:     5,
 			},
 		})
 	}
@@ -84,7 +93,7 @@ func (b *TestBuilder) WithMapSize(size model.MapSize) *TestBuilder {
 
 func (b *TestBuilder) WithTroop(ownerID string, troopType model.TroopType, pos hex.Coord, ready bool) *TestBuilder {
 	id := fmt.Sprintf("unit_%d_%d_%d", pos.Q, pos.R, pos.S)
-	t, _ := NewTroopFromBalance(id, troopType, ownerID, pos)
+	t, _ := NewTroopFromBalance(b.state, id, troopType, ownerID, pos)
 	t.IsReady = ready
 	b.state.AddTroop(t)
 	return b
@@ -92,7 +101,7 @@ func (b *TestBuilder) WithTroop(ownerID string, troopType model.TroopType, pos h
 
 func (b *TestBuilder) WithStructure(sType model.StructureType, ownerID string, pos hex.Coord) *TestBuilder {
 	id := fmt.Sprintf("struct_%d_%d_%d", pos.Q, pos.R, pos.S)
-	s, _ := NewStructureFromBalance(id, sType, ownerID, pos)
+	s, _ := NewStructureFromBalance(b.state, id, sType, ownerID, pos)
 	b.state.AddStructure(s)
 	return b
 }
@@ -126,6 +135,30 @@ func (b *TestBuilder) WithActivePlayer(playerID string) *TestBuilder {
 	return b
 }
 
+func (b *TestBuilder) WithTurnMode(mode model.TurnMode) *TestBuilder {
+	b.state.TurnMode = mode
+	if mode == model.TurnModeSimultaneous {
+		b.state.Phase = model.PhasePlanning
+	}
+	return b
+}
+
+func (b *TestBuilder) WithFogOfWar() *TestBuilder {
+	b.state.FogOfWar = true
+	return b
+}
+
+func (b *TestBuilder) WithRules(o *model.RuleOverrides) *TestBuilder {
+	settings := model.RoomSettings{MapSize: b.state.MapSize, Rules: o}
+	rules, err := ResolveRules(CurrentBalance(), settings)
+	if err != nil {
+		panic(err)
+	}
+	b.state.Rules = rules
+	b.state.RulesVersion = rules.Version
+	return b
+}
+
 func (b *TestBuilder) Build() *GameState {
 	// Ensure grid bounds are respected if terrain isn't explicitly set
 	for _, c := range b.state.Grid.AllHexes() {
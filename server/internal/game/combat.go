@@ -1,36 +1,62 @@
 package game
 
 import (
+	"sort"
+
 	"github.com/teomiscia/hexbattle/internal/dice"
 	"github.com/teomiscia/hexbattle/internal/model"
 	"github.com/teomiscia/hexbattle/internal/ws"
 )
 
+// troopATK returns a troop's attack modifier, including the terrain it attacks from.
+func troopATK(gs *GameState, t *model.Troop) int {
+	return t.ATK + TerrainInfo(gs, gs.GetTerrainAt(t.Hex)).ATKModifier
+}
+
+// troopDEF returns a troop's defense, including the terrain it stands on.
+func troopDEF(gs *GameState, t *model.Troop) int {
+	return t.DEF + TerrainInfo(gs, gs.GetTerrainAt(t.Hex)).DEFModifier
+}
+
+// rollHits applies the d20 hit rule: a natural 20 always hits, a natural 1 always
+// misses, anything else hits if the modified roll meets the target's defense.
+func rollHits(natural, total, def int) bool {
+	switch natural {
+	case 20:
+		return true
+	case 1:
+		return false
+	default:
+		return total >= def
+	}
+}
+
+// meleeExchange returns whether a defender strikes back at any attacker it survives:
+// only melee troops trade blows. Otherwise a counter needs the attacker to fumble.
+func meleeExchange(attacker, defender *model.Troop) bool {
+	return attacker.IsMelee() && defender.IsMelee()
+}
+
 // ResolveTroopCombat resolves a full combat exchange between an attacker troop and a defender troop.
 // Returns the combat result delta and any troop destroyed deltas.
 func ResolveTroopCombat(gs *GameState, roller *dice.Roller, attacker, defender *model.Troop) (*ws.CombatResultData, []*ws.TroopDestroyedData) {
+	return resolveTroopCombat(gs, roller, attacker, defender, 0)
+}
+
+// resolveTroopCombat is ResolveTroopCombat with a flat penalty subtracted from the
+// attacker's ATK modifier on the primary roll (the counterattack is unaffected).
+func resolveTroopCombat(gs *GameState, roller *dice.Roller, attacker, defender *model.Troop, atkPenalty int) (*ws.CombatResultData, []*ws.TroopDestroyedData) {
 	var destroyed []*ws.TroopDestroyedData
 
 	// --- Primary attack ---
-	attackerTerrain := gs.GetTerrainAt(attacker.Hex)
-	defenderTerrain := gs.GetTerrainAt(defender.Hex)
-
 	naturalRoll := roller.D20()
-	atkModifier := attacker.ATK + model.GetTerrainInfo(attackerTerrain).ATKModifier
+	atkModifier := troopATK(gs, attacker) - atkPenalty
 	totalRoll := naturalRoll + atkModifier
-	targetDEF := defender.DEF + model.GetTerrainInfo(defenderTerrain).DEFModifier
+	targetDEF := troopDEF(gs, defender)
 
 	isCrit := naturalRoll == 20
 	isFumble := naturalRoll == 1
-
-	var hit bool
-	if isCrit {
-		hit = true
-	} else if isFumble {
-		hit = false
-	} else {
-		hit = totalRoll >= targetDEF
-	}
+	hit := rollHits(naturalRoll, totalRoll, targetDEF)
 
 	damageDealt := 0
 	if hit {
@@ -69,6 +95,7 @@ func ResolveTroopCombat(gs *GameState, roller *dice.Roller, attacker, defender *
 		Crit:        isCrit,
 		Fumble:      isFumble,
 		AttackerHP:  attacker.CurrentHP,
+		ATKPenalty:  atkPenalty,
 	}
 
 	// --- Counterattack ---
@@ -76,34 +103,14 @@ func ResolveTroopCombat(gs *GameState, roller *dice.Roller, attacker, defender *
 	// 1. Melee attacker (range=1) attacks a melee defender (range=1), OR
 	// 2. Attacker rolled a fumble (natural 1) — defender gets free counter regardless
 	// Only if defender is still alive
-	shouldCounter := false
-	if defender.IsAlive() {
-		if isFumble {
-			shouldCounter = true
-		} else if attacker.IsMelee() && defender.IsMelee() {
-			shouldCounter = true
-		}
-	}
+	shouldCounter := defender.IsAlive() && (isFumble || meleeExchange(attacker, defender))
 
 	if shouldCounter {
 		result.HasCounter = true
 
 		counterNatural := roller.D20()
-		counterAtkMod := defender.ATK + model.GetTerrainInfo(defenderTerrain).ATKModifier
-		counterTotal := counterNatural + counterAtkMod
-		counterTargetDEF := attacker.DEF + model.GetTerrainInfo(attackerTerrain).DEFModifier
-
-		counterCrit := counterNatural == 20
-		counterFumble := counterNatural == 1
-
-		var counterHit bool
-		if counterCrit {
-			counterHit = true
-		} else if counterFumble {
-			counterHit = false
-		} else {
-			counterHit = counterTotal >= counterTargetDEF
-		}
+		counterTotal := counterNatural + troopATK(gs, defender)
+		counterHit := rollHits(counterNatural, counterTotal, troopDEF(gs, attacker))
 
 		counterDamage := 0
 		if counterHit {
@@ -145,23 +152,12 @@ func ResolveTroopCombat(gs *GameState, roller *dice.Roller, attacker, defender *
 func ResolveStructureAttack(gs *GameState, roller *dice.Roller, attacker *model.Troop, structure *model.Structure) (*ws.StructureAttackedData, []*ws.TroopDestroyedData) {
 	var destroyed []*ws.TroopDestroyedData
 
-	attackerTerrain := gs.GetTerrainAt(attacker.Hex)
 	naturalRoll := roller.D20()
-	atkModifier := attacker.ATK + model.GetTerrainInfo(attackerTerrain).ATKModifier
-	totalRoll := naturalRoll + atkModifier
+	totalRoll := naturalRoll + troopATK(gs, attacker)
 	targetDEF := structure.DEF
 
 	isCrit := naturalRoll == 20
-	isFumble := naturalRoll == 1
-
-	var hit bool
-	if isCrit {
-		hit = true
-	} else if isFumble {
-		hit = false
-	} else {
-		hit = totalRoll >= targetDEF
-	}
+	hit := rollHits(naturalRoll, totalRoll, targetDEF)
 
 	damageDealt := 0
 	if hit {
@@ -173,7 +169,7 @@ func ResolveStructureAttack(gs *GameState, roller *dice.Roller, attacker *model.
 				damageDealt = roller.RollDamage(dn)
 			}
 			// Anti-structure multiplier (Mech does 2x vs structures)
-			damageDealt *= AntiStructureMultiplier(attacker.Type)
+			damageDealt *= AntiStructureMultiplier(gs, attacker.Type)
 		}
 	}
 
@@ -210,34 +206,24 @@ func ResolveStructureFire(gs *GameState, roller *dice.Roller, structure *model.S
 	atkModifier := structure.ATK
 	totalRoll := naturalRoll + atkModifier
 
-	targetTerrain := gs.GetTerrainAt(target.Hex)
-	targetDEF := target.DEF + model.GetTerrainInfo(targetTerrain).DEFModifier
+	targetDEF := troopDEF(gs, target)
 
 	// Neutral structures have reduced ATK
-	if structure.IsNeutral() && Balance != nil {
-		atkModifier -= Balance.NeutralMod.ATKReduction
+	if b := rules(gs); structure.IsNeutral() && b != nil {
+		atkModifier -= b.NeutralMod.ATKReduction
 		totalRoll = naturalRoll + atkModifier
 	}
 
 	isCrit := naturalRoll == 20
-	isFumble := naturalRoll == 1
-
-	var hit bool
-	if isCrit {
-		hit = true
-	} else if isFumble {
-		hit = false
-	} else {
-		hit = totalRoll >= targetDEF
-	}
+	hit := rollHits(naturalRoll, totalRoll, targetDEF)
 
 	damageDealt := 0
 	if hit {
 		dn, err := StructureDamageDice(structure)
 		if err == nil {
 			// Neutral structures have reduced damage dice
-			if structure.IsNeutral() && Balance != nil {
-				for i := 0; i < Balance.NeutralMod.DamageStepDown; i++ {
+			if b := rules(gs); structure.IsNeutral() && b != nil {
+				for i := 0; i < b.NeutralMod.DamageStepDown; i++ {
 					dn = dn.StepDown()
 				}
 			}
@@ -303,9 +289,61 @@ func FindStructureTarget(gs *GameState, roller *dice.Roller, structure *model.St
 		}
 	}
 
-	// Random tiebreak
+	// Random tiebreak, over a stable order so the same seed picks the same troop
 	if len(closest) == 1 {
 		return closest[0]
 	}
+	sort.Slice(closest, func(i, j int) bool { return closest[i].ID < closest[j].ID })
 	return closest[roller.Roll(len(closest))-1]
 }
+
+// RunStructureCombat executes the structure auto-attack phase and reopens the action phase.
+// Structures fire in ID order so the dice sequence is reproducible from the seed.
+// Troops killed by structure fire are removed from the state.
+func RunStructureCombat(gs *GameState, roller *dice.Roller) *ActionResult {
+	gs.Phase = model.PhaseStructureCombat
+
+	ids := make([]string, 0, len(gs.Structures))
+	for id := range gs.Structures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var deltas []interface{}
+	var deltaTypes []string
+
+	// All structures fire (if they have a valid target).
+	// Neutral structures fire every turn transition.
+	// Owned structures fire every turn transition (at enemies).
+	for _, id := range ids {
+		structure := gs.Structures[id]
+		target := FindStructureTarget(gs, roller, structure)
+		if target == nil {
+			continue
+		}
+
+		result := ResolveStructureFire(gs, roller, structure, target)
+		deltas = append(deltas, result)
+		deltaTypes = append(deltaTypes, ws.MsgStructureFires)
+
+		if result.Killed {
+			deltas = append(deltas, &ws.TroopDestroyedData{
+				UnitID: target.ID,
+				HexQ:   target.Hex.Q,
+				HexR:   target.Hex.R,
+				HexS:   target.Hex.S,
+				Cause:  "structure_fire",
+			})
+			deltaTypes = append(deltaTypes, ws.MsgTroopDestroyed)
+			gs.RemoveTroop(target.ID)
+		}
+	}
+
+	gs.Phase = gs.ActionPhase()
+
+	return &ActionResult{
+		Ack:        true,
+		Deltas:     deltas,
+		DeltaTypes: deltaTypes,
+	}
+}
@@ -12,8 +12,8 @@ import (
 
 func TestResolveTroopCombat_NormalHit(t *testing.T) {
 	gs := NewTestGame().
-		WithTroop("p1", model.TroopMarine, hex.NewCoord(0, 0, 0), true).
-		WithTroop("p2", model.TroopMarine, hex.NewCoord(1, -1, 0), true).
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(1, -1, 0), true).
 		Build()
 
 	attacker := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
@@ -50,7 +50,7 @@ func TestResolveTroopCombat_NormalHit(t *testing.T) {
 
 func TestResolveStructureAttack(t *testing.T) {
 	gs := NewTestGame().
-		WithTroop("p1", model.TroopMech, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopMech, hex.NewCoord(0, 0, 0), true).
 		WithStructure(model.StructureOutpost, "p2", hex.NewCoord(1, -1, 0)).
 		Build()
 
@@ -73,8 +73,10 @@ func TestResolveStructureAttack(t *testing.T) {
 }
 
 func TestAntiStructureMultiplier(t *testing.T) {
+	gs := NewTestGame().Build()
+
 	// Mech has 2x multiplier
-	assert.Equal(t, 2, AntiStructureMultiplier(model.TroopMech))
+	assert.Equal(t, 2, AntiStructureMultiplier(gs, troopMech))
 	// Marine has 1x multiplier
-	assert.Equal(t, 1, AntiStructureMultiplier(model.TroopMarine))
+	assert.Equal(t, 1, AntiStructureMultiplier(gs, troopMarine))
 }
@@ -2,6 +2,8 @@ package game
 
 import (
 	"fmt"
+	"sort"
+	"sync/atomic"
 
 	"github.com/teomiscia/hexbattle/internal/config"
 	"github.com/teomiscia/hexbattle/internal/dice"
@@ -9,33 +11,144 @@ import (
 	"github.com/teomiscia/hexbattle/internal/model"
 )
 
-// Balance holds the loaded game balance constants.
-// Set once at startup via LoadBalance().
-var Balance *config.BalanceData
+// balance holds the server's current balance data: the ruleset new games start with.
+// Replaced by LoadBalance when the balance file is reloaded; running games keep theirs.
+var balance atomic.Pointer[config.BalanceData]
 
-// LoadBalance initializes the global balance data from a config.
+// CurrentBalance returns the server's current balance data, or nil if none is loaded.
+func CurrentBalance() *config.BalanceData {
+	return balance.Load()
+}
+
+// LoadBalance makes b the server's balance data.
+// The terrain table is replaced by the balance file's terrain types when it defines any.
 func LoadBalance(b *config.BalanceData) {
-	Balance = b
+	balance.Store(b)
+	if len(b.Terrain) > 0 {
+		model.SetTerrainTable(TerrainTableFromBalance(b.Terrain))
+	}
+}
+
+// ReloadBalance reads the balance file again and makes it the ruleset for new games.
+// Running games stay pinned to the ruleset they started with.
+func ReloadBalance(path string) (*config.BalanceData, error) {
+	b, err := config.LoadBalance(path)
+	if err != nil {
+		return nil, err
+	}
+	LoadBalance(b)
+	return b, nil
+}
+
+// rules returns the ruleset the game is played with: its own resolved rules,
+// or the server's balance data for states created without one.
+func rules(gs *GameState) *config.BalanceData {
+	if gs != nil && gs.Rules != nil {
+		return gs.Rules
+	}
+	return CurrentBalance()
+}
+
+// TerrainTableFromBalance builds the terrain table from the balance file's terrain section.
+func TerrainTableFromBalance(terrain map[string]config.TerrainConfig) map[model.TerrainType]model.TerrainInfo {
+	table := make(map[model.TerrainType]model.TerrainInfo, len(terrain))
+	for name, tc := range terrain {
+		table[model.TerrainType(name)] = terrainInfo(model.TerrainType(name), tc)
+	}
+	return table
+}
+
+// terrainInfo converts a terrain entry from balance data.
+// Passable terrain costs at least 1 movement point; impassable terrain costs 0.
+func terrainInfo(t model.TerrainType, tc config.TerrainConfig) model.TerrainInfo {
+	info := model.TerrainInfo{
+		Type:         t,
+		MovementCost: tc.MovementCost,
+		ATKModifier:  tc.ATKModifier,
+		DEFModifier:  tc.DEFModifier,
+		Passable:     tc.IsPassable(),
+	}
+	if !info.Passable {
+		info.MovementCost = 0
+	} else if info.MovementCost < 1 {
+		info.MovementCost = 1
+	}
+	return info
+}
+
+// TerrainInfo returns the properties of a terrain type under the game's ruleset.
+// Unknown types play as plains.
+func TerrainInfo(gs *GameState, t model.TerrainType) model.TerrainInfo {
+	b := rules(gs)
+	if b == nil || len(b.Terrain) == 0 {
+		return model.GetTerrainInfo(t)
+	}
+	tc, ok := b.Terrain[string(t)]
+	if !ok {
+		t = model.TerrainPlains
+		tc = b.Terrain[string(t)]
+	}
+	return terrainInfo(t, tc)
+}
+
+// TroopTypes returns every troop type in the game's roster, sorted by name.
+func TroopTypes(gs *GameState) []model.TroopType {
+	b := rules(gs)
+	if b == nil {
+		return nil
+	}
+	types := make([]model.TroopType, 0, len(b.Troops))
+	for name := range b.Troops {
+		types = append(types, model.TroopType(name))
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// IsTroopType returns whether the troop type is in the game's roster.
+func IsTroopType(gs *GameState, t model.TroopType) bool {
+	b := rules(gs)
+	if b == nil {
+		return false
+	}
+	_, ok := b.Troops[string(t)]
+	return ok
+}
+
+// TroopHasRole returns whether the troop type is tagged with the given role.
+func TroopHasRole(gs *GameState, t model.TroopType, role string) bool {
+	b := rules(gs)
+	if b == nil {
+		return false
+	}
+	for _, r := range b.Troops[string(t)].Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
 
 // TroopCost returns the coin cost for a troop type.
-func TroopCost(t model.TroopType) int {
-	if Balance == nil {
+func TroopCost(gs *GameState, t model.TroopType) int {
+	b := rules(gs)
+	if b == nil {
 		return 0
 	}
-	tc, ok := Balance.Troops[string(t)]
+	tc, ok := b.Troops[string(t)]
 	if !ok {
 		return 0
 	}
 	return tc.Cost
 }
 
-// NewTroopFromBalance creates a Troop with stats from balance data.
-func NewTroopFromBalance(id string, troopType model.TroopType, ownerID string, pos hex.Coord) (*model.Troop, error) {
-	if Balance == nil {
+// NewTroopFromBalance creates a Troop with stats from the game's ruleset.
+func NewTroopFromBalance(gs *GameState, id string, troopType model.TroopType, ownerID string, pos hex.Coord) (*model.Troop, error) {
+	b := rules(gs)
+	if b == nil {
 		return nil, fmt.Errorf("balance data not loaded")
 	}
-	tc, ok := Balance.Troops[string(troopType)]
+	tc, ok := b.Troops[string(troopType)]
 	if !ok {
 		return nil, fmt.Errorf("unknown troop type: %s", troopType)
 	}
@@ -51,6 +164,7 @@ func NewTroopFromBalance(id string, troopType model.TroopType, ownerID string, p
 		Mobility:          tc.Mobility,
 		Range:             tc.Range,
 		Damage:            tc.Damage,
+		Vision:            tc.Vision,
 		IsReady:           false, // cannot act on purchase turn
 		HasMoved:          false,
 		HasAttacked:       false,
@@ -59,12 +173,13 @@ func NewTroopFromBalance(id string, troopType model.TroopType, ownerID string, p
 	}, nil
 }
 
-// NewStructureFromBalance creates a Structure with stats from balance data.
-func NewStructureFromBalance(id string, sType model.StructureType, ownerID string, pos hex.Coord) (*model.Structure, error) {
-	if Balance == nil {
+// NewStructureFromBalance creates a Structure with stats from the game's ruleset.
+func NewStructureFromBalance(gs *GameState, id string, sType model.StructureType, ownerID string, pos hex.Coord) (*model.Structure, error) {
+	b := rules(gs)
+	if b == nil {
 		return nil, fmt.Errorf("balance data not loaded")
 	}
-	sc, ok := Balance.Structures[string(sType)]
+	sc, ok := b.Structures[string(sType)]
 	if !ok {
 		return nil, fmt.Errorf("unknown structure type: %s", sType)
 	}
@@ -79,6 +194,7 @@ func NewStructureFromBalance(id string, sType model.StructureType, ownerID strin
 		DEF:       sc.DEF,
 		Range:     sc.Range,
 		Damage:    sc.Damage,
+		Vision:    sc.Vision,
 		Income:    sc.Income,
 		CanSpawn:  sc.Spawn,
 	}, nil
@@ -95,11 +211,12 @@ func StructureDamageDice(s *model.Structure) (dice.DiceNotation, error) {
 }
 
 // AntiStructureMultiplier returns the damage multiplier a troop gets vs structures.
-func AntiStructureMultiplier(t model.TroopType) int {
-	if Balance == nil {
+func AntiStructureMultiplier(gs *GameState, t model.TroopType) int {
+	b := rules(gs)
+	if b == nil {
 		return 1
 	}
-	tc, ok := Balance.Troops[string(t)]
+	tc, ok := b.Troops[string(t)]
 	if !ok {
 		return 1
 	}
@@ -110,40 +227,46 @@ func AntiStructureMultiplier(t model.TroopType) int {
 }
 
 // PassiveIncome returns the base passive income per turn.
-func PassiveIncome() int {
-	if Balance == nil {
+func PassiveIncome(gs *GameState) int {
+	b := rules(gs)
+	if b == nil {
 		return 100
 	}
-	return Balance.Economy.PassiveIncome
+	return b.Economy.PassiveIncome
 }
 
 // StructureIncome returns the income bonus per owned structure.
-func StructureIncome() int {
-	if Balance == nil {
+func StructureIncome(gs *GameState) int {
+	b := rules(gs)
+	if b == nil {
 		return 50
 	}
-	return Balance.Economy.StructureIncome
+	return b.Economy.StructureIncome
 }
 
 // StartingCoins returns the starting coin amount.
-func StartingCoins() int {
-	if Balance == nil {
+func StartingCoins(gs *GameState) int {
+	b := rules(gs)
+	if b == nil {
 		return 1000
 	}
-	return Balance.Economy.StartingCoins
+	return b.Economy.StartingCoins
 }
 
 // HealingRate returns passive healing HP per turn.
-func HealingRate() int {
-	if Balance == nil {
+func HealingRate(gs *GameState) int {
+	b := rules(gs)
+	if b == nil {
 		return 2
 	}
-	return Balance.Healing.PassiveRate
+	return b.Healing.PassiveRate
 }
 
-// SuddenDeathThreshold returns the turn threshold for sudden death activation.
-func SuddenDeathThreshold(size model.MapSize) int {
-	if Balance == nil {
+// SuddenDeathThreshold returns the turn threshold for sudden death activation on the game's map size.
+func SuddenDeathThreshold(gs *GameState) int {
+	size := gs.MapSize
+	b := rules(gs)
+	if b == nil {
 		switch size {
 		case model.MapSizeSmall:
 			return 20
@@ -154,7 +277,7 @@ func SuddenDeathThreshold(size model.MapSize) int {
 		}
 		return 30
 	}
-	v, ok := Balance.SuddenDeath.TurnThresholds[string(size)]
+	v, ok := b.SuddenDeath.TurnThresholds[string(size)]
 	if !ok {
 		return 30
 	}
@@ -162,9 +285,19 @@ func SuddenDeathThreshold(size model.MapSize) int {
 }
 
 // DominanceTurnsRequired returns how many consecutive turns of structure majority are needed to win.
-func DominanceTurnsRequired() int {
-	if Balance == nil {
+func DominanceTurnsRequired(gs *GameState) int {
+	b := rules(gs)
+	if b == nil {
 		return 3
 	}
-	return Balance.WinCond.DominanceTurnsRequired
+	return b.WinCond.DominanceTurnsRequired
+}
+
+// SurrenderAfterTurn returns the turn number after which a player may surrender.
+func SurrenderAfterTurn(gs *GameState) int {
+	b := rules(gs)
+	if b == nil {
+		return 5
+	}
+	return b.WinCond.SurrenderAfterTurn
 }
@@ -7,13 +7,13 @@ import (
 // CalculateIncome computes the total income for the active player this turn.
 // Returns (passiveIncome, structureIncome, totalIncome).
 func CalculateIncome(gs *GameState, playerID string) (int, int, int) {
-	passive := PassiveIncome()
+	passive := PassiveIncome(gs)
 	structIncome := 0
 
 	for _, s := range gs.Structures {
 		if s.OwnerID == playerID {
 			if s.Type == model.StructureOutpost || s.Type == model.StructureCommandCenter {
-				structIncome += StructureIncome()
+				structIncome += StructureIncome(gs)
 			}
 		}
 	}
@@ -34,10 +34,10 @@ func CreditIncome(gs *GameState, playerID string) int {
 
 // ValidatePurchase checks if a player can buy a troop at a structure.
 func ValidatePurchase(gs *GameState, playerID string, troopType model.TroopType, structureID string) (model.ErrorCode, string) {
-	cost := TroopCost(troopType)
-	if cost == 0 {
+	if !IsTroopType(gs, troopType) {
 		return model.ErrInvalidMessage, "unknown troop type"
 	}
+	cost := TroopCost(gs, troopType)
 
 	idx := gs.PlayerIndex(playerID)
 	if idx < 0 {
@@ -70,7 +70,7 @@ func ValidatePurchase(gs *GameState, playerID string, troopType model.TroopType,
 
 // DeductCost subtracts the troop cost from the player's coins.
 func DeductCost(gs *GameState, playerID string, troopType model.TroopType) {
-	cost := TroopCost(troopType)
+	cost := TroopCost(gs, troopType)
 	idx := gs.PlayerIndex(playerID)
 	if idx >= 0 {
 		gs.Players[idx].Coins -= cost
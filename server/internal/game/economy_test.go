@@ -0,0 +1,41 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+func TestExecuteBuy_RosterFromBalance(t *testing.T) {
+	gs := NewTestGame().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		Build()
+	hq := gs.PlayerHQ("p1")
+
+	// A troop added to the balance file is buyable without code changes
+	CurrentBalance().Troops["medic"] = config.TroopConfig{Cost: 120, HP: 7, ATK: 1, DEF: 12, Mobility: 3, Range: 1, Damage: "1D4", Roles: []string{"support"}}
+	t.Cleanup(func() { delete(CurrentBalance().Troops, "medic") })
+
+	assert.Contains(t, TroopTypes(gs), model.TroopType("medic"))
+	assert.True(t, TroopHasRole(gs, "medic", "support"))
+	assert.False(t, TroopHasRole(gs, "medic", "infantry"))
+
+	result := ExecuteBuy(gs, "p1", "medic", hq.ID)
+	require.True(t, result.Ack)
+	purchased := result.Deltas[0].(*ws.TroopPurchasedData)
+	troop := gs.GetTroop(purchased.UnitID)
+	require.NotNil(t, troop)
+	assert.Equal(t, model.TroopType("medic"), troop.Type)
+	assert.Equal(t, 7, troop.MaxHP)
+	assert.Equal(t, 1000-120, gs.Players[0].Coins)
+
+	// Types missing from the roster are rejected
+	result = ExecuteBuy(gs, "p1", "dragon", hq.ID)
+	assert.False(t, result.Ack)
+	assert.Equal(t, model.ErrInvalidMessage, result.Error.Code)
+}
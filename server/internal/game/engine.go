@@ -3,6 +3,7 @@ package game
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -21,18 +22,47 @@ type Engine struct {
 	Store  store.Store
 	Bot    BotPlayer // nil for PvP games
 
+	// StandIn creates the bot that plays a disconnected player's turns in rooms with
+	// bot takeover. Nil disables takeover: disconnected players forfeit after 60 seconds.
+	StandIn func(playerID string) BotPlayer
+	// TakeoverTimeout is how long a player whose turns a stand-in plays has to come back.
+	TakeoverTimeout time.Duration
+	// SeedWindow is how long the players have, once both have joined, to send their
+	// client seeds before the game starts. Zero starts the game as soon as both have joined.
+	SeedWindow time.Duration
+	// OnGameOver, if set, is called from the engine's goroutine once the game has ended.
+	OnGameOver func()
+
+	// Events numbers the game events sent to each player and keeps them for resync.
+	Events *EventLog
+
+	// Log is the in-memory replay log, opened when the game starts.
+	// It is nil for games restored from a snapshot; the stored log is still appended to.
+	Log *ActionLog
+
 	actionChan     chan PlayerAction
 	disconnectChan chan string
 	reconnectChan  chan ReconnectEvent
 	turnTimer      *time.Timer
-	reconnectTimer *time.Timer
+	reconnectTimer *time.Timer // runs until the earliest reconnect deadline
 	botTimer       *time.Timer
-	disconnectedID string
+	moveTimer      *time.Timer         // a bot account's time for its next action
+	seedTimer      *time.Timer         // runs while the players may still send client seeds
+	committedTo    map[string]bool     // players sent the seed commitment, who may send a client seed
+	seeded         map[string]bool     // players who sent their client seed
+	absent         map[string]*absence // disconnected players by ID
+	seqs           *seqHistory
 	ctx            context.Context
 	cancel         context.CancelFunc
 	logger         *slog.Logger
 }
 
+// absence is a disconnected player's time to come back.
+type absence struct {
+	deadline time.Time
+	standIn  BotPlayer // playing their turns meanwhile, if taken over
+}
+
 // PlayerAction wraps an incoming action from a player.
 type PlayerAction struct {
 	PlayerID string
@@ -45,6 +75,7 @@ type PlayerAction struct {
 // ReconnectEvent signals that a player has reconnected.
 type ReconnectEvent struct {
 	PlayerID string
+	Seq      int // of the reconnect message
 	Conn     *ws.Connection
 }
 
@@ -54,8 +85,13 @@ func NewEngine(ctx context.Context, state *GameState, hub *ws.Hub, st store.Stor
 	return &Engine{
 		State:          state,
 		Hub:            hub,
-		Roller:         dice.NewRoller(state.Seed),
+		Roller:         dice.NewRollerAt(state.Seed, state.RollCount),
 		Store:          st,
+		Events:         NewEventLog(EventLogSize),
+		seqs:           newSeqHistory(SeqHistorySize),
+		absent:         make(map[string]*absence),
+		committedTo:    make(map[string]bool),
+		seeded:         make(map[string]bool),
 		actionChan:     make(chan PlayerAction, 32),
 		disconnectChan: make(chan string, 2),
 		reconnectChan:  make(chan ReconnectEvent, 2),
@@ -67,7 +103,8 @@ func NewEngine(ctx context.Context, state *GameState, hub *ws.Hub, st store.Stor
 	}
 }
 
-// SubmitAction sends a player action to the engine's event loop.
+// SubmitAction sends a player action to the engine's event loop. If the loop is too
+// far behind, the action is dropped and NACKed with RATE_LIMITED.
 func (e *Engine) SubmitAction(action PlayerAction) {
 	select {
 	case e.actionChan <- action:
@@ -76,6 +113,9 @@ func (e *Engine) SubmitAction(action PlayerAction) {
 			"player_id", action.PlayerID,
 			"type", action.Type,
 		)
+		if action.Conn != nil {
+			action.Conn.SendNack(action.Seq, action.Type, string(model.ErrRateLimited), "too many actions queued, try again")
+		}
 	}
 }
 
@@ -117,11 +157,17 @@ func (e *Engine) Run() {
 		if e.botTimer != nil {
 			e.botTimer.Stop()
 		}
+		if e.moveTimer != nil {
+			e.moveTimer.Stop()
+		}
+		if e.seedTimer != nil {
+			e.seedTimer.Stop()
+		}
 		e.logger.Info("game engine stopped")
 	}()
 
 	// If the game is already in progress (restored from snapshot), resume
-	if e.State.Phase == model.PhasePlayerAction {
+	if e.State.Phase == model.PhasePlayerAction || e.State.Phase == model.PhasePlanning {
 		e.startTurnTimer()
 		e.triggerBotIfNeeded()
 	}
@@ -137,6 +183,15 @@ func (e *Engine) Run() {
 		case <-e.botTimerChan():
 			e.playBotTurn()
 
+		case <-e.moveTimerChan():
+			e.handleMoveTimeout()
+
+		case <-e.seedTimerChan():
+			e.seedTimer = nil
+			if e.State.Phase == model.PhaseWaitingForPlayers {
+				e.startGame()
+			}
+
 		case playerID := <-e.disconnectChan:
 			e.handleDisconnect(playerID)
 
@@ -177,20 +232,55 @@ func (e *Engine) botTimerChan() <-chan time.Time {
 	return e.botTimer.C
 }
 
+// moveTimerChan returns the move timer's channel, or a nil channel if no timer is active.
+func (e *Engine) moveTimerChan() <-chan time.Time {
+	if e.moveTimer == nil {
+		return nil
+	}
+	return e.moveTimer.C
+}
+
+// seedTimerChan returns the seed window's channel, or a nil channel if it is not open.
+func (e *Engine) seedTimerChan() <-chan time.Time {
+	if e.seedTimer == nil {
+		return nil
+	}
+	return e.seedTimer.C
+}
+
 // IsBotGame returns true if a bot is attached to this engine.
 func (e *Engine) IsBotGame() bool {
 	return e.Bot != nil
 }
 
+// activeBot returns the bot playing the active player's turn: the game's bot, or the
+// stand-in for a disconnected player. Nil when it is a human's turn.
+func (e *Engine) activeBot() BotPlayer {
+	activeID := e.State.ActivePlayerID()
+	if e.Bot != nil && e.Bot.PlayerID() == activeID {
+		return e.Bot
+	}
+	if a := e.absent[activeID]; a != nil && a.standIn != nil {
+		return a.standIn
+	}
+	return nil
+}
+
+// botState returns the state a bot chooses its actions from. A stand-in sees only what
+// the player it plays for could see.
+func (e *Engine) botState(bot BotPlayer) *GameState {
+	if bot == e.Bot {
+		return e.State
+	}
+	return e.State.BotViewFor(bot.PlayerID())
+}
+
 // triggerBotIfNeeded schedules the bot's turn if it's the active player.
 func (e *Engine) triggerBotIfNeeded() {
-	if e.Bot == nil {
-		return
-	}
 	if e.State.Phase != model.PhasePlayerAction {
 		return
 	}
-	if e.State.ActivePlayerID() != e.Bot.PlayerID() {
+	if e.activeBot() == nil {
 		return
 	}
 	// Small delay so the human player sees the turn_start before the bot acts.
@@ -202,14 +292,15 @@ func (e *Engine) triggerBotIfNeeded() {
 
 // playBotTurn executes the bot's actions one at a time with delays between them.
 func (e *Engine) playBotTurn() {
-	if e.Bot == nil || e.State.Phase != model.PhasePlayerAction {
+	if e.State.Phase != model.PhasePlayerAction {
 		return
 	}
-	if e.State.ActivePlayerID() != e.Bot.PlayerID() {
+	bot := e.activeBot()
+	if bot == nil {
 		return
 	}
 
-	botID := e.Bot.PlayerID()
+	botID := bot.PlayerID()
 
 	// Execute actions one at a time with a delay per action.
 	for {
@@ -217,28 +308,20 @@ func (e *Engine) playBotTurn() {
 			return
 		}
 
-		action := e.Bot.NextAction(e.State)
+		action := bot.NextAction(e.botState(bot))
 		if action == nil {
 			break // bot is done, end turn
 		}
 
-		var result *ActionResult
-		switch action.Type {
-		case BotActionBuy:
-			result = ExecuteBuy(e.State, botID, action.TroopType, action.StructureID)
-		case BotActionMove:
-			result = ExecuteMove(e.State, botID, action.UnitID, action.Target)
-		case BotActionAttack:
-			result = ExecuteAttack(e.State, e.Roller, botID, action.UnitID, action.Target)
-		}
-
-		if result != nil && result.Ack {
+		result := ExecuteBotAction(e.State, e.Roller, botID, action)
+		if result.Ack {
+			e.recordBotAction(botID, action)
 			e.broadcastDeltas(result)
 			if result.GameOver != nil {
 				e.endGame(result.GameOver)
 				return
 			}
-		} else if result != nil {
+		} else {
 			e.logger.Debug("bot action rejected",
 				"type", action.Type,
 				"error", result.Error.Message,
@@ -247,11 +330,13 @@ func (e *Engine) playBotTurn() {
 	}
 
 	// Bot is done — end its turn.
+	turn := e.State.TurnNumber
 	endResult := ExecuteEndTurn(e.State, e.Roller, botID)
 	if !endResult.Ack {
 		e.logger.Warn("bot end_turn rejected", "error", endResult.Error.Message)
 		return
 	}
+	e.record(turn, ws.MsgEndTurn, botID, nil)
 
 	if e.turnTimer != nil {
 		e.turnTimer.Stop()
@@ -279,9 +364,29 @@ func (e *Engine) handleAction(action PlayerAction) {
 		"phase", e.State.Phase,
 	)
 
+	// Actions without a sequence number cannot be told apart and are always played
+	if seqTracked[action.Type] && action.Seq > 0 {
+		if reply, ok := e.seqs.accept(action.PlayerID, action.Seq); !ok {
+			if reply == nil {
+				e.sendNack(action, string(model.ErrSeqOutOfOrder), "sequence number is out of order")
+				return
+			}
+			e.logger.Debug("answering retried action",
+				"player_id", action.PlayerID,
+				"type", action.Type,
+				"seq", action.Seq,
+			)
+			e.resendReply(action, reply)
+			return
+		}
+	}
+
 	switch action.Type {
 	case ws.MsgJoinGame:
+		e.seqs.restart(action.PlayerID, action.Seq)
 		e.handleJoinGame(action)
+	case ws.MsgClientSeed:
+		e.handleClientSeed(action)
 	case ws.MsgMove:
 		e.handleMove(action)
 	case ws.MsgAttack:
@@ -290,8 +395,28 @@ func (e *Engine) handleAction(action PlayerAction) {
 		e.handleBuy(action)
 	case ws.MsgEndTurn:
 		e.handleEndTurn(action)
+	case ws.MsgOrderMove:
+		e.handleOrderMove(action)
+	case ws.MsgOrderAttack:
+		e.handleOrderAttack(action)
+	case ws.MsgOrderBuy:
+		e.handleOrderBuy(action)
+	case ws.MsgCancelOrder:
+		e.handleCancelOrder(action)
+	case ws.MsgSubmitOrders:
+		e.handleSubmitOrders(action)
+	case ws.MsgSurrender:
+		e.handleSurrender(action)
 	case ws.MsgEmote:
 		e.handleEmote(action)
+	case ws.MsgPreviewAttack:
+		e.handlePreviewAttack(action)
+	case ws.MsgPreviewPath:
+		e.handlePreviewPath(action)
+	case ws.MsgRequestState:
+		e.handleRequestState(action)
+	case ws.MsgResync:
+		e.handleResync(action)
 	case ws.MsgPong:
 		// No-op, handled at connection level
 	default:
@@ -301,24 +426,102 @@ func (e *Engine) handleAction(action PlayerAction) {
 
 // handleJoinGame processes a join_game message.
 func (e *Engine) handleJoinGame(action PlayerAction) {
-	// Player is joining the game — send them the full state
+	var data ws.JoinGameData
+	if len(action.Data) > 0 {
+		if err := json.Unmarshal(action.Data, &data); err != nil {
+			e.sendNack(action, string(model.ErrInvalidMessage), "invalid join data")
+			return
+		}
+	}
+
+	// Player is joining the game — send them the full state, with the seed commitment
 	e.sendAck(action)
 	e.sendFullState(action.PlayerID)
 
-	// For bot games, only 1 human connection is needed to start.
-	if e.IsBotGame() && e.Hub.ConnectedCount() >= 1 && e.State.Phase == model.PhaseWaitingForPlayers {
-		e.startGame()
+	if e.State.Phase != model.PhaseWaitingForPlayers {
+		return
+	}
+	if e.State.SeedCommitment != "" {
+		e.committedTo[action.PlayerID] = true
+	}
+	if e.playersJoined() {
+		e.awaitSeeds()
+	}
+}
+
+// playersJoined reports whether the game has the connections it needs to start.
+// For bot games, only 1 human connection is needed.
+func (e *Engine) playersJoined() bool {
+	if e.IsBotGame() {
+		return e.Hub.ConnectedCount() >= 1
+	}
+	return e.Hub.ConnectedCount() >= 2
+}
+
+// handleClientSeed records the entropy a player mixes into the dice seed. A client seed
+// is only taken once the player has been sent the seed commitment, so the server seed
+// is fixed before the server sees it, and only until the game starts.
+func (e *Engine) handleClientSeed(action PlayerAction) {
+	var data ws.ClientSeedData
+	if err := json.Unmarshal(action.Data, &data); err != nil {
+		e.sendNack(action, string(model.ErrInvalidMessage), "invalid client seed data")
+		return
+	}
+	switch {
+	case e.State.Phase != model.PhaseWaitingForPlayers:
+		e.sendNack(action, string(model.ErrInvalidMessage), "the game has already started")
+		return
+	case !e.committedTo[action.PlayerID]:
+		e.sendNack(action, string(model.ErrInvalidMessage), "client seed sent before the seed commitment")
+		return
+	case e.seeded[action.PlayerID]:
+		e.sendNack(action, string(model.ErrInvalidMessage), "client seed already sent")
+		return
+	case len(data.ClientSeed) > dice.MaxClientSeedLen:
+		e.sendNack(action, string(model.ErrInvalidMessage), fmt.Sprintf("client seed must be at most %d bytes", dice.MaxClientSeedLen))
 		return
 	}
 
-	// Check if both players are connected
-	if e.Hub.ConnectedCount() >= 2 && e.State.Phase == model.PhaseWaitingForPlayers {
+	e.State.SetClientSeed(action.PlayerID, data.ClientSeed)
+	e.seeded[action.PlayerID] = true
+	e.sendAck(action)
+
+	if e.playersJoined() {
+		e.awaitSeeds()
+	}
+}
+
+// awaitSeeds starts the game once every human player has sent a client seed, or opens
+// the seed window after which it starts anyway.
+func (e *Engine) awaitSeeds() {
+	if e.SeedWindow <= 0 || e.State.SeedCommitment == "" || e.allSeeded() {
 		e.startGame()
+		return
+	}
+	if e.seedTimer == nil {
+		e.seedTimer = time.NewTimer(e.SeedWindow)
 	}
 }
 
+// allSeeded reports whether every player but the game's bot has sent a client seed.
+func (e *Engine) allSeeded() bool {
+	for _, p := range e.State.Players {
+		if e.Bot != nil && e.Bot.PlayerID() == p.ID {
+			continue
+		}
+		if !e.seeded[p.ID] {
+			return false
+		}
+	}
+	return true
+}
+
 // startGame transitions from WaitingForPlayers to the first turn.
 func (e *Engine) startGame() {
+	if e.seedTimer != nil {
+		e.seedTimer.Stop()
+		e.seedTimer = nil
+	}
 	e.State.Phase = model.PhaseGeneratingMap
 	if e.IsBotGame() {
 		e.logger.Info("bot game starting")
@@ -332,12 +535,17 @@ func (e *Engine) startGame() {
 	// Broadcast full game state to both players
 	e.broadcastFullState()
 
-	// Start first turn
-	e.State.TurnNumber = 1
-	turnStart := RunTurnStart(e.State, e.Roller)
+	// Mix the players' seeds into the committed server seed before the first roll
+	if e.State.FinalizeSeed() {
+		e.Roller = dice.NewRoller(e.State.Seed)
+	}
+
+	// Open the replay log with the starting position
+	e.startLog()
 
-	e.State.Phase = model.PhasePlayerAction
-	e.Hub.BroadcastMessage(ws.MsgTurnStart, turnStart)
+	// Start first turn
+	turnStart := StartFirstTurn(e.State, e.Roller)
+	e.broadcastDelta(ws.MsgTurnStart, turnStart)
 	e.startTurnTimer()
 
 	// If the bot goes first, trigger its turn.
@@ -361,7 +569,9 @@ func (e *Engine) handleMove(action PlayerAction) {
 	}
 
 	e.sendAck(action)
+	e.recordAction(e.State.TurnNumber, action)
 	e.broadcastDeltas(result)
+	e.startMoveTimer() // a bot account's clock restarts with every action it gets through
 }
 
 // handleAttack processes an attack action.
@@ -381,11 +591,14 @@ func (e *Engine) handleAttack(action PlayerAction) {
 	}
 
 	e.sendAck(action)
+	e.recordAction(e.State.TurnNumber, action)
 	e.broadcastDeltas(result)
 
 	if result.GameOver != nil {
 		e.endGame(result.GameOver)
+		return
 	}
+	e.startMoveTimer()
 }
 
 // handleBuy processes a buy action.
@@ -404,11 +617,14 @@ func (e *Engine) handleBuy(action PlayerAction) {
 	}
 
 	e.sendAck(action)
+	e.recordAction(e.State.TurnNumber, action)
 	e.broadcastDeltas(result)
+	e.startMoveTimer()
 }
 
 // handleEndTurn processes an end_turn action.
 func (e *Engine) handleEndTurn(action PlayerAction) {
+	turn := e.State.TurnNumber
 	result := ExecuteEndTurn(e.State, e.Roller, action.PlayerID)
 
 	if !result.Ack {
@@ -417,6 +633,7 @@ func (e *Engine) handleEndTurn(action PlayerAction) {
 	}
 
 	e.sendAck(action)
+	e.recordAction(turn, action)
 
 	if e.turnTimer != nil {
 		e.turnTimer.Stop()
@@ -443,20 +660,235 @@ func (e *Engine) handleEndTurn(action PlayerAction) {
 	e.triggerBotIfNeeded()
 }
 
-// handleEmote forwards an emote to the opponent.
+// handleSurrender processes a surrender action and ends the game.
+func (e *Engine) handleSurrender(action PlayerAction) {
+	result := ExecuteSurrender(e.State, action.PlayerID)
+
+	if !result.Ack {
+		e.sendNack(action, string(result.Error.Code), result.Error.Message)
+		return
+	}
+
+	e.sendAck(action)
+	e.recordAction(e.State.TurnNumber, action)
+	e.logger.Info("player surrendered",
+		"player_id", action.PlayerID,
+	)
+	e.endGame(result.GameOver)
+}
+
+// handleOrderMove queues a move order during the simultaneous planning phase.
+func (e *Engine) handleOrderMove(action PlayerAction) {
+	var data ws.MoveData
+	if err := json.Unmarshal(action.Data, &data); err != nil {
+		e.sendNack(action, string(model.ErrInvalidMessage), "invalid move order data")
+		return
+	}
+
+	target := hex.NewCoord(data.TargetQ, data.TargetR, data.TargetS)
+	e.replyToOrder(action, QueueMoveOrder(e.State, action.PlayerID, data.UnitID, target))
+}
+
+// handleOrderAttack queues an attack order during the simultaneous planning phase.
+func (e *Engine) handleOrderAttack(action PlayerAction) {
+	var data ws.AttackData
+	if err := json.Unmarshal(action.Data, &data); err != nil {
+		e.sendNack(action, string(model.ErrInvalidMessage), "invalid attack order data")
+		return
+	}
+
+	target := hex.NewCoord(data.TargetQ, data.TargetR, data.TargetS)
+	e.replyToOrder(action, QueueAttackOrder(e.State, action.PlayerID, data.UnitID, target))
+}
+
+// handleOrderBuy queues a buy order during the simultaneous planning phase.
+func (e *Engine) handleOrderBuy(action PlayerAction) {
+	var data ws.BuyData
+	if err := json.Unmarshal(action.Data, &data); err != nil {
+		e.sendNack(action, string(model.ErrInvalidMessage), "invalid buy order data")
+		return
+	}
+
+	e.replyToOrder(action, QueueBuyOrder(e.State, action.PlayerID, data.UnitType, data.StructureID))
+}
+
+// handleCancelOrder withdraws queued orders during the simultaneous planning phase.
+func (e *Engine) handleCancelOrder(action PlayerAction) {
+	var data ws.CancelOrderData
+	if len(action.Data) > 0 {
+		if err := json.Unmarshal(action.Data, &data); err != nil {
+			e.sendNack(action, string(model.ErrInvalidMessage), "invalid cancel order data")
+			return
+		}
+	}
+
+	e.replyToOrder(action, CancelOrders(e.State, action.PlayerID, data.UnitID))
+}
+
+// replyToOrder ACKs or NACKs an order. Queued orders are private, so nothing is broadcast.
+func (e *Engine) replyToOrder(action PlayerAction, result *ActionResult) {
+	if !result.Ack {
+		e.sendNack(action, string(result.Error.Code), result.Error.Message)
+		return
+	}
+	e.sendAck(action)
+	e.recordAction(e.State.TurnNumber, action)
+}
+
+// handleSubmitOrders locks in a player's orders and resolves the turn once both are in.
+func (e *Engine) handleSubmitOrders(action PlayerAction) {
+	result := SubmitOrders(e.State, action.PlayerID)
+
+	if !result.Ack {
+		e.sendNack(action, string(result.Error.Code), result.Error.Message)
+		return
+	}
+
+	e.sendAck(action)
+	e.recordAction(e.State.TurnNumber, action)
+	e.broadcastDeltas(result)
+
+	if e.State.AllOrdersSubmitted() {
+		e.resolveSimultaneousTurn()
+	}
+}
+
+// resolveSimultaneousTurn runs the simultaneous resolution pipeline:
+// movement, attacks and purchases, then structure combat, then the next turn start.
+func (e *Engine) resolveSimultaneousTurn() {
+	if e.turnTimer != nil {
+		e.turnTimer.Stop()
+	}
+
+	result := ResolveSimultaneousTurn(e.State, e.Roller)
+	e.broadcastDeltas(result)
+
+	if result.GameOver != nil {
+		e.endGame(result.GameOver)
+		return
+	}
+
+	e.runStructureCombat()
+
+	next := AdvanceSimultaneousTurn(e.State, e.Roller)
+	e.broadcastDeltas(next)
+
+	if next.GameOver != nil {
+		e.endGame(next.GameOver)
+		return
+	}
+
+	e.snapshotState()
+	e.startTurnTimer()
+}
+
+// handleEmote acks an emote and forwards it to the opponent.
 func (e *Engine) handleEmote(action PlayerAction) {
 	var data ws.EmoteData
 	if err := json.Unmarshal(action.Data, &data); err != nil {
+		e.sendNack(action, string(model.ErrInvalidMessage), "invalid emote data")
 		return
 	}
 
+	e.sendAck(action)
 	data.PlayerID = action.PlayerID
 	opponentID := e.State.Players[1-e.State.PlayerIndex(action.PlayerID)].ID
 	e.Hub.SendMessageTo(opponentID, ws.MsgEmote, data)
 }
 
+// handlePreviewAttack answers the asking player with the odds of an attack.
+// Nothing is rolled or recorded.
+func (e *Engine) handlePreviewAttack(action PlayerAction) {
+	var data ws.AttackData
+	if err := json.Unmarshal(action.Data, &data); err != nil {
+		e.sendNack(action, string(model.ErrInvalidMessage), "invalid preview data")
+		return
+	}
+
+	target := hex.NewCoord(data.TargetQ, data.TargetR, data.TargetS)
+	preview, code, msg := PreviewAttack(e.State, action.PlayerID, data.UnitID, target)
+	if preview == nil {
+		e.sendNack(action, string(code), msg)
+		return
+	}
+
+	preview.Seq = action.Seq
+	e.Hub.SendMessageTo(action.PlayerID, ws.MsgAttackPreview, preview)
+}
+
+// handlePreviewPath answers the asking player with the route a troop would take to a hex.
+func (e *Engine) handlePreviewPath(action PlayerAction) {
+	var data ws.MoveData
+	if err := json.Unmarshal(action.Data, &data); err != nil {
+		e.sendNack(action, string(model.ErrInvalidMessage), "invalid preview data")
+		return
+	}
+
+	target := hex.NewCoord(data.TargetQ, data.TargetR, data.TargetS)
+	preview, code, msg := PreviewPath(e.State, action.PlayerID, data.UnitID, target)
+	if preview == nil {
+		e.sendNack(action, string(code), msg)
+		return
+	}
+
+	preview.Seq = action.Seq
+	e.Hub.SendMessageTo(action.PlayerID, ws.MsgPathPreview, preview)
+}
+
+// handleRequestState sends the player the game state as they may see it, so a client
+// that lost track of the deltas can start again from a full state.
+func (e *Engine) handleRequestState(action PlayerAction) {
+	switch e.State.Phase {
+	case model.PhaseWaitingForPlayers, model.PhaseGeneratingMap:
+		e.sendNack(action, string(model.ErrInvalidMessage), "game has not started")
+		return
+	}
+	e.sendFullState(action.PlayerID)
+}
+
+// handleResync sends the player the game events they missed after the given one, or a
+// full game state if those are no longer all kept.
+func (e *Engine) handleResync(action PlayerAction) {
+	var data ws.ResyncData
+	if err := json.Unmarshal(action.Data, &data); err != nil {
+		e.sendNack(action, string(model.ErrInvalidMessage), "invalid resync data")
+		return
+	}
+	switch e.State.Phase {
+	case model.PhaseWaitingForPlayers, model.PhaseGeneratingMap:
+		e.sendNack(action, string(model.ErrInvalidMessage), "game has not started")
+		return
+	}
+
+	e.sendAck(action)
+	missed, ok := e.Events.Since(action.PlayerID, data.Since)
+	if !ok {
+		e.logger.Debug("resync falling back to full state",
+			"player_id", action.PlayerID,
+			"since", data.Since,
+			"last", e.Events.Last(action.PlayerID),
+		)
+		e.sendFullState(action.PlayerID)
+		return
+	}
+	for _, msg := range missed {
+		e.Hub.SendTo(action.PlayerID, msg)
+	}
+}
+
 // handleTurnTimeout auto-ends the turn when the timer expires.
+// In simultaneous mode, orders auto-submit as they stand.
 func (e *Engine) handleTurnTimeout() {
+	if e.State.IsSimultaneous() {
+		if e.State.Phase != model.PhasePlanning {
+			return
+		}
+		e.logger.Info("planning timer expired", "turn", e.State.TurnNumber)
+		e.record(e.State.TurnNumber, LogTimeout, "", nil)
+		e.resolveSimultaneousTurn()
+		return
+	}
+
 	if e.State.Phase != model.PhasePlayerAction {
 		return
 	}
@@ -466,8 +898,10 @@ func (e *Engine) handleTurnTimeout() {
 		"player_id", e.State.ActivePlayerID(),
 	)
 
-	result := ExecuteEndTurn(e.State, e.Roller, e.State.ActivePlayerID())
+	turn, playerID := e.State.TurnNumber, e.State.ActivePlayerID()
+	result := ExecuteEndTurn(e.State, e.Roller, playerID)
 	if result.Ack {
+		e.record(turn, LogTimeout, playerID, nil)
 		e.runStructureCombat()
 		e.broadcastDeltas(result)
 
@@ -482,6 +916,19 @@ func (e *Engine) handleTurnTimeout() {
 	}
 }
 
+// handleMoveTimeout ends a bot account's turn when it takes too long over an action.
+func (e *Engine) handleMoveTimeout() {
+	e.moveTimer = nil
+	if e.State.Phase != model.PhasePlayerAction {
+		return
+	}
+	e.logger.Info("move time limit expired",
+		"turn", e.State.TurnNumber,
+		"player_id", e.State.ActivePlayerID(),
+	)
+	e.handleTurnTimeout()
+}
+
 // handleDisconnect handles a player disconnection.
 func (e *Engine) handleDisconnect(playerID string) {
 	// Ignore disconnects from the bot player (it's never really connected).
@@ -494,19 +941,60 @@ func (e *Engine) handleDisconnect(playerID string) {
 	)
 
 	idx := e.State.PlayerIndex(playerID)
-	if idx >= 0 {
-		e.State.Players[idx].IsDisconnected = true
+	if idx < 0 {
+		return
 	}
-
-	e.disconnectedID = playerID
+	if e.absent[playerID] != nil {
+		return // already counting down
+	}
+	e.State.Players[idx].IsDisconnected = true
+
+	// With bot takeover, a bot plays their turns until they come back or the longer cap runs out
+	a := &absence{deadline: time.Now().Add(60 * time.Second)}
+	if e.State.BotTakeover && e.StandIn != nil && e.TakeoverTimeout > 0 {
+		a.standIn = e.StandIn(playerID)
+		a.deadline = time.Now().Add(e.TakeoverTimeout)
+		e.logger.Info("bot took over for disconnected player", "player_id", playerID)
+	}
+	e.absent[playerID] = a
 
 	// Notify opponent
-	e.Hub.BroadcastMessage(ws.MsgPlayerDisconnected, ws.PlayerDisconnectedData{
-		PlayerID: playerID,
+	e.broadcastEvent(ws.MsgPlayerDisconnected, ws.PlayerDisconnectedData{
+		PlayerID:    playerID,
+		BotTakeover: a.standIn != nil,
 	})
 
-	// Start 60-second reconnect timer
-	e.reconnectTimer = time.NewTimer(60 * time.Second)
+	e.startReconnectTimer()
+	e.triggerBotIfNeeded()
+}
+
+// startReconnectTimer runs the reconnect timer until the earliest deadline of the
+// disconnected players, and stops it if there are none.
+func (e *Engine) startReconnectTimer() {
+	if e.reconnectTimer != nil {
+		e.reconnectTimer.Stop()
+		e.reconnectTimer = nil
+	}
+	if _, deadline, ok := e.nextForfeit(); ok {
+		e.reconnectTimer = time.NewTimer(time.Until(deadline))
+	}
+}
+
+// nextForfeit returns the disconnected player whose time runs out first, in player
+// order on a tie, and false if everyone is connected.
+func (e *Engine) nextForfeit() (string, time.Time, bool) {
+	var next string
+	var deadline time.Time
+	for _, p := range e.State.Players {
+		a := e.absent[p.ID]
+		if a == nil {
+			continue
+		}
+		if next == "" || a.deadline.Before(deadline) {
+			next, deadline = p.ID, a.deadline
+		}
+	}
+	return next, deadline, next != ""
 }
 
 // handleReconnect handles a player reconnecting.
@@ -519,13 +1007,18 @@ func (e *Engine) handleReconnect(event ReconnectEvent) {
 	if idx >= 0 {
 		e.State.Players[idx].IsDisconnected = false
 	}
+	e.seqs.restart(event.PlayerID, event.Seq)
 
-	// Cancel reconnect timer
-	if e.reconnectTimer != nil {
-		e.reconnectTimer.Stop()
-		e.reconnectTimer = nil
+	// Hand control back to the player, even if the stand-in was about to play their turn
+	if a := e.absent[event.PlayerID]; a != nil {
+		delete(e.absent, event.PlayerID)
+		if a.standIn != nil && e.botTimer != nil && e.State.ActivePlayerID() == event.PlayerID {
+			e.botTimer.Stop()
+			e.botTimer = nil
+		}
 	}
-	e.disconnectedID = ""
+	// The opponent may still be away
+	e.startReconnectTimer()
 
 	// Register the new connection
 	e.Hub.Register(event.Conn)
@@ -534,55 +1027,38 @@ func (e *Engine) handleReconnect(event ReconnectEvent) {
 	e.sendFullState(event.PlayerID)
 
 	// Notify opponent
-	e.Hub.BroadcastMessage(ws.MsgPlayerReconnected, ws.PlayerReconnectedData{
+	e.broadcastEvent(ws.MsgPlayerReconnected, ws.PlayerReconnectedData{
 		PlayerID: event.PlayerID,
 	})
 }
 
-// handleReconnectTimeout forfeits the disconnected player.
+// handleReconnectTimeout forfeits the disconnected player whose time ran out first.
 func (e *Engine) handleReconnectTimeout() {
-	if e.disconnectedID == "" {
+	e.reconnectTimer = nil
+	playerID, _, ok := e.nextForfeit()
+	if !ok || e.State.Phase == model.PhaseGameOver {
 		return
 	}
 
 	e.logger.Info("reconnect timeout expired",
-		"player_id", e.disconnectedID,
+		"player_id", playerID,
 	)
 
-	gameOver := CheckDisconnectForfeit(e.State, e.disconnectedID)
+	e.absent = make(map[string]*absence)
+	e.record(e.State.TurnNumber, LogForfeit, playerID, nil)
+	gameOver := CheckDisconnectForfeit(e.State, playerID)
 	e.endGame(gameOver)
 }
 
 // runStructureCombat executes the structure auto-attack phase.
 func (e *Engine) runStructureCombat() {
-	e.State.Phase = model.PhaseStructureCombat
-
-	// All structures fire (if they have a valid target).
-	// Neutral structures fire every turn transition.
-	// Owned structures fire every turn transition (at enemies).
-	for _, structure := range e.State.Structures {
-		target := FindStructureTarget(e.State, e.Roller, structure)
-		if target == nil {
-			continue
-		}
-
-		result := ResolveStructureFire(e.State, e.Roller, structure, target)
-		e.Hub.BroadcastMessage(ws.MsgStructureFires, result)
-
-		if result.Killed {
-			destroyedData := &ws.TroopDestroyedData{
-				UnitID: target.ID,
-				HexQ:   target.Hex.Q,
-				HexR:   target.Hex.R,
-				HexS:   target.Hex.S,
-				Cause:  "structure_fire",
-			}
-			e.Hub.BroadcastMessage(ws.MsgTroopDestroyed, destroyedData)
-			e.State.RemoveTroop(target.ID)
+	result := RunStructureCombat(e.State, e.Roller)
+	for _, delta := range result.Deltas {
+		if fire, ok := delta.(*ws.StructureFiresData); ok {
+			e.record(e.State.TurnNumber, LogStructureFire, "", fire)
 		}
 	}
-
-	e.State.Phase = model.PhasePlayerAction
+	e.broadcastDeltas(result)
 }
 
 // startTurnTimer starts the turn countdown timer.
@@ -593,6 +1069,23 @@ func (e *Engine) startTurnTimer() {
 	duration := time.Duration(e.State.TurnTimer) * time.Second
 	e.turnTimer = time.NewTimer(duration)
 	e.State.TurnStartedAt = time.Now()
+	e.startMoveTimer()
+}
+
+// startMoveTimer starts the clock on the active player's next action if they are a bot
+// account playing under the room's move time limit, and stops it otherwise.
+func (e *Engine) startMoveTimer() {
+	if e.moveTimer != nil {
+		e.moveTimer.Stop()
+		e.moveTimer = nil
+	}
+	if e.State.MoveTimeLimit <= 0 || e.State.Phase != model.PhasePlayerAction {
+		return
+	}
+	if !e.State.ActivePlayerState().IsBot || e.activeBot() != nil {
+		return // a person, or an in-process bot standing in
+	}
+	e.moveTimer = time.NewTimer(time.Duration(e.State.MoveTimeLimit) * time.Second)
 }
 
 // endGame handles game over state.
@@ -602,18 +1095,110 @@ func (e *Engine) endGame(gameOver *ws.GameOverData) {
 	if e.turnTimer != nil {
 		e.turnTimer.Stop()
 	}
+	if e.moveTimer != nil {
+		e.moveTimer.Stop()
+		e.moveTimer = nil
+	}
 
 	e.logger.Info("game over",
 		"winner_id", gameOver.WinnerID,
 		"reason", gameOver.Reason,
 	)
 
-	e.Hub.BroadcastMessage(ws.MsgGameOver, gameOver)
+	// Reveal the dice seeds so players can verify every roll
+	gameOver.Fairness = e.State.SeedReveal()
+
+	e.broadcastEvent(ws.MsgGameOver, gameOver)
+	e.record(e.State.TurnNumber, LogGameOver, "", gameOver)
 	e.snapshotState()
+
+	if e.OnGameOver != nil {
+		e.OnGameOver()
+	}
+}
+
+// startLog opens the replay log with the current state as the starting position.
+func (e *Engine) startLog() {
+	log, err := NewActionLog(e.State)
+	if err != nil {
+		e.logger.Error("failed to open replay log", "error", err)
+		return
+	}
+	e.Log = log
+	e.persistReplay(log.ReplayHeader)
+}
+
+// record appends an entry to the replay log.
+func (e *Engine) record(turn int, entryType, playerID string, data interface{}) {
+	entry, err := NewLogEntry(turn, entryType, playerID, data)
+	if err != nil {
+		e.logger.Error("failed to record replay entry", "type", entryType, "error", err)
+		return
+	}
+	if e.Log != nil {
+		e.Log.Append(entry)
+	}
+	e.persistReplay(entry)
+}
+
+// recordAction logs an accepted client action as received.
+func (e *Engine) recordAction(turn int, action PlayerAction) {
+	var data interface{}
+	if len(action.Data) > 0 {
+		data = action.Data
+	}
+	e.record(turn, action.Type, action.PlayerID, data)
+}
+
+// recordBotAction logs an accepted bot action as the equivalent client message.
+func (e *Engine) recordBotAction(botID string, action *BotAction) {
+	turn := e.State.TurnNumber
+	switch action.Type {
+	case BotActionBuy:
+		e.record(turn, ws.MsgBuy, botID, ws.BuyData{
+			UnitType:    action.TroopType,
+			StructureID: action.StructureID,
+		})
+	case BotActionMove:
+		e.record(turn, ws.MsgMove, botID, ws.MoveData{
+			UnitID:  action.UnitID,
+			TargetQ: action.Target.Q,
+			TargetR: action.Target.R,
+			TargetS: action.Target.S,
+		})
+	case BotActionAttack:
+		e.record(turn, ws.MsgAttack, botID, ws.AttackData{
+			UnitID:  action.UnitID,
+			TargetQ: action.Target.Q,
+			TargetR: action.Target.R,
+			TargetS: action.Target.S,
+		})
+	}
+}
+
+// persistReplay appends a record to the stored replay log.
+func (e *Engine) persistReplay(record interface{}) {
+	if e.Store == nil {
+		return
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		e.logger.Error("failed to serialize replay record", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := e.Store.AppendReplay(ctx, e.State.ID, data, ReplayTTL); err != nil {
+		e.logger.Error("failed to append replay record", "error", err)
+	}
 }
 
 // sendAck sends an ACK to the acting player.
 func (e *Engine) sendAck(action PlayerAction) {
+	e.seqs.answer(action.PlayerID, action.Seq, true, "", "")
 	if action.Conn != nil {
 		action.Conn.SendAck(action.Seq, action.Type)
 	}
@@ -621,26 +1206,84 @@ func (e *Engine) sendAck(action PlayerAction) {
 
 // sendNack sends a NACK to the acting player.
 func (e *Engine) sendNack(action PlayerAction, code, message string) {
+	e.seqs.answer(action.PlayerID, action.Seq, false, code, message)
 	if action.Conn != nil {
 		action.Conn.SendNack(action.Seq, action.Type, code, message)
 	}
 }
 
+// resendReply answers a retried action the way the first one was answered. An action
+// still without an answer is dropped; its answer is on the way.
+func (e *Engine) resendReply(action PlayerAction, reply *seqReply) {
+	if !reply.answered || action.Conn == nil {
+		return
+	}
+	if reply.ack {
+		action.Conn.SendAck(action.Seq, action.Type)
+		return
+	}
+	action.Conn.SendNack(action.Seq, action.Type, reply.code, reply.message)
+}
+
 // broadcastDeltas sends all delta messages from an ActionResult to both players.
+// Under fog of war each player receives their own filtered copy.
 func (e *Engine) broadcastDeltas(result *ActionResult) {
-	for i, delta := range result.Deltas {
-		e.Hub.BroadcastMessage(result.DeltaTypes[i], delta)
+	if !e.State.FogOfWar {
+		for i, delta := range result.Deltas {
+			e.broadcastEvent(result.DeltaTypes[i], delta)
+		}
+		return
+	}
+
+	for _, p := range e.State.Players {
+		types, deltas := e.State.FilterDeltas(p.ID, result.DeltaTypes, result.Deltas)
+		for i, delta := range deltas {
+			e.sendEvent(p.ID, types[i], delta)
+		}
 	}
 }
 
-// sendFullState sends the complete game state to a specific player.
+// broadcastEvent sends a game event to both players.
+func (e *Engine) broadcastEvent(msgType string, data interface{}) {
+	for _, p := range e.State.Players {
+		e.sendEvent(p.ID, msgType, data)
+	}
+}
+
+// sendEvent numbers a game event for the player and sends it. Events are numbered
+// whether or not the player is connected, so the count always matches the game.
+func (e *Engine) sendEvent(playerID, msgType string, data interface{}) {
+	msg, err := e.Events.Record(playerID, msgType, data)
+	if err != nil {
+		e.logger.Error("failed to encode event", "type", msgType, "error", err)
+		return
+	}
+	e.Hub.SendTo(playerID, msg)
+}
+
+// broadcastDelta sends a single delta message to both players.
+func (e *Engine) broadcastDelta(msgType string, delta interface{}) {
+	e.broadcastDeltas(&ActionResult{
+		Deltas:     []interface{}{delta},
+		DeltaTypes: []string{msgType},
+	})
+}
+
+// sendFullState sends the game state, as the player may see it, to a specific player.
 func (e *Engine) sendFullState(playerID string) {
-	e.Hub.SendMessageTo(playerID, ws.MsgGameState, e.State)
+	msg, err := e.Events.RecordState(playerID, e.State.StateFor(playerID))
+	if err != nil {
+		e.logger.Error("failed to encode game state", "error", err)
+		return
+	}
+	e.Hub.SendTo(playerID, msg)
 }
 
-// broadcastFullState sends the complete game state to all connected players.
+// broadcastFullState sends the game state to all players.
 func (e *Engine) broadcastFullState() {
-	e.Hub.BroadcastMessage(ws.MsgGameState, e.State)
+	for _, p := range e.State.Players {
+		e.sendFullState(p.ID)
+	}
 }
 
 // snapshotState persists the game state to Redis.
@@ -649,6 +1292,9 @@ func (e *Engine) snapshotState() {
 		return
 	}
 
+	// Save the dice position so a restored engine continues the same stream
+	e.State.RollCount = e.Roller.Draws()
+
 	data, err := e.State.Serialize()
 	if err != nil {
 		e.logger.Error("failed to serialize game state",
@@ -672,6 +1318,19 @@ func (e *Engine) snapshotState() {
 	}
 }
 
+// StartFirstTurn sets turn 1, runs its turn start pipeline and opens the action phase.
+func StartFirstTurn(gs *GameState, roller *dice.Roller) *ws.TurnStartData {
+	gs.TurnNumber = 1
+	var turnStart *ws.TurnStartData
+	if gs.IsSimultaneous() {
+		turnStart = RunSimultaneousTurnStart(gs, roller)
+	} else {
+		turnStart = RunTurnStart(gs, roller)
+	}
+	gs.Phase = gs.ActionPhase()
+	return turnStart
+}
+
 // RunTurnStart executes the turn start pipeline and returns the delta data.
 func RunTurnStart(gs *GameState, roller *dice.Roller) *ws.TurnStartData {
 	gs.Phase = model.PhaseTurnStart
@@ -684,12 +1343,52 @@ func RunTurnStart(gs *GameState, roller *dice.Roller) *ws.TurnStartData {
 	var sdDamages []ws.SuddenDeathDamage
 	sdDamages, _ = RunSuddenDeathPhase(gs)
 
-	// 3. Passive healing (+2 HP to troops not in combat last turn)
+	// 3-8. Healing, regen, income and troop readiness
+	healed, structRegens, income := runPlayerUpkeep(gs, activePlayerID)
+
+	return &ws.TurnStartData{
+		TurnNumber:         gs.TurnNumber,
+		ActivePlayerID:     activePlayerID,
+		TimerSeconds:       gs.TurnTimer,
+		IncomeGained:       income.IncomeGained,
+		StructureIncome:    income.StructureIncome,
+		TotalCoins:         income.TotalCoins,
+		HealedUnits:        healed,
+		StructureRegens:    structRegens,
+		SuddenDeathDamages: sdDamages,
+	}
+}
+
+// RunSimultaneousTurnStart executes the turn start pipeline for both players at once.
+// Sudden death runs once per round; upkeep and income apply to each player.
+func RunSimultaneousTurnStart(gs *GameState, roller *dice.Roller) *ws.TurnStartData {
+	gs.Phase = model.PhaseTurnStart
+
+	sdDamages, _ := RunSuddenDeathPhase(gs)
+
+	data := &ws.TurnStartData{
+		TurnNumber:         gs.TurnNumber,
+		TimerSeconds:       gs.TurnTimer,
+		SuddenDeathDamages: sdDamages,
+	}
+	for i := 0; i < 2; i++ {
+		healed, structRegens, income := runPlayerUpkeep(gs, gs.Players[i].ID)
+		data.HealedUnits = append(data.HealedUnits, healed...)
+		data.StructureRegens = append(data.StructureRegens, structRegens...)
+		data.Incomes = append(data.Incomes, income)
+	}
+	return data
+}
+
+// runPlayerUpkeep applies one player's turn start effects: healing, structure regen,
+// income, and readying troops.
+func runPlayerUpkeep(gs *GameState, playerID string) ([]ws.HealedUnit, []ws.StructureRegen, ws.PlayerIncome) {
+	// Passive healing (+2 HP to troops not in combat last turn)
 	var healed []ws.HealedUnit
 	for _, troop := range gs.Troops {
-		if troop.OwnerID == activePlayerID && troop.IsAlive() && !troop.WasInCombat {
+		if troop.OwnerID == playerID && troop.IsAlive() && !troop.WasInCombat {
 			before := troop.CurrentHP
-			amount := troop.Heal(HealingRate())
+			amount := troop.Heal(HealingRate(gs))
 			if amount > 0 {
 				healed = append(healed, ws.HealedUnit{
 					UnitID:   troop.ID,
@@ -700,12 +1399,12 @@ func RunTurnStart(gs *GameState, roller *dice.Roller) *ws.TurnStartData {
 		}
 	}
 
-	// 4. Structure passive regen
+	// Structure passive regen
 	var structRegens []ws.StructureRegen
 	for _, structure := range gs.Structures {
-		if structure.IsOwnedBy(activePlayerID) && structure.IsAlive() {
+		if structure.IsOwnedBy(playerID) && structure.IsAlive() {
 			before := structure.CurrentHP
-			amount := structure.Heal(HealingRate())
+			amount := structure.Heal(HealingRate(gs))
 			if amount > 0 {
 				structRegens = append(structRegens, ws.StructureRegen{
 					StructureID: structure.ID,
@@ -716,14 +1415,13 @@ func RunTurnStart(gs *GameState, roller *dice.Roller) *ws.TurnStartData {
 		}
 	}
 
-	// 5-6. Calculate and credit income
-	passive, structIncome, totalIncome := CalculateIncome(gs, activePlayerID)
-	CreditIncome(gs, activePlayerID)
-	_ = passive
+	// Calculate and credit income
+	_, structIncome, totalIncome := CalculateIncome(gs, playerID)
+	CreditIncome(gs, playerID)
 
-	// 7-8. Reset troop action flags and mark purchased troops as ready
+	// Reset troop action flags and mark purchased troops as ready
 	for _, troop := range gs.Troops {
-		if troop.OwnerID == activePlayerID {
+		if troop.OwnerID == playerID {
 			if !troop.IsReady {
 				troop.IsReady = true // troops purchased last turn become ready
 			}
@@ -731,21 +1429,16 @@ func RunTurnStart(gs *GameState, roller *dice.Roller) *ws.TurnStartData {
 		}
 	}
 
-	idx := gs.PlayerIndex(activePlayerID)
+	idx := gs.PlayerIndex(playerID)
 	totalCoins := 0
 	if idx >= 0 {
 		totalCoins = gs.Players[idx].Coins
 	}
 
-	return &ws.TurnStartData{
-		TurnNumber:         gs.TurnNumber,
-		ActivePlayerID:     activePlayerID,
-		TimerSeconds:       gs.TurnTimer,
-		IncomeGained:       totalIncome,
-		StructureIncome:    structIncome,
-		TotalCoins:         totalCoins,
-		HealedUnits:        healed,
-		StructureRegens:    structRegens,
-		SuddenDeathDamages: sdDamages,
+	return healed, structRegens, ws.PlayerIncome{
+		PlayerID:        playerID,
+		IncomeGained:    totalIncome,
+		StructureIncome: structIncome,
+		TotalCoins:      totalCoins,
 	}
 }
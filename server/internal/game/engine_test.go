@@ -0,0 +1,373 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// memStore is an in-memory store.Store for engine tests.
+type memStore struct {
+	mu      sync.Mutex
+	games   map[string][]byte
+	replays map[string][][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		games:   make(map[string][]byte),
+		replays: make(map[string][][]byte),
+	}
+}
+
+func (s *memStore) SaveGameState(_ context.Context, gameID string, data []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.games[gameID] = data
+	return nil
+}
+
+func (s *memStore) LoadGameState(_ context.Context, gameID string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.games[gameID], nil
+}
+
+func (s *memStore) DeleteGameState(_ context.Context, gameID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.games, gameID)
+	return nil
+}
+
+func (s *memStore) AppendReplay(_ context.Context, gameID string, record []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replays[gameID] = append(s.replays[gameID], record)
+	return nil
+}
+
+func (s *memStore) LoadReplay(_ context.Context, gameID string) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replays[gameID], nil
+}
+
+func (s *memStore) ListGameIDs(_ context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.games))
+	for id := range s.games {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *memStore) Ping(_ context.Context) error { return nil }
+
+func (s *memStore) Close() error { return nil }
+
+func TestEngine_RestoreContinuesDiceStream(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMech, hex.NewCoord(1, -1, 0), true).
+		Build()
+	st := newMemStore()
+	e := NewEngine(context.Background(), gs, ws.NewHub(), st)
+
+	// Use up part of the stream before the snapshot
+	res := ExecuteAttack(e.State, e.Roller, "p1", "unit_0_0_0", hex.NewCoord(1, -1, 0))
+	require.True(t, res.Ack)
+	require.NotZero(t, e.Roller.Draws())
+	e.snapshotState()
+
+	data, err := st.LoadGameState(context.Background(), gs.ID)
+	require.NoError(t, err)
+	restoredState, err := DeserializeGameState(data)
+	require.NoError(t, err)
+	assert.Equal(t, e.Roller.Draws(), restoredState.RollCount)
+	restored := NewEngine(context.Background(), restoredState, ws.NewHub(), st)
+
+	// Both engines must produce the same rolls from here on
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, e.Roller.D20(), restored.Roller.D20())
+	}
+}
+
+func TestEngine_BotTakeover(t *testing.T) {
+	gs := NewTestGame().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		Build()
+	gs.BotTakeover = true
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	e.TakeoverTimeout = time.Hour
+	e.StandIn = func(playerID string) BotPlayer { return &buyerBot{id: playerID} }
+
+	e.handleDisconnect("p1")
+	require.NotNil(t, e.absent["p1"].standIn)
+	require.NotNil(t, e.botTimer, "the stand-in plays the turn in progress")
+	require.NotNil(t, e.reconnectTimer)
+
+	e.playBotTurn()
+	assert.Len(t, gs.PlayerTroops("p1"), 1)
+	assert.Equal(t, "p2", gs.ActivePlayerID())
+	assert.NotEqual(t, model.PhaseGameOver, gs.Phase)
+
+	// p2 plays by hand, then p1 comes back before their turn is played for them
+	e.handleEndTurn(PlayerAction{PlayerID: "p2", Type: ws.MsgEndTurn})
+	require.Equal(t, "p1", gs.ActivePlayerID())
+	require.NotNil(t, e.botTimer)
+
+	e.handleReconnect(ReconnectEvent{PlayerID: "p1", Conn: ws.NewConnection(context.Background(), nil, "p1")})
+	assert.Nil(t, e.absent["p1"])
+	assert.Nil(t, e.reconnectTimer)
+	assert.Nil(t, e.botTimer)
+	assert.False(t, gs.Players[0].IsDisconnected)
+	e.playBotTurn()
+	assert.Equal(t, "p1", gs.ActivePlayerID(), "the turn is the player's again")
+}
+
+// targetBot attacks, once per turn, the first enemy troop in unit ID order it is shown,
+// and records the troops it targeted.
+type targetBot struct {
+	id       string
+	attacked int // turn of the last attack
+	targets  []string
+}
+
+func (b *targetBot) PlayerID() string { return b.id }
+
+func (b *targetBot) NextAction(gs *GameState) *BotAction {
+	if b.attacked == gs.TurnNumber {
+		return nil
+	}
+	b.attacked = gs.TurnNumber
+	var enemies []string
+	for id, t := range gs.Troops {
+		if t.OwnerID != b.id {
+			enemies = append(enemies, id)
+		}
+	}
+	if len(enemies) == 0 {
+		return nil
+	}
+	sort.Strings(enemies)
+	b.targets = append(b.targets, enemies[0])
+	return &BotAction{Type: BotActionAttack, UnitID: gs.PlayerTroops(b.id)[0].ID, Target: gs.Troops[enemies[0]].Hex}
+}
+
+func TestEngine_StandInSeesOnlyThroughFog(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		WithTroop("p1", troopSniper, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(1, -1, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(-5, 0, 5), true).
+		Build()
+	gs.BotTakeover = true
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	e.TakeoverTimeout = time.Hour
+	standIn := &targetBot{id: "p1"}
+	e.StandIn = func(playerID string) BotPlayer { return standIn }
+
+	e.handleDisconnect("p1")
+	e.playBotTurn()
+
+	// The troop out of sight comes first in ID order, but the stand-in is not shown it
+	assert.Equal(t, []string{"unit_1_-1_0"}, standIn.targets)
+	assert.Equal(t, "p2", gs.ActivePlayerID())
+}
+
+func TestEngine_DisconnectWithoutTakeover(t *testing.T) {
+	gs := NewTestGame().Build()
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	e.TakeoverTimeout = time.Hour
+	e.StandIn = func(playerID string) BotPlayer { return &buyerBot{id: playerID} }
+
+	e.handleDisconnect("p1")
+	assert.Nil(t, e.absent["p1"].standIn, "the room did not allow takeover")
+	assert.Nil(t, e.botTimer)
+}
+
+func TestEngine_BothPlayersDisconnect(t *testing.T) {
+	gs := NewTestGame().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		Build()
+	gs.BotTakeover = true
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	e.TakeoverTimeout = time.Hour
+	e.StandIn = func(playerID string) BotPlayer { return &buyerBot{id: playerID} }
+
+	e.handleDisconnect("p1")
+	e.handleDisconnect("p2")
+	require.NotNil(t, e.absent["p2"])
+	assert.NotNil(t, e.activeBot(), "p1 keeps their stand-in")
+
+	// Both stand-ins play their turns
+	e.playBotTurn()
+	require.Equal(t, "p2", gs.ActivePlayerID())
+	require.NotNil(t, e.botTimer)
+	e.playBotTurn()
+	assert.Equal(t, "p1", gs.ActivePlayerID())
+
+	// p2 comes back; p1's time is still running out
+	conn := ws.NewConnection(context.Background(), nil, "p2")
+	e.handleReconnect(ReconnectEvent{PlayerID: "p2", Conn: conn})
+	require.NotNil(t, e.reconnectTimer)
+	drain(t, conn)
+
+	e.absent["p1"].deadline = time.Now()
+	e.handleReconnectTimeout()
+	require.Equal(t, model.PhaseGameOver, gs.Phase)
+	var gameOver ws.GameOverData
+	for _, env := range drain(t, conn) {
+		if env.Type == ws.MsgGameOver {
+			require.NoError(t, json.Unmarshal(env.Data, &gameOver))
+		}
+	}
+	assert.Equal(t, "p2", gameOver.WinnerID, "p1 forfeits, not the player who came back")
+}
+
+func TestEngine_MoveTimeLimit(t *testing.T) {
+	gs := NewTestGame().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		Build()
+	gs.Players[1].IsBot = true
+	gs.MoveTimeLimit = 5
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+
+	e.startTurnTimer()
+	assert.Nil(t, e.moveTimer, "people only have the turn timer")
+
+	e.handleEndTurn(PlayerAction{PlayerID: "p1", Type: ws.MsgEndTurn})
+	require.Equal(t, "p2", gs.ActivePlayerID())
+	require.NotNil(t, e.moveTimer, "the bot account's clock runs")
+
+	// Rejected actions do not buy the bot more time
+	clock := e.moveTimer
+	e.handleAction(PlayerAction{PlayerID: "p2", Seq: 1, Type: ws.MsgMove, Data: []byte(`{"unit_id":"nope"}`)})
+	e.handleAction(PlayerAction{PlayerID: "p2", Seq: 2, Type: ws.MsgAttack, Data: []byte(`not json`)})
+	assert.Same(t, clock, e.moveTimer)
+
+	e.handleMoveTimeout()
+	assert.Equal(t, "p1", gs.ActivePlayerID(), "a slow bot loses the rest of its turn")
+	assert.Nil(t, e.moveTimer)
+}
+
+// drain returns the messages queued on the connection.
+func drain(t *testing.T, conn *ws.Connection) []ws.Envelope {
+	t.Helper()
+	var envs []ws.Envelope
+	for {
+		select {
+		case msg := <-conn.SendChan:
+			var env ws.Envelope
+			require.NoError(t, json.Unmarshal(msg, &env))
+			envs = append(envs, env)
+		default:
+			return envs
+		}
+	}
+}
+
+func TestEngine_Resync(t *testing.T) {
+	gs := NewTestGame().Build()
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	conn := ws.NewConnection(context.Background(), nil, "p1")
+	e.Hub.Register(conn)
+
+	e.sendFullState("p1")
+	e.broadcastDelta(ws.MsgTroopMoved, ws.TroopMovedData{UnitID: "a"})
+	e.broadcastDelta(ws.MsgTroopMoved, ws.TroopMovedData{UnitID: "b"})
+	sent := drain(t, conn)
+	require.Len(t, sent, 3)
+	for i, env := range sent {
+		assert.Equal(t, i+1, env.Event)
+	}
+	assert.Equal(t, 2, e.Events.Last("p2"), "players offline still have their events numbered")
+
+	resync := func(since int) []ws.Envelope {
+		data, _ := json.Marshal(ws.ResyncData{Since: since})
+		e.handleAction(PlayerAction{PlayerID: "p1", Seq: 7, Type: ws.MsgResync, Data: data, Conn: conn})
+		return drain(t, conn)
+	}
+
+	// The missed deltas are sent again as they were
+	replayed := resync(1)
+	require.Len(t, replayed, 3)
+	assert.Equal(t, ws.MsgAck, replayed[0].Type)
+	assert.Equal(t, sent[1:], replayed[1:])
+
+	// A gap reaching back over a full state gets a new one
+	replayed = resync(0)
+	require.Len(t, replayed, 2)
+	assert.Equal(t, ws.MsgGameState, replayed[1].Type)
+	assert.Equal(t, 4, replayed[1].Event)
+}
+
+func TestEngine_RetriedActionIsAnsweredAgain(t *testing.T) {
+	gs := NewTestGame().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		WithCoins("p1", 100).
+		Build()
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	conn := ws.NewConnection(context.Background(), nil, "p1")
+	hq := gs.PlayerHQ("p1").ID
+
+	send := func(seq int, msgType string, data interface{}) []ws.Envelope {
+		raw, _ := json.Marshal(data)
+		e.handleAction(PlayerAction{PlayerID: "p1", Seq: seq, Type: msgType, Data: raw, Conn: conn})
+		return drain(t, conn)
+	}
+	buy := ws.BuyData{UnitType: troopMarine, StructureID: hq}
+
+	require.Equal(t, ws.MsgAck, send(5, ws.MsgBuy, buy)[0].Type)
+	coins := gs.Players[0].Coins
+	require.Less(t, coins, 100)
+
+	// The retry is acked again without buying twice
+	replies := send(5, ws.MsgBuy, buy)
+	require.Len(t, replies, 1)
+	assert.Equal(t, ws.MsgAck, replies[0].Type)
+	assert.Equal(t, coins, gs.Players[0].Coins)
+	assert.Len(t, gs.PlayerTroops("p1"), 1)
+
+	// A rejected action is rejected again the same way
+	nacked := send(6, ws.MsgMove, ws.MoveData{UnitID: "nope"})
+	require.Equal(t, ws.MsgNack, nacked[0].Type)
+	assert.Equal(t, nacked, send(6, ws.MsgMove, ws.MoveData{UnitID: "nope"}))
+
+	// Emotes are acked too, and a retried one is not shown twice
+	opponent := ws.NewConnection(context.Background(), nil, "p2")
+	e.Hub.Register(opponent)
+	emote := ws.EmoteData{EmoteID: "gg"}
+	assert.Equal(t, ws.MsgAck, send(7, ws.MsgEmote, emote)[0].Type)
+	assert.Equal(t, ws.MsgAck, send(7, ws.MsgEmote, emote)[0].Type)
+	assert.Len(t, drain(t, opponent), 1)
+
+	// Older sequence numbers are out of order
+	replies = send(4, ws.MsgBuy, buy)
+	require.Len(t, replies, 1)
+	var nack ws.NackData
+	require.NoError(t, json.Unmarshal(replies[0].Data, &nack))
+	assert.Equal(t, model.ErrSeqOutOfOrder, nack.Error.Code)
+	assert.Len(t, gs.PlayerTroops("p1"), 1)
+
+	// A client counting again from 1 starts a new sequence when it joins
+	send(1, ws.MsgJoinGame, ws.JoinGameData{})
+	assert.Equal(t, ws.MsgAck, send(2, ws.MsgEndTurn, nil)[0].Type)
+}
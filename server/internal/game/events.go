@@ -0,0 +1,93 @@
+package game
+
+import (
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// EventLogSize is how many of the latest events a game keeps per player for resync.
+const EventLogSize = 256
+
+// EventLog numbers the events a game sends each player and keeps the latest ones, so a
+// client that missed some can have them sent again. Every player has their own count:
+// under fog of war players are not sent the same deltas, and a client must be able to
+// tell a lost event from one it was never meant to see. Full game states are numbered
+// but not kept; there is always a fresher one to send.
+//
+// An EventLog belongs to its engine's goroutine and is not safe for concurrent use.
+type EventLog struct {
+	size    int
+	streams map[string]*eventStream
+}
+
+// eventStream is one player's events.
+type eventStream struct {
+	last   int      // number of the latest event, 0 before the first
+	events [][]byte // the latest events by number modulo the size, nil for full states
+}
+
+// NewEventLog creates an event log keeping the latest size events per player.
+func NewEventLog(size int) *EventLog {
+	return &EventLog{
+		size:    size,
+		streams: make(map[string]*eventStream),
+	}
+}
+
+// Record numbers the event for the player and returns the encoded message to send.
+func (l *EventLog) Record(playerID, msgType string, data interface{}) ([]byte, error) {
+	return l.record(playerID, msgType, data, true)
+}
+
+// RecordState numbers a full game state for the player and returns the encoded message.
+func (l *EventLog) RecordState(playerID string, state *GameState) ([]byte, error) {
+	return l.record(playerID, ws.MsgGameState, state, false)
+}
+
+func (l *EventLog) record(playerID, msgType string, data interface{}, keep bool) ([]byte, error) {
+	s := l.streams[playerID]
+	if s == nil {
+		s = &eventStream{events: make([][]byte, l.size)}
+		l.streams[playerID] = s
+	}
+
+	msg, err := ws.NewEvent(msgType, s.last+1, data)
+	if err != nil {
+		return nil, err
+	}
+	s.last++
+	if keep {
+		s.events[s.last%l.size] = msg
+	} else {
+		s.events[s.last%l.size] = nil
+	}
+	return msg, nil
+}
+
+// Last returns the number of the latest event sent to the player, 0 if there is none.
+func (l *EventLog) Last(playerID string) int {
+	if s := l.streams[playerID]; s != nil {
+		return s.last
+	}
+	return 0
+}
+
+// Since returns the player's events numbered after since, in order. It returns false
+// when they cannot all be replayed: some are too old to be kept, one is a full state, or
+// since is ahead of the log, as it is for clients of a game restored after a restart.
+// The client then needs a full state instead.
+func (l *EventLog) Since(playerID string, since int) ([][]byte, bool) {
+	last := l.Last(playerID)
+	if since < 0 || since > last || last-since > l.size {
+		return nil, false
+	}
+
+	missed := make([][]byte, 0, last-since)
+	for n := since + 1; n <= last; n++ {
+		msg := l.streams[playerID].events[n%l.size]
+		if msg == nil {
+			return nil, false
+		}
+		missed = append(missed, msg)
+	}
+	return missed, true
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+func eventNumbers(t *testing.T, msgs [][]byte) []int {
+	t.Helper()
+	numbers := make([]int, len(msgs))
+	for i, msg := range msgs {
+		var env ws.Envelope
+		require.NoError(t, json.Unmarshal(msg, &env))
+		numbers[i] = env.Event
+	}
+	return numbers
+}
+
+func TestEventLog(t *testing.T) {
+	l := NewEventLog(4)
+	for i := 0; i < 3; i++ {
+		_, err := l.Record("p1", ws.MsgTroopMoved, ws.TroopMovedData{})
+		require.NoError(t, err)
+	}
+	_, err := l.Record("p2", ws.MsgTroopMoved, ws.TroopMovedData{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, l.Last("p1"))
+	assert.Equal(t, 1, l.Last("p2"), "every player has their own count")
+
+	missed, ok := l.Since("p1", 1)
+	require.True(t, ok)
+	assert.Equal(t, []int{2, 3}, eventNumbers(t, missed))
+	missed, ok = l.Since("p1", 3)
+	assert.True(t, ok)
+	assert.Empty(t, missed)
+	_, ok = l.Since("p1", 4)
+	assert.False(t, ok, "ahead of the log")
+
+	// Only the latest 4 are kept
+	for i := 0; i < 3; i++ {
+		_, err := l.Record("p1", ws.MsgTroopMoved, ws.TroopMovedData{})
+		require.NoError(t, err)
+	}
+	_, ok = l.Since("p1", 1)
+	assert.False(t, ok)
+	missed, ok = l.Since("p1", 2)
+	require.True(t, ok)
+	assert.Equal(t, []int{3, 4, 5, 6}, eventNumbers(t, missed))
+
+	// Full states are numbered but not kept
+	msg, err := l.RecordState("p1", NewTestGame().Build())
+	require.NoError(t, err)
+	assert.Equal(t, []int{7}, eventNumbers(t, [][]byte{msg}))
+	_, ok = l.Since("p1", 6)
+	assert.False(t, ok)
+	_, err = l.Record("p1", ws.MsgTroopMoved, ws.TroopMovedData{})
+	require.NoError(t, err)
+	missed, ok = l.Since("p1", 7)
+	require.True(t, ok)
+	assert.Equal(t, []int{8}, eventNumbers(t, missed))
+}
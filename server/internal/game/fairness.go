@@ -0,0 +1,215 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// CommitSeed picks the game's secret server seed and publishes its commitment.
+// Must be called before the game starts; the dice seed is derived by FinalizeSeed.
+func (gs *GameState) CommitSeed() error {
+	serverSeed, err := dice.NewServerSeed()
+	if err != nil {
+		return err
+	}
+	gs.ServerSeed = serverSeed
+	gs.SeedCommitment = dice.CommitSeed(serverSeed)
+	return nil
+}
+
+// SetClientSeed records the entropy a player contributes to the dice seed.
+// Seeds sent once the game has started are ignored.
+func (gs *GameState) SetClientSeed(playerID, seed string) {
+	idx := gs.PlayerIndex(playerID)
+	if idx < 0 || gs.Phase != model.PhaseWaitingForPlayers {
+		return
+	}
+	gs.ClientSeeds[idx] = seed
+}
+
+// FinalizeSeed derives the dice seed from the committed server seed and the players' seeds.
+// Returns false, leaving Seed unchanged, if no seed was committed.
+func (gs *GameState) FinalizeSeed() bool {
+	if gs.ServerSeed == "" {
+		return false
+	}
+	gs.Seed = dice.DeriveSeed(gs.ServerSeed, gs.ClientSeeds[:]...)
+	gs.RollCount = 0
+	return true
+}
+
+// SeedReveal returns the seeds to disclose at game over, or nil if none was committed.
+func (gs *GameState) SeedReveal() *ws.SeedRevealData {
+	if gs.ServerSeed == "" {
+		return nil
+	}
+	return &ws.SeedRevealData{
+		Commitment:  gs.SeedCommitment,
+		ServerSeed:  gs.ServerSeed,
+		ClientSeeds: gs.ClientSeeds[:],
+		Seed:        gs.Seed,
+	}
+}
+
+// VerifySeedReveal checks revealed seeds against their commitment and against
+// the dice seed the game was played with.
+func VerifySeedReveal(reveal *ws.SeedRevealData, seed int64) error {
+	if !dice.VerifyCommitment(reveal.ServerSeed, reveal.Commitment) {
+		return fmt.Errorf("server seed does not match commitment %s", reveal.Commitment)
+	}
+	if derived := dice.DeriveSeed(reveal.ServerSeed, reveal.ClientSeeds...); derived != reveal.Seed {
+		return fmt.Errorf("revealed seeds derive %d, not the revealed seed %d", derived, reveal.Seed)
+	}
+	if reveal.Seed != seed {
+		return fmt.Errorf("revealed seed %d is not the seed the game was played with (%d)", reveal.Seed, seed)
+	}
+	return nil
+}
+
+// SeedReveal returns the seeds disclosed in the log's game over entry.
+func (l *ActionLog) SeedReveal() (*ws.SeedRevealData, error) {
+	if !l.IsComplete() {
+		return nil, fmt.Errorf("replay: game is not over")
+	}
+	var gameOver ws.GameOverData
+	if err := json.Unmarshal(l.Entries[len(l.Entries)-1].Data, &gameOver); err != nil {
+		return nil, fmt.Errorf("replay: invalid game over entry: %w", err)
+	}
+	if gameOver.Fairness == nil {
+		return nil, fmt.Errorf("replay: game was not played with a committed seed")
+	}
+	return gameOver.Fairness, nil
+}
+
+// RollMessage is a server message carrying dice rolls:
+// a combat_result, structure_attacked or structure_fires.
+type RollMessage struct {
+	Type string
+	Data interface{}
+}
+
+// rollMessages extracts the roll-carrying messages from a delta.
+// Combats resolved in simultaneous mode are unpacked from attacks_resolved.
+func rollMessages(delta interface{}) []RollMessage {
+	switch d := delta.(type) {
+	case *ws.CombatResultData:
+		return []RollMessage{{ws.MsgCombatResult, *d}}
+	case *ws.StructureAttackedData:
+		return []RollMessage{{ws.MsgStructureAttacked, *d}}
+	case *ws.StructureFiresData:
+		return []RollMessage{{ws.MsgStructureFires, *d}}
+	case *ws.AttacksResolvedData:
+		var msgs []RollMessage
+		for _, c := range d.Combats {
+			msgs = append(msgs, RollMessage{ws.MsgCombatResult, c})
+		}
+		for _, a := range d.StructureAttacks {
+			msgs = append(msgs, RollMessage{ws.MsgStructureAttacked, a})
+		}
+		return msgs
+	default:
+		return nil
+	}
+}
+
+// ParseRollMessages extracts the roll-carrying messages from a WebSocket envelope
+// as a client received it. Other message types yield nothing.
+func ParseRollMessages(env ws.Envelope) ([]RollMessage, error) {
+	var delta interface{}
+	switch env.Type {
+	case ws.MsgCombatResult:
+		delta = &ws.CombatResultData{}
+	case ws.MsgStructureAttacked:
+		delta = &ws.StructureAttackedData{}
+	case ws.MsgStructureFires:
+		delta = &ws.StructureFiresData{}
+	case ws.MsgAttacksResolved:
+		delta = &ws.AttacksResolvedData{}
+	default:
+		return nil, nil
+	}
+	if err := json.Unmarshal(env.Data, delta); err != nil {
+		return nil, fmt.Errorf("invalid %s message: %w", env.Type, err)
+	}
+	return rollMessages(delta), nil
+}
+
+// ReplayRolls replays a game's log and returns every roll-carrying message
+// the game produced, in order.
+func ReplayRolls(log *ActionLog) ([]RollMessage, error) {
+	replay, err := NewReplay(log)
+	if err != nil {
+		return nil, err
+	}
+
+	var rolls []RollMessage
+	for {
+		step, err := replay.Next()
+		if errors.Is(err, io.EOF) {
+			return rolls, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, delta := range step.Deltas {
+			rolls = append(rolls, rollMessages(delta)...)
+		}
+	}
+}
+
+// MatchRolls checks that every received roll message was produced by the replay,
+// in the same order. Under fog of war a client sees only some of the game's rolls,
+// and the side it could not see is blanked, so blanked fields are not compared.
+// Returns the index of the first received message that does not match, or -1.
+func MatchRolls(replayed, received []RollMessage) int {
+	next := 0
+	for i, msg := range received {
+		for next < len(replayed) && !rollMatches(replayed[next], msg) {
+			next++
+		}
+		if next == len(replayed) {
+			return i
+		}
+		next++
+	}
+	return -1
+}
+
+// rollMatches compares a replayed roll message with a received one, after blanking
+// in the replayed copy whatever fog of war blanked in the received one.
+func rollMatches(replayed, received RollMessage) bool {
+	if replayed.Type != received.Type {
+		return false
+	}
+	switch want := replayed.Data.(type) {
+	case ws.CombatResultData:
+		got := received.Data.(ws.CombatResultData)
+		if got.AttackerID == "" {
+			want.AttackerID = ""
+			want.AttackerHP = 0
+			want.AttackerKilled = false
+		}
+		if got.DefenderID == "" {
+			want.DefenderID = ""
+			want.DefenderHP = 0
+			want.Killed = false
+		}
+		return want == got
+	case ws.StructureAttackedData:
+		got := received.Data.(ws.StructureAttackedData)
+		if got.AttackerID == "" {
+			want.AttackerID = ""
+		}
+		return want == got
+	case ws.StructureFiresData:
+		return want == received.Data.(ws.StructureFiresData)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,166 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+func newCommittedGame(t *testing.T) *GameState {
+	gs := NewTestGame().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		WithStructure(model.StructureOutpost, "", hex.NewCoord(2, -1, -1)).
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(1, -1, 0), true).
+		Build()
+	gs.Phase = model.PhaseWaitingForPlayers
+	require.NoError(t, gs.CommitSeed())
+	return gs
+}
+
+func TestFairness_SeedHiddenUntilGameOver(t *testing.T) {
+	gs := newCommittedGame(t)
+	gs.SetClientSeed("p1", "p1-entropy")
+	require.True(t, gs.FinalizeSeed())
+
+	// Clients see only the commitment
+	data, err := json.Marshal(gs)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), gs.SeedCommitment)
+	assert.NotContains(t, string(data), gs.ServerSeed)
+	assert.NotContains(t, string(data), "p1-entropy")
+
+	// Snapshots keep the secrets
+	data, err = gs.Serialize()
+	require.NoError(t, err)
+	restored, err := DeserializeGameState(data)
+	require.NoError(t, err)
+	assert.Equal(t, gs.Seed, restored.Seed)
+	assert.Equal(t, gs.ServerSeed, restored.ServerSeed)
+	assert.Equal(t, gs.ClientSeeds, restored.ClientSeeds)
+}
+
+func TestFairness_ClientSeedsChangeDiceSeed(t *testing.T) {
+	gs := newCommittedGame(t)
+	gs.FinalizeSeed()
+	without := gs.Seed
+
+	gs.SetClientSeed("p2", "p2-entropy")
+	gs.FinalizeSeed()
+	assert.NotEqual(t, without, gs.Seed)
+	assert.Equal(t, dice.DeriveSeed(gs.ServerSeed, "", "p2-entropy"), gs.Seed)
+
+	// Too late once the game is underway
+	gs.Phase = model.PhasePlayerAction
+	gs.SetClientSeed("p1", "late")
+	assert.Empty(t, gs.ClientSeeds[0])
+}
+
+func TestFairness_RevealVerifiesRolls(t *testing.T) {
+	gs := newCommittedGame(t)
+	gs.SetClientSeed("p1", "p1-entropy")
+	gs.SetClientSeed("p2", "p2-entropy")
+
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	t.Cleanup(func() {
+		if e.turnTimer != nil {
+			e.turnTimer.Stop()
+		}
+	})
+	e.startGame()
+	submit(e, "p1", ws.MsgAttack, ws.AttackData{UnitID: "unit_0_0_0", TargetQ: 1, TargetR: -1, TargetS: 0})
+	submit(e, "p1", ws.MsgEndTurn, nil)
+	submit(e, "p2", ws.MsgEndTurn, nil)
+	e.absent["p2"] = &absence{}
+	e.handleReconnectTimeout()
+	require.True(t, e.Log.IsComplete())
+
+	reveal, err := e.Log.SeedReveal()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1-entropy", "p2-entropy"}, reveal.ClientSeeds)
+	require.NoError(t, VerifySeedReveal(reveal, e.Log.Seed))
+
+	rolls, err := ReplayRolls(e.Log)
+	require.NoError(t, err)
+	require.NotEmpty(t, rolls)
+	assert.Equal(t, ws.MsgCombatResult, rolls[0].Type)
+
+	// A client that saw only some rolls still matches
+	assert.Equal(t, -1, MatchRolls(rolls, rolls[1:]))
+
+	// A roll the replay did not produce is caught
+	combat := rolls[0].Data.(ws.CombatResultData)
+	combat.NaturalRoll = combat.NaturalRoll%20 + 1
+	tampered := []RollMessage{{ws.MsgCombatResult, combat}}
+	assert.Equal(t, 0, MatchRolls(rolls, tampered))
+
+	// A forged server seed fails the commitment
+	forged := *reveal
+	forged.ServerSeed = "00"
+	assert.Error(t, VerifySeedReveal(&forged, e.Log.Seed))
+}
+
+func TestEngine_ClientSeedsFollowTheCommitment(t *testing.T) {
+	gs := newCommittedGame(t)
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	e.SeedWindow = time.Minute
+	t.Cleanup(func() {
+		if e.turnTimer != nil {
+			e.turnTimer.Stop()
+		}
+	})
+
+	conns := map[string]*ws.Connection{}
+	send := func(playerID string, seq int, msgType string, data interface{}) []ws.Envelope {
+		t.Helper()
+		raw, err := json.Marshal(data)
+		require.NoError(t, err)
+		e.handleAction(PlayerAction{PlayerID: playerID, Seq: seq, Type: msgType, Data: raw, Conn: conns[playerID]})
+		return drain(t, conns[playerID])
+	}
+	join := func(playerID string) []ws.Envelope {
+		t.Helper()
+		conns[playerID] = ws.NewConnection(context.Background(), nil, playerID)
+		e.Hub.Register(conns[playerID])
+		return send(playerID, 1, ws.MsgJoinGame, ws.JoinGameData{})
+	}
+
+	// Before the commitment was sent, a client seed is rejected
+	conns["p1"] = ws.NewConnection(context.Background(), nil, "p1")
+	replies := send("p1", 1, ws.MsgClientSeed, ws.ClientSeedData{ClientSeed: "early"})
+	require.Equal(t, ws.MsgNack, replies[0].Type)
+	assert.Empty(t, gs.ClientSeeds[0])
+
+	// Joining sends the commitment; the seed is taken after it
+	replies = join("p1")
+	require.Len(t, replies, 2)
+	var state GameState
+	require.NoError(t, json.Unmarshal(replies[1].Data, &state))
+	assert.Equal(t, gs.SeedCommitment, state.SeedCommitment)
+	assert.Equal(t, ws.MsgAck, send("p1", 2, ws.MsgClientSeed, ws.ClientSeedData{ClientSeed: "p1-entropy"})[0].Type)
+
+	// The game waits for the other player's seed
+	join("p2")
+	assert.Equal(t, model.PhaseWaitingForPlayers, gs.Phase)
+	assert.NotNil(t, e.seedTimer)
+
+	assert.Equal(t, ws.MsgAck, send("p2", 2, ws.MsgClientSeed, ws.ClientSeedData{ClientSeed: "p2-entropy"})[0].Type)
+	assert.NotEqual(t, model.PhaseWaitingForPlayers, gs.Phase)
+	assert.Nil(t, e.seedTimer)
+	assert.Equal(t, dice.DeriveSeed(gs.ServerSeed, "p1-entropy", "p2-entropy"), gs.Seed)
+
+	// Too late once the game has started
+	drain(t, conns["p1"])
+	replies = send("p1", 3, ws.MsgClientSeed, ws.ClientSeedData{ClientSeed: "late"})
+	require.Equal(t, ws.MsgNack, replies[0].Type)
+	assert.Equal(t, "p1-entropy", gs.ClientSeeds[0])
+}
@@ -0,0 +1,467 @@
+package game
+
+import (
+	"sort"
+
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// DefaultVision is the sight radius used when a troop or structure has none configured
+// (e.g. states restored from snapshots taken before vision existed).
+const DefaultVision = 2
+
+// playerVision is what one player can see at a point in time.
+type playerVision struct {
+	hexes      map[hex.Coord]bool
+	troops     map[string]bool // own troops plus enemy troops in sight
+	structures map[string]bool // structures in sight
+}
+
+func troopVision(t *model.Troop) int {
+	if t.Vision > 0 {
+		return t.Vision
+	}
+	return DefaultVision
+}
+
+func structureVision(s *model.Structure) int {
+	if s.Vision > 0 {
+		return s.Vision
+	}
+	return DefaultVision
+}
+
+// VisibleHexes returns every hex within sight of the player's living troops and owned structures.
+func (gs *GameState) VisibleHexes(playerID string) map[hex.Coord]bool {
+	visible := make(map[hex.Coord]bool)
+	for _, t := range gs.PlayerTroops(playerID) {
+		for _, h := range gs.Grid.HexesInRange(t.Hex, troopVision(t)) {
+			visible[h] = true
+		}
+	}
+	for _, s := range gs.PlayerStructures(playerID) {
+		for _, h := range gs.Grid.HexesInRange(s.Hex, structureVision(s)) {
+			visible[h] = true
+		}
+	}
+	return visible
+}
+
+// computeVision captures what the player can currently see.
+func (gs *GameState) computeVision(playerID string) *playerVision {
+	v := &playerVision{
+		hexes:      gs.VisibleHexes(playerID),
+		troops:     make(map[string]bool),
+		structures: make(map[string]bool),
+	}
+	for id, t := range gs.Troops {
+		if t.IsAlive() && (t.OwnerID == playerID || v.hexes[t.Hex]) {
+			v.troops[id] = true
+		}
+	}
+	for id, s := range gs.Structures {
+		if v.hexes[s.Hex] {
+			v.structures[id] = true
+		}
+	}
+	return v
+}
+
+// explore adds hexes to the player's terrain memory and returns those seen for the first time.
+func (gs *GameState) explore(idx int, hexes map[hex.Coord]bool) []hex.Coord {
+	if gs.Explored[idx] == nil {
+		gs.Explored[idx] = make(map[hex.Coord]bool)
+	}
+	var fresh []hex.Coord
+	for h := range hexes {
+		if !gs.Explored[idx][h] {
+			gs.Explored[idx][h] = true
+			fresh = append(fresh, h)
+		}
+	}
+	sort.Slice(fresh, func(i, j int) bool {
+		if fresh[i].Q != fresh[j].Q {
+			return fresh[i].Q < fresh[j].Q
+		}
+		return fresh[i].R < fresh[j].R
+	})
+	return fresh
+}
+
+// fogStructure returns a copy of a structure with its owner and HP withheld.
+func fogStructure(s *model.Structure) *model.Structure {
+	c := *s
+	c.OwnerID = ""
+	c.CurrentHP = 0
+	c.Fogged = true
+	return &c
+}
+
+// StateFor returns the game state as the given player is allowed to see it.
+// Without fog of war this is the state itself. Under fog of war it is a copy holding
+// only explored terrain, troops in sight, and structures on explored hexes (fogged when
+// out of sight); the opponent's coins and stats are withheld.
+// Because the client replaces its view wholesale, this also resets the baseline
+// that FilterDeltas diffs against.
+func (gs *GameState) StateFor(playerID string) *GameState {
+	if !gs.FogOfWar {
+		return gs
+	}
+
+	idx := gs.PlayerIndex(playerID)
+	v := gs.computeVision(playerID)
+	if idx >= 0 {
+		gs.explore(idx, v.hexes)
+		gs.vision[idx] = v
+	}
+	return gs.fogView(playerID, v)
+}
+
+// BotViewFor returns the game state as the given player is allowed to see it, for a
+// bot playing their side. Unlike StateFor it leaves the baseline that FilterDeltas
+// diffs against alone, since nothing is sent to the player's client.
+func (gs *GameState) BotViewFor(playerID string) *GameState {
+	if !gs.FogOfWar {
+		return gs
+	}
+	return gs.fogView(playerID, gs.computeVision(playerID))
+}
+
+// fogView builds the fogged copy of the state for a player with the given sight.
+func (gs *GameState) fogView(playerID string, v *playerVision) *GameState {
+	idx := gs.PlayerIndex(playerID)
+	explored := make(map[hex.Coord]bool)
+	if idx >= 0 {
+		for h := range gs.Explored[idx] {
+			explored[h] = true
+		}
+	}
+	for h := range v.hexes {
+		explored[h] = true
+	}
+
+	view := *gs
+	view.Troops = make(map[string]*model.Troop)
+	for id := range v.troops {
+		view.Troops[id] = gs.Troops[id]
+	}
+	view.Structures = make(map[string]*model.Structure)
+	for id, s := range gs.Structures {
+		switch {
+		case v.structures[id]:
+			view.Structures[id] = s
+		case explored[s.Hex]:
+			view.Structures[id] = fogStructure(s)
+		}
+	}
+	view.Terrain = make(map[hex.Coord]model.TerrainType)
+	for h := range explored {
+		if t, ok := gs.Terrain[h]; ok {
+			view.Terrain[h] = t
+		}
+	}
+	for i := range view.Players {
+		if view.Players[i].ID != playerID {
+			view.Players[i].Coins = 0
+			view.Stats[i] = model.GameOverStats{} // its counters tell of kills out of sight
+		}
+	}
+	view.Explored = [2]map[hex.Coord]bool{}
+	if idx >= 0 {
+		view.Explored[idx] = explored
+	}
+	view.vision = [2]*playerVision{}
+	return &view
+}
+
+// FilterDeltas projects a batch of deltas onto what one player is allowed to see.
+// Without fog of war the batch is returned unchanged. Under fog of war deltas about
+// unseen units are dropped or stripped, and a vision_update is appended when the
+// player's sight changed as a result of the batch.
+func (gs *GameState) FilterDeltas(playerID string, deltaTypes []string, deltas []interface{}) ([]string, []interface{}) {
+	if !gs.FogOfWar {
+		return deltaTypes, deltas
+	}
+	idx := gs.PlayerIndex(playerID)
+	if idx < 0 {
+		return nil, nil
+	}
+
+	curr := gs.computeVision(playerID)
+	prev := gs.vision[idx]
+	if prev == nil {
+		prev = curr // restored game: the next full state send sets the real baseline
+	}
+	f := &fogFilter{gs: gs, playerID: playerID, prev: prev, curr: curr}
+
+	var outTypes []string
+	var out []interface{}
+	for i, delta := range deltas {
+		if filtered := f.filter(delta); filtered != nil {
+			outTypes = append(outTypes, deltaTypes[i])
+			out = append(out, filtered)
+		}
+	}
+
+	if update := gs.visionUpdate(idx, prev, curr); update != nil {
+		outTypes = append(outTypes, ws.MsgVisionUpdate)
+		out = append(out, update)
+	}
+	gs.vision[idx] = curr
+	return outTypes, out
+}
+
+// visionUpdate diffs two snapshots of a player's sight. Returns nil if nothing changed.
+func (gs *GameState) visionUpdate(idx int, prev, curr *playerVision) *ws.VisionUpdateData {
+	update := &ws.VisionUpdateData{}
+
+	for _, h := range gs.explore(idx, curr.hexes) {
+		update.Revealed = append(update.Revealed, ws.RevealedHex{
+			Q: h.Q, R: h.R, S: h.S, Terrain: gs.GetTerrainAt(h),
+		})
+	}
+
+	playerID := gs.Players[idx].ID
+	for _, id := range sortedKeys(curr.troops) {
+		// Own new troops were announced by their troop_purchased delta.
+		if !prev.troops[id] && gs.Troops[id].OwnerID != playerID {
+			update.Spotted = append(update.Spotted, *gs.Troops[id])
+		}
+	}
+	for _, id := range sortedKeys(prev.troops) {
+		// Troops that no longer exist were announced by their troop_destroyed delta.
+		if !curr.troops[id] && gs.Troops[id] != nil {
+			update.Hidden = append(update.Hidden, id)
+		}
+	}
+
+	for _, id := range sortedKeys(curr.structures) {
+		if !prev.structures[id] {
+			update.Structures = append(update.Structures, *gs.Structures[id])
+		}
+	}
+	for _, id := range sortedKeys(prev.structures) {
+		if !curr.structures[id] {
+			update.Structures = append(update.Structures, *fogStructure(gs.Structures[id]))
+		}
+	}
+
+	if len(update.Revealed) == 0 && len(update.Spotted) == 0 &&
+		len(update.Hidden) == 0 && len(update.Structures) == 0 {
+		return nil
+	}
+	return update
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fogFilter filters individual deltas for one recipient.
+// A unit counts as seen if it was in sight before the batch or is in sight after it,
+// so the recipient hears about units that die or leave sight during the batch.
+type fogFilter struct {
+	gs       *GameState
+	playerID string
+	prev     *playerVision
+	curr     *playerVision
+}
+
+func (f *fogFilter) seesTroop(id string) bool {
+	return f.prev.troops[id] || f.curr.troops[id]
+}
+
+func (f *fogFilter) seesStructure(id string) bool {
+	return f.prev.structures[id] || f.curr.structures[id]
+}
+
+func (f *fogFilter) ownsTroop(id string) bool {
+	t := f.gs.Troops[id]
+	return t != nil && t.OwnerID == f.playerID
+}
+
+// filter returns the delta as the recipient may see it, or nil to drop it.
+// Deltas that carry no hidden information pass through unchanged.
+func (f *fogFilter) filter(delta interface{}) interface{} {
+	switch d := delta.(type) {
+	case *ws.TroopMovedData:
+		if m := f.move(*d); m != nil {
+			return m
+		}
+		return nil
+	case *ws.CombatResultData:
+		if c := f.combat(*d); c != nil {
+			return c
+		}
+		return nil
+	case *ws.StructureAttackedData:
+		if a := f.structureAttack(*d); a != nil {
+			return a
+		}
+		return nil
+	case *ws.TroopDestroyedData:
+		if f.seesTroop(d.UnitID) {
+			return d
+		}
+		return nil
+	case *ws.StructureFiresData:
+		if f.seesStructure(d.StructureID) || f.seesTroop(d.TargetID) {
+			return d
+		}
+		return nil
+	case *ws.TroopPurchasedData:
+		return f.purchase(d)
+	case *ws.TurnStartData:
+		return f.turnStart(*d)
+	case *ws.MovementResolvedData:
+		return f.movementResolved(d)
+	case *ws.AttacksResolvedData:
+		return f.attacksResolved(d)
+	default:
+		return delta
+	}
+}
+
+// move passes an enemy move only if the troop was already known and is still in sight.
+// Troops entering or leaving sight are reported through vision_update instead.
+func (f *fogFilter) move(d ws.TroopMovedData) *ws.TroopMovedData {
+	if f.ownsTroop(d.UnitID) || (f.prev.troops[d.UnitID] && f.curr.troops[d.UnitID]) {
+		return &d
+	}
+	return nil
+}
+
+// combat passes a combat that involves a seen troop, stripping the side that is not seen.
+func (f *fogFilter) combat(d ws.CombatResultData) *ws.CombatResultData {
+	seesAttacker := f.seesTroop(d.AttackerID)
+	seesDefender := f.seesTroop(d.DefenderID)
+	if !seesAttacker && !seesDefender {
+		return nil
+	}
+	if !seesAttacker {
+		d.AttackerID = ""
+		d.AttackerHP = 0
+		d.AttackerKilled = false
+	}
+	if !seesDefender {
+		d.DefenderID = ""
+		d.DefenderHP = 0
+		d.Killed = false
+	}
+	return &d
+}
+
+func (f *fogFilter) structureAttack(d ws.StructureAttackedData) *ws.StructureAttackedData {
+	seesAttacker := f.seesTroop(d.AttackerID)
+	if !seesAttacker && !f.seesStructure(d.StructureID) {
+		return nil
+	}
+	if !seesAttacker {
+		d.AttackerID = ""
+	}
+	return &d
+}
+
+// purchase passes only the recipient's own purchases. Enemy troops bought in sight
+// are reported through vision_update, which keeps the opponent's coins private.
+func (f *fogFilter) purchase(d *ws.TroopPurchasedData) interface{} {
+	if d.Owner == f.playerID {
+		return d
+	}
+	return nil
+}
+
+// turnStart withholds the opponent's income and upkeep on unseen units.
+func (f *fogFilter) turnStart(d ws.TurnStartData) *ws.TurnStartData {
+	if d.ActivePlayerID != "" && d.ActivePlayerID != f.playerID {
+		d.IncomeGained = 0
+		d.StructureIncome = 0
+		d.TotalCoins = 0
+	}
+
+	healed := []ws.HealedUnit{}
+	for _, h := range d.HealedUnits {
+		if f.seesTroop(h.UnitID) {
+			healed = append(healed, h)
+		}
+	}
+	d.HealedUnits = healed
+
+	regens := []ws.StructureRegen{}
+	for _, r := range d.StructureRegens {
+		if f.seesStructure(r.StructureID) {
+			regens = append(regens, r)
+		}
+	}
+	d.StructureRegens = regens
+
+	storm := []ws.SuddenDeathDamage{}
+	for _, s := range d.SuddenDeathDamages {
+		if f.seesTroop(s.UnitID) {
+			storm = append(storm, s)
+		}
+	}
+	d.SuddenDeathDamages = storm
+
+	var incomes []ws.PlayerIncome
+	for _, inc := range d.Incomes {
+		if inc.PlayerID == f.playerID {
+			incomes = append(incomes, inc)
+		}
+	}
+	d.Incomes = incomes
+	return &d
+}
+
+func (f *fogFilter) movementResolved(d *ws.MovementResolvedData) *ws.MovementResolvedData {
+	out := &ws.MovementResolvedData{Moves: []ws.TroopMovedData{}, Cancelled: f.ownCancelled(d.Cancelled)}
+	for _, m := range d.Moves {
+		if filtered := f.move(m); filtered != nil {
+			out.Moves = append(out.Moves, *filtered)
+		}
+	}
+	return out
+}
+
+func (f *fogFilter) attacksResolved(d *ws.AttacksResolvedData) *ws.AttacksResolvedData {
+	out := &ws.AttacksResolvedData{
+		Combats:          []ws.CombatResultData{},
+		StructureAttacks: []ws.StructureAttackedData{},
+		Destroyed:        []ws.TroopDestroyedData{},
+		Cancelled:        f.ownCancelled(d.Cancelled),
+	}
+	for _, c := range d.Combats {
+		if filtered := f.combat(c); filtered != nil {
+			out.Combats = append(out.Combats, *filtered)
+		}
+	}
+	for _, a := range d.StructureAttacks {
+		if filtered := f.structureAttack(a); filtered != nil {
+			out.StructureAttacks = append(out.StructureAttacks, *filtered)
+		}
+	}
+	for _, td := range d.Destroyed {
+		if f.seesTroop(td.UnitID) {
+			out.Destroyed = append(out.Destroyed, td)
+		}
+	}
+	return out
+}
+
+// ownCancelled keeps only the recipient's cancelled orders; the opponent's plan stays private.
+func (f *fogFilter) ownCancelled(cancelled []ws.CancelledOrder) []ws.CancelledOrder {
+	out := []ws.CancelledOrder{}
+	for _, c := range cancelled {
+		if f.ownsTroop(c.UnitID) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
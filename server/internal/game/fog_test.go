@@ -0,0 +1,142 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+func TestStateFor_NoFogReturnsFullState(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopMarine, hex.NewCoord(-5, 0, 5), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(5, 0, -5), true).
+		Build()
+
+	assert.Same(t, gs, gs.StateFor("p1"))
+}
+
+func TestStateFor_HidesTroopsOutOfSight(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopMarine, hex.NewCoord(-5, 0, 5), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(5, 0, -5), true).
+		WithTroop("p2", troopSniper, hex.NewCoord(-3, 0, 3), true).
+		Build()
+	gs.Stats[0].TroopsKilled = 1
+	gs.Stats[1] = model.GameOverStats{TroopsKilled: 2, TroopsLost: 1, TotalDamageDealt: 9}
+
+	view := gs.StateFor("p1")
+
+	assert.Contains(t, view.Troops, "unit_-5_0_5", "own troops are always visible")
+	assert.Contains(t, view.Troops, "unit_-3_0_3", "enemy within vision radius is visible")
+	assert.NotContains(t, view.Troops, "unit_5_0_-5", "enemy outside vision radius is hidden")
+	assert.Equal(t, 0, view.Players[1].Coins, "opponent coins are withheld")
+	assert.Equal(t, 1000, view.Players[0].Coins)
+	assert.Equal(t, model.GameOverStats{}, view.Stats[1], "opponent stats are withheld")
+	assert.Equal(t, 1, view.Stats[0].TroopsKilled)
+	assert.Equal(t, 2, gs.Stats[1].TroopsKilled, "projection does not modify the real stats")
+	assert.Nil(t, view.Explored[1], "opponent terrain memory is withheld")
+	assert.Len(t, gs.Troops, 3, "projection does not modify the real state")
+}
+
+func TestStateFor_FogsRememberedStructures(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithStructure(model.StructureOutpost, "p2", hex.NewCoord(2, -2, 0)).
+		WithStructure(model.StructureOutpost, "p2", hex.NewCoord(6, -6, 0)).
+		Build()
+
+	gs.StateFor("p1")
+	gs.GetTroop("unit_0_0_0").Hex = hex.NewCoord(-4, 0, 4)
+	view := gs.StateFor("p1")
+
+	remembered := view.Structures["struct_2_-2_0"]
+	require.NotNil(t, remembered, "explored structure stays on the map")
+	assert.True(t, remembered.Fogged)
+	assert.Empty(t, remembered.OwnerID)
+	assert.Equal(t, "p2", gs.GetStructure("struct_2_-2_0").OwnerID, "real structure is untouched")
+	assert.NotContains(t, view.Structures, "struct_6_-6_0", "never-seen structure is omitted")
+	assert.True(t, view.Explored[0][hex.NewCoord(1, -1, 0)], "terrain memory persists out of sight")
+}
+
+func TestFilterDeltas_UnseenEnemyMoveIsDropped(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopMarine, hex.NewCoord(-5, 0, 5), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(5, 0, -5), true).
+		WithActivePlayer("p2").
+		Build()
+	gs.StateFor("p1")
+
+	result := ExecuteMove(gs, "p2", "unit_5_0_-5", hex.NewCoord(4, 0, -4))
+	require.True(t, result.Ack)
+
+	types, deltas := gs.FilterDeltas("p1", result.DeltaTypes, result.Deltas)
+	assert.Empty(t, types)
+	assert.Empty(t, deltas)
+}
+
+func TestFilterDeltas_EnemyEnteringSightIsSpotted(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(4, -4, 0), true).
+		WithActivePlayer("p2").
+		Build()
+	gs.StateFor("p1")
+
+	result := ExecuteMove(gs, "p2", "unit_4_-4_0", hex.NewCoord(2, -2, 0))
+	require.True(t, result.Ack)
+
+	types, deltas := gs.FilterDeltas("p1", result.DeltaTypes, result.Deltas)
+	require.Equal(t, []string{ws.MsgVisionUpdate}, types)
+
+	update := deltas[0].(*ws.VisionUpdateData)
+	require.Len(t, update.Spotted, 1)
+	assert.Equal(t, "unit_4_-4_0", update.Spotted[0].ID)
+	assert.Equal(t, hex.NewCoord(2, -2, 0), update.Spotted[0].Hex)
+}
+
+func TestFilterDeltas_OwnMoveRevealsTerrain(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		Build()
+	gs.StateFor("p1")
+
+	result := ExecuteMove(gs, "p1", "unit_0_0_0", hex.NewCoord(1, -1, 0))
+	require.True(t, result.Ack)
+
+	types, deltas := gs.FilterDeltas("p1", result.DeltaTypes, result.Deltas)
+	require.Equal(t, []string{ws.MsgTroopMoved, ws.MsgVisionUpdate}, types)
+
+	update := deltas[1].(*ws.VisionUpdateData)
+	assert.NotEmpty(t, update.Revealed)
+	assert.Empty(t, update.Hidden)
+}
+
+func TestFilterDeltas_HiddenAttackerIsStripped(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopSniper, hex.NewCoord(-1, 0, 1), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(2, -2, 0), true).
+		Build()
+	gs.StateFor("p2")
+
+	// The sniper fires from range 3, outside the marine's vision radius of 2.
+	delta := &ws.CombatResultData{AttackerID: "unit_-1_0_1", DefenderID: "unit_2_-2_0", AttackerHP: 6, DefenderHP: 3}
+
+	_, deltas := gs.FilterDeltas("p2", []string{ws.MsgCombatResult}, []interface{}{delta})
+	require.NotEmpty(t, deltas)
+
+	combat := deltas[0].(*ws.CombatResultData)
+	assert.Equal(t, "unit_2_-2_0", combat.DefenderID)
+	assert.Equal(t, 3, combat.DefenderHP)
+	assert.Empty(t, combat.AttackerID)
+	assert.Equal(t, 0, combat.AttackerHP)
+}
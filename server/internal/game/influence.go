@@ -0,0 +1,177 @@
+package game
+
+import (
+	"sort"
+
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// InfluenceMap is what one player faces on each hex over the next turn: the enemy
+// troops and structures that can hit a unit standing there, and the player's own
+// troops and structures that can hit an enemy there. Bots use it to keep their troops
+// out of fire. It is a snapshot: build a new one once the state changes.
+type InfluenceMap struct {
+	PlayerID string
+
+	threats map[hex.Coord][]influenceSource
+	support map[hex.Coord][]influenceSource
+}
+
+// influenceSource is a troop or a structure that can strike a hex next turn.
+type influenceSource struct {
+	troop     *model.Troop
+	structure *model.Structure
+}
+
+func (s influenceSource) id() string {
+	if s.troop != nil {
+		return s.troop.ID
+	}
+	return s.structure.ID
+}
+
+// damageTo returns the source's expected damage on the target, as it stands now.
+func (s influenceSource) damageTo(gs *GameState, target *model.Troop) float64 {
+	if s.troop != nil {
+		return ExpectedDamage(gs, s.troop, target)
+	}
+	return ExpectedStructureFire(gs, s.structure, target)
+}
+
+// NewInfluenceMap builds the player's influence map. A troop covers every hex in range
+// of where it could move with its full mobility; structures cover the hexes in their
+// range. Neutral structures fire at everyone, so they count for both threat and support.
+// A structure only fires at the closest troop: its threat is an upper bound.
+func NewInfluenceMap(gs *GameState, playerID string) *InfluenceMap {
+	m := &InfluenceMap{
+		PlayerID: playerID,
+		threats:  make(map[hex.Coord][]influenceSource),
+		support:  make(map[hex.Coord][]influenceSource),
+	}
+
+	for _, troop := range sortedTroops(gs) {
+		if !troop.IsAlive() {
+			continue
+		}
+		cover := m.threats
+		if troop.OwnerID == playerID {
+			cover = m.support
+		}
+		for h := range nextTurnReach(gs, troop) {
+			cover[h] = append(cover[h], influenceSource{troop: troop})
+		}
+	}
+
+	structures := gs.AllStructures()
+	sort.Slice(structures, func(i, j int) bool { return structures[i].ID < structures[j].ID })
+	for _, structure := range structures {
+		source := influenceSource{structure: structure}
+		for _, h := range gs.Grid.HexesInRange(structure.Hex, structure.Range) {
+			if !structure.IsOwnedBy(playerID) {
+				m.threats[h] = append(m.threats[h], source)
+			}
+			if structure.IsOwnedBy(playerID) || structure.IsNeutral() {
+				m.support[h] = append(m.support[h], source)
+			}
+		}
+	}
+	return m
+}
+
+// nextTurnReach returns the hexes the troop can attack next turn, from its hex or
+// anywhere it can move to with full mobility.
+func nextTurnReach(gs *GameState, troop *model.Troop) map[hex.Coord]bool {
+	mover := *troop
+	mover.RemainingMobility = mover.Mobility
+
+	origins := []hex.Coord{troop.Hex}
+	for h := range ReachableHexes(gs, &mover) {
+		origins = append(origins, h)
+	}
+
+	reach := make(map[hex.Coord]bool)
+	for _, origin := range origins {
+		for _, h := range gs.Grid.HexesInRange(origin, troop.Range) {
+			if h != origin {
+				reach[h] = true
+			}
+		}
+	}
+	return reach
+}
+
+// ThreatTo returns the damage the troop can expect to take next turn standing on h,
+// if every enemy that can reach the hex attacks it.
+func (m *InfluenceMap) ThreatTo(gs *GameState, troop *model.Troop, h hex.Coord) float64 {
+	probe := *troop
+	probe.Hex = h
+	return m.sum(gs, m.threats[h], &probe)
+}
+
+// SupportAt returns the damage the player's troops and structures can expect to deal
+// next turn to an enemy on h with no defense of its own.
+func (m *InfluenceMap) SupportAt(gs *GameState, h hex.Coord) float64 {
+	return m.sum(gs, m.support[h], &model.Troop{Hex: h})
+}
+
+// SupportFor is SupportAt without the troop's own firepower: the backing the troop
+// would have from the rest of its side, standing on h.
+func (m *InfluenceMap) SupportFor(gs *GameState, troop *model.Troop, h hex.Coord) float64 {
+	var others []influenceSource
+	for _, s := range m.support[h] {
+		if s.troop == nil || s.troop.ID != troop.ID {
+			others = append(others, s)
+		}
+	}
+	return m.sum(gs, others, &model.Troop{Hex: h})
+}
+
+func (m *InfluenceMap) sum(gs *GameState, sources []influenceSource, target *model.Troop) float64 {
+	total := 0.0
+	for _, s := range sources {
+		total += s.damageTo(gs, target)
+	}
+	return total
+}
+
+// HexInfluence is one hex of an influence map, as dumped for inspection.
+type HexInfluence struct {
+	Hex hex.Coord `json:"hex"`
+	// Threat and Support are expected damage against a unit with no defense of its own,
+	// so only the terrain's cover counts.
+	Threat    float64  `json:"threat"`
+	Support   float64  `json:"support"`
+	ThreatIDs []string `json:"threat_ids,omitempty"` // enemy troops and structures covering the hex
+}
+
+// Hexes returns every hex of the map that is threatened or supported, in grid order.
+func (m *InfluenceMap) Hexes(gs *GameState) []HexInfluence {
+	var result []HexInfluence
+	for _, h := range gs.Grid.AllHexes() {
+		threats, support := m.threats[h], m.support[h]
+		if len(threats) == 0 && len(support) == 0 {
+			continue
+		}
+		entry := HexInfluence{
+			Hex:     h,
+			Threat:  m.sum(gs, threats, &model.Troop{Hex: h}),
+			Support: m.SupportAt(gs, h),
+		}
+		for _, s := range threats {
+			entry.ThreatIDs = append(entry.ThreatIDs, s.id())
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// sortedTroops returns the troops in ID order, so the map is built the same way every time.
+func sortedTroops(gs *GameState) []*model.Troop {
+	troops := make([]*model.Troop, 0, len(gs.Troops))
+	for _, t := range gs.Troops {
+		troops = append(troops, t)
+	}
+	sort.Slice(troops, func(i, j int) bool { return troops[i].ID < troops[j].ID })
+	return troops
+}
@@ -0,0 +1,60 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func TestInfluenceMap(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopSniper, hex.NewCoord(-3, 0, 3), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(3, 0, -3), true).
+		WithStructure(model.StructureOutpost, "", hex.NewCoord(0, -5, 5)).
+		WithTerrain(hex.NewCoord(0, 1, -1), model.TerrainForest).
+		Build()
+	sniper := gs.TroopAtHex(hex.NewCoord(-3, 0, 3))
+	m := NewInfluenceMap(gs, "p1")
+
+	// The marine can move 3 hexes and strike the next one
+	assert.Greater(t, m.ThreatTo(gs, sniper, hex.NewCoord(0, 0, 0)), 0.0)
+	assert.Zero(t, m.ThreatTo(gs, sniper, hex.NewCoord(-2, 0, 2)))
+	// Cover makes the same threat less likely to land
+	assert.Less(t, m.ThreatTo(gs, sniper, hex.NewCoord(0, 1, -1)), m.ThreatTo(gs, sniper, hex.NewCoord(1, 0, -1)))
+
+	// The sniper covers hexes up to 5 away, but SupportFor leaves its own firepower out
+	assert.Greater(t, m.SupportAt(gs, hex.NewCoord(2, 0, -2)), 0.0)
+	assert.Zero(t, m.SupportAt(gs, hex.NewCoord(3, 0, -3)))
+	assert.Zero(t, m.SupportFor(gs, sniper, hex.NewCoord(2, 0, -2)))
+
+	// Neutral structures fire at both sides
+	near := hex.NewCoord(0, -4, 4)
+	assert.Greater(t, m.ThreatTo(gs, sniper, near), 0.0)
+	assert.Greater(t, m.SupportAt(gs, near), 0.0)
+	assert.Greater(t, NewInfluenceMap(gs, "p2").ThreatTo(gs, gs.TroopAtHex(hex.NewCoord(3, 0, -3)), near), 0.0)
+}
+
+func TestInfluenceMap_Hexes(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p2", troopMarine, hex.NewCoord(3, 0, -3), true).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		Build()
+	marine := gs.TroopAtHex(hex.NewCoord(3, 0, -3))
+	hq := gs.PlayerHQ("p2")
+
+	hexes := NewInfluenceMap(gs, "p1").Hexes(gs)
+	require.NotEmpty(t, hexes)
+	byHex := make(map[hex.Coord]HexInfluence, len(hexes))
+	for _, h := range hexes {
+		assert.Greater(t, h.Threat, 0.0, "only threatened hexes when p1 has nothing on the map")
+		assert.Zero(t, h.Support)
+		byHex[h.Hex] = h
+	}
+
+	assert.Equal(t, []string{marine.ID}, byHex[hex.NewCoord(0, 0, 0)].ThreatIDs)
+	assert.Equal(t, []string{hq.ID}, byHex[hex.NewCoord(0, -4, 4)].ThreatIDs)
+	assert.NotContains(t, byHex, hex.NewCoord(-3, 0, 3))
+}
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// maxBotActionsPerTurn stops a bot that keeps proposing actions from stalling a match.
+const maxBotActionsPerTurn = 500
+
+// MatchResult is the outcome of a game played headless between two bots.
+type MatchResult struct {
+	GameOver  *ws.GameOverData
+	Turns     int
+	Purchases [2]map[model.TroopType]int // troops bought by each player, by type
+}
+
+// PlayMatch plays a game between two bots to its end without an Engine: no WebSockets,
+// timers or persistence. bots are in player order. Each turn mirrors the Engine's bot
+// turn: the bot acts until it returns nil, then the turn ends and structures fire.
+// A game still running after maxTurns ends in a draw. Only alternating mode is supported.
+func PlayMatch(gs *GameState, roller *dice.Roller, bots [2]BotPlayer, maxTurns int) (*MatchResult, error) {
+	if gs.IsSimultaneous() {
+		return nil, fmt.Errorf("match: simultaneous turn mode is not supported")
+	}
+	for i, b := range bots {
+		if b.PlayerID() != gs.Players[i].ID {
+			return nil, fmt.Errorf("match: bot %d plays as %s, not %s", i, b.PlayerID(), gs.Players[i].ID)
+		}
+	}
+
+	m := &MatchResult{
+		Purchases: [2]map[model.TroopType]int{{}, {}},
+	}
+	finish := func(gameOver *ws.GameOverData, turns int) *MatchResult {
+		gs.Phase = model.PhaseGameOver
+		m.GameOver = gameOver
+		m.Turns = turns
+		return m
+	}
+
+	StartFirstTurn(gs, roller)
+	for {
+		idx := gs.ActivePlayer
+		bot := bots[idx]
+		for i := 0; i < maxBotActionsPerTurn; i++ {
+			if gs.Phase != model.PhasePlayerAction || gs.ActivePlayer != idx {
+				break
+			}
+			action := bot.NextAction(gs)
+			if action == nil {
+				break
+			}
+			result := ExecuteBotAction(gs, roller, bot.PlayerID(), action)
+			if !result.Ack {
+				continue
+			}
+			if action.Type == BotActionBuy {
+				m.Purchases[idx][action.TroopType]++
+			}
+			if result.GameOver != nil {
+				return finish(result.GameOver, gs.TurnNumber), nil
+			}
+		}
+
+		end := ExecuteEndTurn(gs, roller, bot.PlayerID())
+		if !end.Ack {
+			return nil, fmt.Errorf("match: turn %d: end turn rejected: %s", gs.TurnNumber, end.Error.Message)
+		}
+		RunStructureCombat(gs, roller)
+		if end.GameOver != nil {
+			return finish(end.GameOver, gs.TurnNumber), nil
+		}
+		if gs.TurnNumber > maxTurns {
+			// The next turn has already begun; the result reports the turns played
+			gameOver := buildGameOver(gs, "", model.WinReasonDraw)
+			for id, stats := range gameOver.Stats {
+				stats.TurnsPlayed = maxTurns
+				gameOver.Stats[id] = stats
+			}
+			return finish(gameOver, maxTurns), nil
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// buyerBot buys one marine at its HQ every turn it can afford one.
+type buyerBot struct {
+	id     string
+	bought int // turn of the last purchase
+}
+
+func (b *buyerBot) PlayerID() string { return b.id }
+
+func (b *buyerBot) NextAction(gs *GameState) *BotAction {
+	if b.bought == gs.TurnNumber {
+		return nil
+	}
+	b.bought = gs.TurnNumber
+	return &BotAction{Type: BotActionBuy, TroopType: troopMarine, StructureID: gs.PlayerHQ(b.id).ID}
+}
+
+func TestPlayMatch_DrawAtTurnLimit(t *testing.T) {
+	gs := NewTestGame().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		Build()
+	gs.Phase = model.PhaseGameStarted
+
+	result, err := PlayMatch(gs, dice.NewRoller(1), [2]BotPlayer{&buyerBot{id: "p1"}, &buyerBot{id: "p2"}}, 6)
+	require.NoError(t, err)
+
+	assert.Equal(t, model.WinReasonDraw, result.GameOver.Reason)
+	assert.Empty(t, result.GameOver.WinnerID)
+	assert.Equal(t, 6, result.Turns)
+	assert.Equal(t, 6, result.GameOver.Stats["p1"].TurnsPlayed)
+	assert.Equal(t, 7, gs.TurnNumber, "the state is left as it was")
+	assert.Equal(t, model.PhaseGameOver, gs.Phase)
+	assert.Equal(t, 3, result.Purchases[0][troopMarine])
+	assert.Equal(t, 3, result.Purchases[1][troopMarine])
+	assert.Len(t, gs.PlayerTroops("p1"), 3)
+}
+
+func TestPlayMatch_RejectsMismatchedSeats(t *testing.T) {
+	gs := NewTestGame().Build()
+	_, err := PlayMatch(gs, dice.NewRoller(1), [2]BotPlayer{&buyerBot{id: "p2"}, &buyerBot{id: "p1"}}, 6)
+	assert.Error(t, err)
+}
@@ -33,7 +33,7 @@ func ReachableHexes(gs *GameState, troop *model.Troop) map[hex.Coord]int {
 		for _, neighbor := range neighbors {
 			// Skip impassable terrain
 			terrain := gs.GetTerrainAt(neighbor)
-			if !model.IsPassable(terrain) {
+			if !TerrainInfo(gs, terrain).Passable {
 				continue
 			}
 
@@ -42,7 +42,7 @@ func ReachableHexes(gs *GameState, troop *model.Troop) map[hex.Coord]int {
 				continue
 			}
 
-			moveCost := model.MovementCost(terrain)
+			moveCost := TerrainInfo(gs, terrain).MovementCost
 			totalCost := current.cost + moveCost
 
 			// Minimum movement rule: if adjacent to start and have mobility left,
@@ -104,6 +104,137 @@ func CanAttackTarget(troop *model.Troop, targetHex hex.Coord) bool {
 	return dist >= 1 && dist <= troop.Range
 }
 
+// Path is a route planned by PlanPath, over as many turns as it takes.
+type Path struct {
+	Steps []PathStep
+	Cost  int // total movement cost
+	Turns int // turns until the target is entered
+}
+
+// PathStep is one hex entered along a Path.
+type PathStep struct {
+	Hex  hex.Coord
+	Cost int // movement cost from the start, this hex included
+	Turn int // turn in which the hex is entered: 1 is the current turn
+}
+
+// pathView is what the planner knows of the map: all of it for bots,
+// what the player has seen for previews under fog of war.
+type pathView struct {
+	terrain func(hex.Coord) model.TerrainType
+	enemy   func(hex.Coord) bool // an enemy troop blocks the hex
+	canStop func(hex.Coord) bool // a move may end on the hex
+}
+
+func fullView(gs *GameState, playerID string) pathView {
+	return pathView{
+		terrain: gs.GetTerrainAt,
+		enemy:   func(h hex.Coord) bool { return gs.IsHexOccupiedByEnemy(h, playerID) },
+		canStop: func(h hex.Coord) bool { return gs.TroopAtHex(h) == nil && gs.StructureAtHex(h) == nil },
+	}
+}
+
+// PlanPath plans the cheapest route for the troop to the target with A*, across the
+// whole map and over several turns. It follows the rules of ReachableHexes: terrain
+// costs, impassable terrain, enemy troops blocking the way, and moves ending only on
+// free hexes. Each turn's move spends the troop's mobility, starting with what is left
+// this turn. The target may be occupied, so a route can lead to an enemy to attack.
+// Returns nil if the target cannot be reached.
+func PlanPath(gs *GameState, troop *model.Troop, target hex.Coord) *Path {
+	return planPath(gs, troop, target, fullView(gs, troop.OwnerID))
+}
+
+func planPath(gs *GameState, troop *model.Troop, target hex.Coord, view pathView) *Path {
+	start := troop.Hex
+	if target == start || !gs.Grid.Contains(target) || !TerrainInfo(gs, view.terrain(target)).Passable {
+		return nil
+	}
+
+	// Every hex costs at least 1 to enter, so the distance never overestimates
+	cost := map[hex.Coord]int{start: 0}
+	from := make(map[hex.Coord]hex.Coord)
+	frontier := &costQueue{}
+	heap.Push(frontier, costEntry{pos: start, cost: start.Distance(target)})
+
+	for frontier.Len() > 0 {
+		current := heap.Pop(frontier).(costEntry)
+		if current.pos == target {
+			break
+		}
+		g := cost[current.pos]
+		if current.cost > g+current.pos.Distance(target) {
+			continue // stale entry
+		}
+
+		for _, neighbor := range gs.Grid.Neighbors(current.pos) {
+			info := TerrainInfo(gs, view.terrain(neighbor))
+			if !info.Passable || (neighbor != target && view.enemy(neighbor)) {
+				continue
+			}
+			next := g + info.MovementCost
+			if prev, ok := cost[neighbor]; ok && next >= prev {
+				continue
+			}
+			cost[neighbor] = next
+			from[neighbor] = current.pos
+			heap.Push(frontier, costEntry{pos: neighbor, cost: next + neighbor.Distance(target)})
+		}
+	}
+	if _, ok := cost[target]; !ok {
+		return nil
+	}
+
+	var hexes []hex.Coord
+	for h := target; h != start; h = from[h] {
+		hexes = append(hexes, h)
+	}
+	path := &Path{Steps: make([]PathStep, len(hexes)), Cost: cost[target]}
+	for i := range hexes {
+		h := hexes[len(hexes)-1-i]
+		path.Steps[i] = PathStep{Hex: h, Cost: cost[h]}
+	}
+	path.Turns = scheduleTurns(troop, path.Steps, view)
+	return path
+}
+
+// scheduleTurns sets the turn each step is entered in and returns the number of turns.
+// A turn's move spends at most the troop's mobility, though its first hex can always be
+// entered, and ends on a hex the troop may stop on. Where friendly troops leave nowhere
+// to stop, the plan assumes they will have moved on.
+func scheduleTurns(troop *model.Troop, steps []PathStep, view pathView) int {
+	turn, mobility := 1, troop.RemainingMobility
+	if !troop.CanMove() {
+		turn, mobility = 2, troop.Mobility
+	}
+
+	for first := 0; first < len(steps); turn++ {
+		spent, last, stop := 0, first, -1
+		for i := first; i < len(steps); i++ {
+			stepCost := steps[i].Cost
+			if i > 0 {
+				stepCost -= steps[i-1].Cost
+			}
+			if i > first && spent+stepCost > mobility {
+				break
+			}
+			spent += stepCost
+			last = i
+			if i == len(steps)-1 || view.canStop(steps[i].Hex) {
+				stop = i
+			}
+		}
+		if stop >= first {
+			last = stop
+		}
+		for i := first; i <= last; i++ {
+			steps[i].Turn = turn
+		}
+		first = last + 1
+		mobility = troop.Mobility
+	}
+	return turn - 1
+}
+
 // --- Priority queue for Dijkstra ---
 
 type costEntry struct {
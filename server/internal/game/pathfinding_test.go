@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/config"
 	"github.com/teomiscia/hexbattle/internal/hex"
 	"github.com/teomiscia/hexbattle/internal/model"
 )
@@ -12,7 +14,7 @@ func TestReachableHexes(t *testing.T) {
 	// Setup map where Marine is at center, surrounded by plains
 	gs := NewTestGame().
 		WithMapSize(model.MapSizeSmall).
-		WithTroop("p1", model.TroopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
 		Build()
 
 	troop := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
@@ -47,11 +49,11 @@ func TestReachableHexes_TerrainCostsAndBlocking(t *testing.T) {
 	// Enemy Troop blocking at (0, 1, -1)
 	gs := NewTestGame().
 		WithMapSize(model.MapSizeSmall).
-		WithTroop("p1", model.TroopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
 		WithTerrain(hex.NewCoord(1, 0, -1), model.TerrainForest).
 		WithTerrain(hex.NewCoord(-1, 0, 1), model.TerrainMountains).
-		WithTroop("p2", model.TroopMarine, hex.NewCoord(0, 1, -1), true). // enemy block
-		WithTroop("p1", model.TroopSniper, hex.NewCoord(0, -1, 1), true). // friendly block (can't stop, can pass)
+		WithTroop("p2", troopMarine, hex.NewCoord(0, 1, -1), true). // enemy block
+		WithTroop("p1", troopSniper, hex.NewCoord(0, -1, 1), true). // friendly block (can't stop, can pass)
 		Build()
 
 	troop := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
@@ -81,7 +83,7 @@ func TestReachableHexes_MinimumMovement(t *testing.T) {
 	// Forest (Cost 2) at (1, 0, -1)
 	gs := NewTestGame().
 		WithMapSize(model.MapSizeSmall).
-		WithTroop("p1", model.TroopMech, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopMech, hex.NewCoord(0, 0, 0), true).
 		WithTerrain(hex.NewCoord(1, 0, -1), model.TerrainForest).
 		Build()
 
@@ -99,7 +101,7 @@ func TestReachableHexes_MinimumMovement(t *testing.T) {
 
 func TestCanAttackTarget(t *testing.T) {
 	gs := NewTestGame().
-		WithTroop("p1", model.TroopSniper, hex.NewCoord(0, 0, 0), true). // Range 3
+		WithTroop("p1", troopSniper, hex.NewCoord(0, 0, 0), true). // Range 3
 		Build()
 
 	troop := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
@@ -109,3 +111,124 @@ func TestCanAttackTarget(t *testing.T) {
 	assert.False(t, CanAttackTarget(troop, hex.NewCoord(4, 0, -4))) // Dist 4
 	assert.False(t, CanAttackTarget(troop, hex.NewCoord(0, 0, 0)))  // Self (Dist 0) is not attackable
 }
+
+func TestReachableHexes_TerrainFromBalance(t *testing.T) {
+	gs := NewTestGame().
+		WithMapSize(model.MapSizeSmall).
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTerrain(hex.NewCoord(1, 0, -1), "road").
+		WithTerrain(hex.NewCoord(2, 0, -2), "road").
+		WithTerrain(hex.NewCoord(-1, 0, 1), "swamp").
+		WithTerrain(hex.NewCoord(0, 1, -1), "crater").
+		Build()
+
+	// Terrain types added in the balance file, with no code changes
+	terrain := map[string]config.TerrainConfig{
+		"road":   {MovementCost: 1},
+		"swamp":  {MovementCost: 3, DEFModifier: -1},
+		"crater": {Passable: ptr(false)},
+	}
+	for name, tc := range gs.Rules.Terrain {
+		terrain[name] = tc
+	}
+	rules := *gs.Rules
+	rules.Terrain = terrain
+	gs.Rules = &rules
+
+	troop := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	reachable := ReachableHexes(gs, troop)
+
+	assert.Equal(t, 2, reachable[hex.NewCoord(2, 0, -2)], "roads cost 1")
+	assert.Equal(t, 3, reachable[hex.NewCoord(-1, 0, 1)], "swamp costs 3")
+	_, ok := reachable[hex.NewCoord(0, 1, -1)]
+	assert.False(t, ok, "craters are impassable")
+	assert.Equal(t, -1, TerrainInfo(gs, "swamp").DEFModifier)
+}
+
+// assertValidPath checks that the path is a chain of passable, adjacent hexes from the troop
+// to the target, whose costs and turns add up.
+func assertValidPath(t *testing.T, gs *GameState, troop *model.Troop, target hex.Coord, path *Path) {
+	t.Helper()
+	prev, prevCost, prevTurn := troop.Hex, 0, 1
+	for _, step := range path.Steps {
+		assert.Equal(t, 1, prev.Distance(step.Hex), "steps must be adjacent")
+		info := TerrainInfo(gs, gs.GetTerrainAt(step.Hex))
+		assert.True(t, info.Passable)
+		assert.Equal(t, prevCost+info.MovementCost, step.Cost)
+		assert.GreaterOrEqual(t, step.Turn, prevTurn)
+		prev, prevCost, prevTurn = step.Hex, step.Cost, step.Turn
+	}
+	assert.Equal(t, target, prev)
+	assert.Equal(t, prevCost, path.Cost)
+	assert.Equal(t, prevTurn, path.Turns)
+}
+
+func TestPlanPath_AroundWater(t *testing.T) {
+	// A lake along q = 1, passable only from r = 3 down
+	b := NewTestGame().WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true)
+	for r := -7; r <= 2; r++ {
+		b.WithTerrain(hex.NewCoord(1, r, -1-r), model.TerrainWater)
+	}
+	gs := b.Build()
+	marine := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	target := hex.NewCoord(3, 0, -3)
+
+	path := PlanPath(gs, marine, target)
+	require.NotNil(t, path)
+	assertValidPath(t, gs, marine, target, path)
+	assert.Equal(t, 7, path.Cost)
+	assert.Equal(t, 3, path.Turns) // mobility 3 a turn
+
+	// The first turn ends where ReachableHexes agrees it can
+	reachable := ReachableHexes(gs, marine)
+	for _, step := range path.Steps {
+		if step.Turn == 1 {
+			assert.Contains(t, reachable, step.Hex)
+		}
+	}
+}
+
+func TestPlanPath_TurnsAndBlocking(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopMarine, hex.NewCoord(3, 0, -3), true).
+		Build()
+	marine := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	target := hex.NewCoord(6, 0, -6)
+
+	// Straight through the friendly troop, but the first turn can't end on it
+	path := PlanPath(gs, marine, target)
+	require.NotNil(t, path)
+	assertValidPath(t, gs, marine, target, path)
+	assert.Equal(t, 6, path.Cost)
+	assert.Equal(t, []int{1, 1, 2, 2, 2, 3}, pathTurns(path))
+
+	// An enemy can't be passed, only attacked at the end of the route
+	gs.AddTroop(&model.Troop{ID: "enemy", OwnerID: "p2", Hex: hex.NewCoord(4, 0, -4), CurrentHP: 1})
+	path = PlanPath(gs, marine, target)
+	require.NotNil(t, path)
+	assertValidPath(t, gs, marine, target, path)
+	assert.Equal(t, 7, path.Cost)
+	path = PlanPath(gs, marine, hex.NewCoord(4, 0, -4))
+	require.NotNil(t, path)
+	assert.Equal(t, 4, path.Cost)
+
+	// A troop that has already moved starts next turn
+	marine.HasMoved = true
+	path = PlanPath(gs, marine, hex.NewCoord(1, 0, -1))
+	require.NotNil(t, path)
+	assert.Equal(t, []int{2}, pathTurns(path))
+
+	// Impassable and off-map targets have no route
+	gs.Terrain[target] = model.TerrainMountains
+	assert.Nil(t, PlanPath(gs, marine, target))
+	assert.Nil(t, PlanPath(gs, marine, hex.NewCoord(20, 0, -20)))
+}
+
+func pathTurns(path *Path) []int {
+	turns := make([]int, len(path.Steps))
+	for i, step := range path.Steps {
+		turns[i] = step.Turn
+	}
+	return turns
+}
@@ -0,0 +1,237 @@
+package game
+
+import (
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// critChance and fumbleChance are the odds of a natural 20 and a natural 1.
+const (
+	critChance   = 1.0 / 20
+	fumbleChance = 1.0 / 20
+)
+
+// PreviewAttack computes the odds of the unit attacking the target hex without rolling
+// anything. The modifiers are the ones ResolveTroopCombat and ResolveStructureAttack use;
+// the ATK penalty of a target moving away in simultaneous mode is not known in advance.
+// Unlike ValidateAttack, units may be previewed outside the player's turn.
+func PreviewAttack(gs *GameState, playerID, unitID string, target hex.Coord) (*ws.AttackPreviewData, model.ErrorCode, string) {
+	switch gs.Phase {
+	case model.PhaseWaitingForPlayers, model.PhaseGeneratingMap, model.PhaseGameOver:
+		return nil, model.ErrInvalidAttack, "game is not in progress"
+	}
+
+	attacker := gs.GetTroop(unitID)
+	if attacker == nil || attacker.OwnerID != playerID {
+		return nil, model.ErrUnitNotFound, "unit not found"
+	}
+	if !CanAttackTarget(attacker, target) {
+		return nil, model.ErrInvalidAttack, "target is out of attack range"
+	}
+	if gs.FogOfWar && !gs.VisibleHexes(playerID)[target] {
+		return nil, model.ErrInvalidAttack, "no target at hex"
+	}
+
+	dn, err := TroopDamageDice(attacker)
+	if err != nil {
+		return nil, model.ErrInvalidAttack, "unit has no damage dice"
+	}
+
+	if defender := gs.TroopAtHex(target); defender != nil {
+		if defender.OwnerID == playerID {
+			return nil, model.ErrInvalidAttack, "cannot attack your own unit"
+		}
+		return previewTroopCombat(gs, attacker, defender, dn), "", ""
+	}
+	if structure := gs.StructureAtHex(target); structure != nil {
+		if structure.OwnerID == playerID {
+			return nil, model.ErrInvalidAttack, "cannot attack your own structure"
+		}
+		return previewStructureAttack(gs, attacker, structure, dn), "", ""
+	}
+	return nil, model.ErrInvalidAttack, "no target at hex"
+}
+
+// PreviewPath plans the unit's route to the target hex with the same planner bots use.
+// Under fog of war the route only uses what the player knows: enemy troops out of sight
+// don't block it, and unexplored hexes are taken to be plains.
+func PreviewPath(gs *GameState, playerID, unitID string, target hex.Coord) (*ws.PathPreviewData, model.ErrorCode, string) {
+	switch gs.Phase {
+	case model.PhaseWaitingForPlayers, model.PhaseGeneratingMap, model.PhaseGameOver:
+		return nil, model.ErrInvalidMove, "game is not in progress"
+	}
+
+	troop := gs.GetTroop(unitID)
+	if troop == nil || troop.OwnerID != playerID {
+		return nil, model.ErrUnitNotFound, "unit not found"
+	}
+
+	view := fullView(gs, playerID)
+	if gs.FogOfWar {
+		visible := gs.VisibleHexes(playerID)
+		explored := gs.Explored[gs.PlayerIndex(playerID)]
+		view.terrain = func(h hex.Coord) model.TerrainType {
+			if visible[h] || explored[h] {
+				return gs.GetTerrainAt(h)
+			}
+			return model.TerrainPlains
+		}
+		view.enemy = func(h hex.Coord) bool {
+			return visible[h] && gs.IsHexOccupiedByEnemy(h, playerID)
+		}
+		view.canStop = func(h hex.Coord) bool {
+			if (visible[h] || explored[h]) && gs.StructureAtHex(h) != nil {
+				return false
+			}
+			t := gs.TroopAtHex(h)
+			return t == nil || (t.OwnerID != playerID && !visible[h])
+		}
+	}
+
+	path := planPath(gs, troop, target, view)
+	if path == nil {
+		return nil, model.ErrInvalidMove, "no path to hex"
+	}
+
+	preview := &ws.PathPreviewData{
+		UnitID: unitID,
+		Steps:  make([]ws.PathStepData, len(path.Steps)),
+		Cost:   path.Cost,
+		Turns:  path.Turns,
+	}
+	for i, step := range path.Steps {
+		preview.Steps[i] = ws.PathStepData{Q: step.Hex.Q, R: step.Hex.R, S: step.Hex.S, Cost: step.Cost, Turn: step.Turn}
+	}
+	return preview, "", ""
+}
+
+// previewTroopCombat mirrors resolveTroopCombat.
+func previewTroopCombat(gs *GameState, attacker, defender *model.Troop, dn dice.DiceNotation) *ws.AttackPreviewData {
+	p := previewAttackRoll(troopATK(gs, attacker), troopDEF(gs, defender), dn, 1, defender.CurrentHP)
+	p.UnitID = attacker.ID
+	p.TargetID = defender.ID
+	p.TargetKind = "troop"
+
+	counterDice, err := TroopDamageDice(defender)
+	if err != nil {
+		return p
+	}
+
+	// The defender strikes back if it survives a melee exchange, or whenever the attacker fumbles
+	p.CounterPossible = true
+	if meleeExchange(attacker, defender) {
+		p.CounterChance = 1 - p.KillChance
+	} else {
+		p.CounterOnFumbleOnly = true
+		p.CounterChance = fumbleChance
+	}
+
+	p.CounterThreshold, p.CounterHitChance = hitOdds(troopATK(gs, defender), troopDEF(gs, attacker))
+	p.CounterDamageMin = halfDamage(counterDice.Min())
+	p.CounterDamageMax = halfDamage(counterDice.Max())
+
+	// Half damage, rounded down with a minimum of 1, kills if the full roll reaches 2x the HP
+	killRoll := 2 * attacker.CurrentHP
+	if attacker.CurrentHP <= 1 {
+		killRoll = counterDice.Min()
+	}
+	p.CounterKillChance = p.CounterChance * p.CounterHitChance * counterDice.ChanceAtLeast(killRoll)
+	return p
+}
+
+// previewStructureAttack mirrors ResolveStructureAttack. Structures never strike back.
+func previewStructureAttack(gs *GameState, attacker *model.Troop, structure *model.Structure, dn dice.DiceNotation) *ws.AttackPreviewData {
+	multiplier := AntiStructureMultiplier(gs, attacker.Type)
+	p := previewAttackRoll(troopATK(gs, attacker), structure.DEF, dn, multiplier, structure.CurrentHP)
+	p.UnitID = attacker.ID
+	p.TargetID = structure.ID
+	p.TargetKind = "structure"
+	return p
+}
+
+// previewAttackRoll computes the odds of one attack roll: damage is the dice roll times
+// multiplier, doubled on a crit, and kills once it reaches hp.
+func previewAttackRoll(atkModifier, targetDEF int, dn dice.DiceNotation, multiplier, hp int) *ws.AttackPreviewData {
+	threshold, hitChance := hitOdds(atkModifier, targetDEF)
+	return &ws.AttackPreviewData{
+		ATKModifier:  atkModifier,
+		TargetDEF:    targetDEF,
+		HitThreshold: threshold,
+		HitChance:    hitChance,
+		CritChance:   critChance,
+		FumbleChance: fumbleChance,
+		DamageMin:    dn.Min() * multiplier,
+		DamageMax:    dn.Max() * multiplier,
+		CritMin:      dn.Min() * multiplier * 2,
+		CritMax:      dn.Max() * multiplier * 2,
+		KillChance: (hitChance-critChance)*dn.ChanceAtLeast(ceilDiv(hp, multiplier)) +
+			critChance*dn.ChanceAtLeast(ceilDiv(hp, 2*multiplier)),
+	}
+}
+
+// hitOdds returns the natural d20 roll needed to hit and the chance of rolling it,
+// following rollHits: a natural 20 always hits and a natural 1 always misses.
+func hitOdds(atkModifier, targetDEF int) (int, float64) {
+	threshold := targetDEF - atkModifier
+	if threshold < 2 {
+		threshold = 2
+	}
+	if threshold > 20 {
+		threshold = 20
+	}
+	return threshold, float64(21-threshold) / 20
+}
+
+// halfDamage mirrors dice.Roller.RollHalfDamage for a given roll.
+func halfDamage(roll int) int {
+	if roll/2 < 1 {
+		return 1
+	}
+	return roll / 2
+}
+
+func ceilDiv(a, b int) int {
+	if b <= 0 {
+		return a
+	}
+	return (a + b - 1) / b
+}
+
+// ExpectedDamage returns the average damage of one attack by attacker on defender from
+// where both stand, with misses counted as zero and no counter-attack. Lookahead bots use
+// it to weigh the threat to a troop.
+func ExpectedDamage(gs *GameState, attacker, defender *model.Troop) float64 {
+	dn, err := TroopDamageDice(attacker)
+	if err != nil {
+		return 0
+	}
+	_, hitChance := hitOdds(troopATK(gs, attacker), troopDEF(gs, defender))
+	return expectedRoll(hitChance, dn)
+}
+
+// ExpectedStructureFire is ExpectedDamage for a structure firing at a troop, with the
+// neutral modifiers ResolveStructureFire applies.
+func ExpectedStructureFire(gs *GameState, structure *model.Structure, target *model.Troop) float64 {
+	dn, err := StructureDamageDice(structure)
+	if err != nil {
+		return 0
+	}
+	atkModifier := structure.ATK
+	if b := rules(gs); structure.IsNeutral() && b != nil {
+		atkModifier -= b.NeutralMod.ATKReduction
+		for i := 0; i < b.NeutralMod.DamageStepDown; i++ {
+			dn = dn.StepDown()
+		}
+	}
+	_, hitChance := hitOdds(atkModifier, troopDEF(gs, target))
+	return expectedRoll(hitChance, dn)
+}
+
+// expectedRoll is the average damage of an attack that hits with the given chance,
+// doubling the damage on a natural 20.
+func expectedRoll(hitChance float64, dn dice.DiceNotation) float64 {
+	avg := float64(dn.Min()+dn.Max()) / 2
+	return (hitChance-critChance)*avg + critChance*2*avg
+}
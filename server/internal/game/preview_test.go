@@ -0,0 +1,156 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+func TestPreviewAttack_TroopMatchesSimulation(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(1, 0, -1), true).
+		WithTerrain(hex.NewCoord(1, 0, -1), model.TerrainForest).
+		Build()
+	attacker := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	defender := gs.TroopAtHex(hex.NewCoord(1, 0, -1))
+	defender.CurrentHP = 4
+
+	preview, code, _ := PreviewAttack(gs, "p1", attacker.ID, defender.Hex)
+	require.NotNil(t, preview, code)
+
+	// Marine ATK 3 vs marine DEF 14 + 2 forest: needs a natural 13
+	assert.Equal(t, "troop", preview.TargetKind)
+	assert.Equal(t, 3, preview.ATKModifier)
+	assert.Equal(t, 16, preview.TargetDEF)
+	assert.Equal(t, 13, preview.HitThreshold)
+	assert.InDelta(t, 0.40, preview.HitChance, 1e-9)
+	assert.Equal(t, 2, preview.DamageMin) // 1D6+1
+	assert.Equal(t, 7, preview.DamageMax)
+	assert.Equal(t, 14, preview.CritMax)
+	assert.True(t, preview.CounterPossible)
+	assert.False(t, preview.CounterOnFumbleOnly)
+
+	odds := SimulateTroopCombat(gs, attacker, defender, 40000, 7)
+	assert.InDelta(t, odds.HitChance, preview.HitChance, 0.01)
+	assert.InDelta(t, odds.KillChance, preview.KillChance, 0.01)
+	assert.InDelta(t, odds.CounterChance, preview.CounterChance, 0.01)
+	assert.InDelta(t, odds.AttackerDeathChance, preview.CounterKillChance, 0.01)
+
+	// Previewing changes nothing
+	assert.Equal(t, 4, defender.CurrentHP)
+	assert.False(t, attacker.HasAttacked)
+}
+
+func TestPreviewAttack_Structure(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopMech, hex.NewCoord(0, 0, 0), true).
+		WithStructure(model.StructureOutpost, "", hex.NewCoord(2, 0, -2)).
+		Build()
+	mech := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	outpost := gs.StructureAtHex(hex.NewCoord(2, 0, -2))
+
+	preview, code, _ := PreviewAttack(gs, "p1", mech.ID, outpost.Hex)
+	require.NotNil(t, preview, code)
+	assert.Equal(t, "structure", preview.TargetKind)
+	assert.Equal(t, 8, preview.DamageMin) // (2D6+2) x2 vs structures
+	assert.Equal(t, 28, preview.DamageMax)
+	assert.False(t, preview.CounterPossible)
+
+	odds := SimulateStructureAttack(gs, mech, outpost, 40000, 7)
+	assert.InDelta(t, odds.KillChance, preview.KillChance, 0.01)
+}
+
+func TestPreviewAttack_Rejected(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopMarine, hex.NewCoord(1, 0, -1), true).
+		WithTroop("p2", troopSniper, hex.NewCoord(5, 0, -5), true).
+		Build()
+	marine := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	sniper := gs.TroopAtHex(hex.NewCoord(5, 0, -5))
+
+	_, code, _ := PreviewAttack(gs, "p1", marine.ID, hex.NewCoord(1, 0, -1))
+	assert.Equal(t, model.ErrInvalidAttack, code, "own unit")
+	_, code, _ = PreviewAttack(gs, "p1", marine.ID, sniper.Hex)
+	assert.Equal(t, model.ErrInvalidAttack, code, "out of range")
+	_, code, _ = PreviewAttack(gs, "p1", sniper.ID, marine.Hex)
+	assert.Equal(t, model.ErrUnitNotFound, code, "not the player's unit")
+}
+
+func TestExpectedDamage_MatchesSimulation(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopSniper, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(2, 0, -2), true).
+		WithStructure(model.StructureCommandCenter, "", hex.NewCoord(0, 2, -2)).
+		Build()
+	sniper := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	marine := gs.TroopAtHex(hex.NewCoord(2, 0, -2))
+	marine.CurrentHP = 100 // no kills, so every hit deals its full damage
+
+	odds := SimulateTroopCombat(gs, sniper, marine, 40000, 3)
+	assert.InDelta(t, odds.ExpectedDamage, ExpectedDamage(gs, sniper, marine), 0.05)
+
+	// Neutral command center: ATK 4-2 vs DEF 11 needs a natural 9, 1D6+2 steps down to 1D4+2
+	assert.InDelta(t, (0.6-0.05)*4.5+0.05*9, ExpectedStructureFire(gs, gs.StructureAtHex(hex.NewCoord(0, 2, -2)), sniper), 1e-9)
+}
+
+func TestPreviewPath(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(5, 0, -5), true).
+		WithTerrain(hex.NewCoord(4, 0, -4), model.TerrainWater).
+		Build()
+	marine := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	target := hex.NewCoord(6, 0, -6)
+
+	// Out of sight, the lake and the enemy don't show: the route goes straight
+	preview, code, _ := PreviewPath(gs, "p1", marine.ID, target)
+	require.NotNil(t, preview, code)
+	assert.Equal(t, 6, preview.Cost)
+	assert.Equal(t, 2, preview.Turns)
+	assert.Equal(t, ws.PathStepData{Q: 4, R: 0, S: -4, Cost: 4, Turn: 2}, preview.Steps[3])
+
+	// Seen, both are avoided
+	gs.FogOfWar = false
+	preview, _, _ = PreviewPath(gs, "p1", marine.ID, target)
+	require.NotNil(t, preview)
+	assert.Greater(t, preview.Cost, 6)
+	for _, step := range preview.Steps {
+		assert.NotContains(t, []hex.Coord{hex.NewCoord(4, 0, -4), hex.NewCoord(5, 0, -5)}, hex.NewCoord(step.Q, step.R, step.S))
+	}
+
+	_, code, _ = PreviewPath(gs, "p2", marine.ID, target)
+	assert.Equal(t, model.ErrUnitNotFound, code)
+	_, code, _ = PreviewPath(gs, "p1", marine.ID, hex.NewCoord(20, 0, -20))
+	assert.Equal(t, model.ErrInvalidMove, code)
+}
+
+func TestPreviewPath_StructuresOnlyWhereExplored(t *testing.T) {
+	gs := NewTestGame().
+		WithFogOfWar().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithStructure(model.StructureOutpost, "", hex.NewCoord(3, 0, -3)).
+		Build()
+	marine := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	target := hex.NewCoord(6, 0, -6)
+
+	// Never seen, the outpost does not show: the first turn ends on its hex
+	preview, code, _ := PreviewPath(gs, "p1", marine.ID, target)
+	require.NotNil(t, preview, code)
+	assert.Equal(t, 2, preview.Turns)
+	assert.Equal(t, 1, preview.Steps[2].Turn)
+
+	// Explored, the route stops short of it
+	gs.Explored[0] = map[hex.Coord]bool{hex.NewCoord(3, 0, -3): true}
+	preview, _, _ = PreviewPath(gs, "p1", marine.ID, target)
+	require.NotNil(t, preview)
+	assert.Equal(t, 3, preview.Turns)
+	assert.Equal(t, 2, preview.Steps[2].Turn)
+}
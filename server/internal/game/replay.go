@@ -0,0 +1,364 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// ReplayTTL is how long a game's action log is kept in the store.
+// It outlives the 1h post-game snapshot so finished games can be reviewed.
+const ReplayTTL = 7 * 24 * time.Hour
+
+// Log entry types for events that are not client messages.
+// Accepted client actions are logged under their WebSocket message type (move, attack, ...).
+const (
+	LogTimeout       = "timeout"        // turn timer expired
+	LogStructureFire = "structure_fire" // a structure fired during structure combat
+	LogForfeit       = "forfeit"        // reconnect timeout expired
+	LogGameOver      = "game_over"      // final entry of a finished game
+)
+
+// ErrReplayDiverged is returned when replaying a log does not reproduce the recorded game.
+var ErrReplayDiverged = errors.New("replay: log diverged from simulation")
+
+// LogEntry is one event in a game's action log.
+type LogEntry struct {
+	Turn     int             `json:"turn"`
+	Type     string          `json:"type"`
+	PlayerID string          `json:"player_id,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	At       time.Time       `json:"at"`
+}
+
+// NewLogEntry builds a log entry, marshaling data as the entry payload.
+func NewLogEntry(turn int, entryType, playerID string, data interface{}) (LogEntry, error) {
+	entry := LogEntry{
+		Turn:     turn,
+		Type:     entryType,
+		PlayerID: playerID,
+		At:       time.Now(),
+	}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return LogEntry{}, fmt.Errorf("replay: failed to marshal %s entry: %w", entryType, err)
+		}
+		entry.Data = raw
+	}
+	return entry, nil
+}
+
+// ReplayHeader identifies a game and holds everything needed to replay it
+// besides the entries: the dice seed and the starting position.
+type ReplayHeader struct {
+	GameID  string          `json:"game_id"`
+	Seed    int64           `json:"seed"`
+	Initial json.RawMessage `json:"initial"` // serialized GameState before turn 1
+}
+
+// ActionLog is the append-only record of a game.
+type ActionLog struct {
+	ReplayHeader
+	Entries []LogEntry `json:"entries"`
+}
+
+// NewActionLog opens a log with the game's current state as the starting position.
+func NewActionLog(gs *GameState) (*ActionLog, error) {
+	initial, err := gs.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("replay: failed to serialize initial state: %w", err)
+	}
+	return &ActionLog{
+		ReplayHeader: ReplayHeader{
+			GameID:  gs.ID,
+			Seed:    gs.Seed,
+			Initial: initial,
+		},
+	}, nil
+}
+
+// Append adds an entry to the end of the log.
+func (l *ActionLog) Append(entry LogEntry) {
+	l.Entries = append(l.Entries, entry)
+}
+
+// IsComplete returns true if the log ends with the game being over.
+func (l *ActionLog) IsComplete() bool {
+	return len(l.Entries) > 0 && l.Entries[len(l.Entries)-1].Type == LogGameOver
+}
+
+// ParseActionLog rebuilds a log from its stored records:
+// the header first, then one record per entry.
+func ParseActionLog(records [][]byte) (*ActionLog, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("replay: empty log")
+	}
+
+	var log ActionLog
+	if err := json.Unmarshal(records[0], &log.ReplayHeader); err != nil {
+		return nil, fmt.Errorf("replay: invalid header: %w", err)
+	}
+	for i, rec := range records[1:] {
+		var entry LogEntry
+		if err := json.Unmarshal(rec, &entry); err != nil {
+			return nil, fmt.Errorf("replay: invalid entry %d: %w", i, err)
+		}
+		log.Entries = append(log.Entries, entry)
+	}
+	return &log, nil
+}
+
+// Replay rebuilds a match from its action log, one entry at a time,
+// through the same Execute* functions the engine uses.
+type Replay struct {
+	State  *GameState
+	Roller *dice.Roller
+
+	log     *ActionLog
+	next    int
+	started bool
+	fires   []*ws.StructureFiresData // structure fire produced by the simulation, checked against the log
+}
+
+// ReplayStep is the outcome of applying one log entry.
+// Entry is nil for the opening step, which carries the starting position and first turn start.
+type ReplayStep struct {
+	Entry      *LogEntry
+	DeltaTypes []string
+	Deltas     []interface{}
+}
+
+// NewReplay prepares a replay of the given log from its starting position.
+func NewReplay(log *ActionLog) (*Replay, error) {
+	state, err := DeserializeGameState(log.Initial)
+	if err != nil {
+		return nil, fmt.Errorf("replay: invalid initial state: %w", err)
+	}
+	return &Replay{
+		State:  state,
+		Roller: dice.NewRollerAt(log.Seed, state.RollCount),
+		log:    log,
+	}, nil
+}
+
+// Next applies the next log entry and returns the deltas it produced.
+// Returns io.EOF once every entry has been applied.
+func (r *Replay) Next() (*ReplayStep, error) {
+	if !r.started {
+		r.started = true
+		initial := json.RawMessage(r.log.Initial)
+		turnStart := StartFirstTurn(r.State, r.Roller)
+		return &ReplayStep{
+			DeltaTypes: []string{ws.MsgGameState, ws.MsgTurnStart},
+			Deltas:     []interface{}{initial, turnStart},
+		}, nil
+	}
+
+	if r.next >= len(r.log.Entries) {
+		return nil, io.EOF
+	}
+	entry := &r.log.Entries[r.next]
+	r.next++
+
+	result, err := r.apply(entry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: entry %d (%s, turn %d): %v", ErrReplayDiverged, r.next-1, entry.Type, entry.Turn, err)
+	}
+	return &ReplayStep{
+		Entry:      entry,
+		DeltaTypes: result.DeltaTypes,
+		Deltas:     result.Deltas,
+	}, nil
+}
+
+// Envelopes encodes the step's deltas as WebSocket messages, the same format
+// a live client receives.
+func (s *ReplayStep) Envelopes() ([][]byte, error) {
+	envs := make([][]byte, 0, len(s.Deltas))
+	for i, delta := range s.Deltas {
+		env, err := ws.NewEnvelope(s.DeltaTypes[i], delta)
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// apply replays one entry. The sequences here mirror the Engine's handlers.
+func (r *Replay) apply(entry *LogEntry) (*ActionResult, error) {
+	gs := r.State
+
+	var result *ActionResult
+	switch entry.Type {
+	case ws.MsgMove:
+		var data ws.MoveData
+		if err := json.Unmarshal(entry.Data, &data); err != nil {
+			return nil, err
+		}
+		result = ExecuteMove(gs, entry.PlayerID, data.UnitID, hex.NewCoord(data.TargetQ, data.TargetR, data.TargetS))
+
+	case ws.MsgAttack:
+		var data ws.AttackData
+		if err := json.Unmarshal(entry.Data, &data); err != nil {
+			return nil, err
+		}
+		result = ExecuteAttack(gs, r.Roller, entry.PlayerID, data.UnitID, hex.NewCoord(data.TargetQ, data.TargetR, data.TargetS))
+
+	case ws.MsgBuy:
+		var data ws.BuyData
+		if err := json.Unmarshal(entry.Data, &data); err != nil {
+			return nil, err
+		}
+		result = ExecuteBuy(gs, entry.PlayerID, data.UnitType, data.StructureID)
+
+	case ws.MsgEndTurn:
+		result = r.endTurn(entry.PlayerID)
+
+	case ws.MsgOrderMove:
+		var data ws.MoveData
+		if err := json.Unmarshal(entry.Data, &data); err != nil {
+			return nil, err
+		}
+		result = QueueMoveOrder(gs, entry.PlayerID, data.UnitID, hex.NewCoord(data.TargetQ, data.TargetR, data.TargetS))
+
+	case ws.MsgOrderAttack:
+		var data ws.AttackData
+		if err := json.Unmarshal(entry.Data, &data); err != nil {
+			return nil, err
+		}
+		result = QueueAttackOrder(gs, entry.PlayerID, data.UnitID, hex.NewCoord(data.TargetQ, data.TargetR, data.TargetS))
+
+	case ws.MsgOrderBuy:
+		var data ws.BuyData
+		if err := json.Unmarshal(entry.Data, &data); err != nil {
+			return nil, err
+		}
+		result = QueueBuyOrder(gs, entry.PlayerID, data.UnitType, data.StructureID)
+
+	case ws.MsgCancelOrder:
+		var data ws.CancelOrderData
+		if len(entry.Data) > 0 {
+			if err := json.Unmarshal(entry.Data, &data); err != nil {
+				return nil, err
+			}
+		}
+		result = CancelOrders(gs, entry.PlayerID, data.UnitID)
+
+	case ws.MsgSubmitOrders:
+		result = SubmitOrders(gs, entry.PlayerID)
+		if result.Ack && gs.AllOrdersSubmitted() {
+			result = mergeResults(result, r.resolveSimultaneousTurn())
+		}
+
+	case ws.MsgSurrender:
+		result = ExecuteSurrender(gs, entry.PlayerID)
+
+	case LogTimeout:
+		if gs.IsSimultaneous() {
+			result = r.resolveSimultaneousTurn()
+		} else {
+			result = r.endTurn(gs.ActivePlayerID())
+		}
+
+	case LogStructureFire:
+		if err := r.checkStructureFire(entry); err != nil {
+			return nil, err
+		}
+		result = &ActionResult{Ack: true}
+
+	case LogForfeit:
+		result = &ActionResult{Ack: true, GameOver: CheckDisconnectForfeit(gs, entry.PlayerID)}
+
+	case LogGameOver:
+		// The game over message is replayed as recorded; the outcome was
+		// already reached by the preceding entries.
+		result = &ActionResult{
+			Ack:        true,
+			Deltas:     []interface{}{entry.Data},
+			DeltaTypes: []string{ws.MsgGameOver},
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown entry type %q", entry.Type)
+	}
+
+	if !result.Ack {
+		return nil, fmt.Errorf("action rejected: %s", result.Error.Message)
+	}
+	return result, nil
+}
+
+// endTurn mirrors Engine.handleEndTurn: end the turn, then run structure combat.
+func (r *Replay) endTurn(playerID string) *ActionResult {
+	result := ExecuteEndTurn(r.State, r.Roller, playerID)
+	if !result.Ack {
+		return result
+	}
+	return mergeResults(r.structureCombat(), result)
+}
+
+// resolveSimultaneousTurn mirrors Engine.resolveSimultaneousTurn.
+func (r *Replay) resolveSimultaneousTurn() *ActionResult {
+	result := ResolveSimultaneousTurn(r.State, r.Roller)
+	if result.GameOver != nil {
+		return result
+	}
+	combat := r.structureCombat()
+	next := AdvanceSimultaneousTurn(r.State, r.Roller)
+	return mergeResults(result, combat, next)
+}
+
+// structureCombat runs structure combat and queues each shot for checking
+// against the structure_fire entries that follow in the log.
+func (r *Replay) structureCombat() *ActionResult {
+	result := RunStructureCombat(r.State, r.Roller)
+	for _, delta := range result.Deltas {
+		if fire, ok := delta.(*ws.StructureFiresData); ok {
+			r.fires = append(r.fires, fire)
+		}
+	}
+	return result
+}
+
+// checkStructureFire compares a recorded structure shot with the one the replay produced.
+func (r *Replay) checkStructureFire(entry *LogEntry) error {
+	var recorded ws.StructureFiresData
+	if err := json.Unmarshal(entry.Data, &recorded); err != nil {
+		return err
+	}
+	if len(r.fires) == 0 {
+		return fmt.Errorf("structure %s fired in the log but not in the replay", recorded.StructureID)
+	}
+	simulated := r.fires[0]
+	r.fires = r.fires[1:]
+	if *simulated != recorded {
+		return fmt.Errorf("structure %s fire mismatch", recorded.StructureID)
+	}
+	return nil
+}
+
+// mergeResults concatenates the deltas of several results.
+// The merged result is acknowledged if all are, and ends the game if any does.
+func mergeResults(results ...*ActionResult) *ActionResult {
+	merged := &ActionResult{Ack: true}
+	for _, res := range results {
+		merged.Ack = merged.Ack && res.Ack
+		if merged.Error == nil {
+			merged.Error = res.Error
+		}
+		merged.Deltas = append(merged.Deltas, res.Deltas...)
+		merged.DeltaTypes = append(merged.DeltaTypes, res.DeltaTypes...)
+		if res.GameOver != nil {
+			merged.GameOver = res.GameOver
+		}
+	}
+	return merged
+}
@@ -0,0 +1,171 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// newReplayTestEngine starts an engine (no store, no connections) on a small skirmish:
+// two marines in contact next to a neutral outpost that fires every turn.
+func newReplayTestEngine(t *testing.T, mode model.TurnMode) *Engine {
+	gs := NewTestGame().
+		WithTurnMode(mode).
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		WithStructure(model.StructureOutpost, "", hex.NewCoord(2, -1, -1)).
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(1, -1, 0), true).
+		Build()
+
+	e := NewEngine(context.Background(), gs, ws.NewHub(), nil)
+	t.Cleanup(func() {
+		if e.turnTimer != nil {
+			e.turnTimer.Stop()
+		}
+	})
+	e.startGame()
+	require.NotNil(t, e.Log)
+	return e
+}
+
+func submit(e *Engine, playerID, msgType string, data interface{}) {
+	var raw json.RawMessage
+	if data != nil {
+		raw, _ = json.Marshal(data)
+	}
+	e.handleAction(PlayerAction{PlayerID: playerID, Type: msgType, Data: raw})
+}
+
+// replayAll runs a replay to the end and returns its final state.
+func replayAll(t *testing.T, log *ActionLog) *GameState {
+	replay, err := NewReplay(log)
+	require.NoError(t, err)
+	for {
+		_, err := replay.Next()
+		if errors.Is(err, io.EOF) {
+			return replay.State
+		}
+		require.NoError(t, err)
+	}
+}
+
+func assertSameGame(t *testing.T, want, got *GameState) {
+	assert.Equal(t, want.TurnNumber, got.TurnNumber)
+	assert.Equal(t, want.Phase, got.Phase)
+	assert.Equal(t, want.Players, got.Players)
+	assert.Equal(t, want.Stats, got.Stats)
+	assert.Equal(t, want.Troops, got.Troops)
+	assert.Equal(t, want.Structures, got.Structures)
+}
+
+func TestReplay_AlternatingGameMatchesLiveGame(t *testing.T) {
+	e := newReplayTestEngine(t, model.TurnModeAlternating)
+
+	p2HQ := e.State.PlayerHQ("p2").ID
+	for e.State.TurnNumber <= SurrenderAfterTurn(e.State) {
+		active := e.State.ActivePlayerID()
+		for _, troop := range e.State.PlayerTroops(active) {
+			if enemy := findAdjacentEnemy(e.State, troop); enemy != nil {
+				submit(e, active, ws.MsgAttack, ws.AttackData{UnitID: troop.ID, TargetQ: enemy.Q, TargetR: enemy.R, TargetS: enemy.S})
+			}
+		}
+		if active == "p2" {
+			submit(e, active, ws.MsgBuy, ws.BuyData{UnitType: troopMarine, StructureID: p2HQ})
+			submit(e, active, ws.MsgEndTurn, nil)
+		} else {
+			e.handleTurnTimeout()
+		}
+	}
+	submit(e, "p1", ws.MsgSurrender, nil)
+
+	require.Equal(t, model.PhaseGameOver, e.State.Phase)
+	require.True(t, e.Log.IsComplete())
+
+	assertSameGame(t, e.State, replayAll(t, e.Log))
+}
+
+func TestReplay_SimultaneousGameMatchesLiveGame(t *testing.T) {
+	e := newReplayTestEngine(t, model.TurnModeSimultaneous)
+
+	submit(e, "p1", ws.MsgOrderAttack, ws.AttackData{UnitID: "unit_0_0_0", TargetQ: 1, TargetR: -1, TargetS: 0})
+	submit(e, "p2", ws.MsgOrderMove, ws.MoveData{UnitID: "unit_1_-1_0", TargetQ: 1, TargetR: -2, TargetS: 1})
+	submit(e, "p1", ws.MsgSubmitOrders, nil)
+	submit(e, "p2", ws.MsgSubmitOrders, nil)
+	e.handleTurnTimeout()
+	e.absent["p2"] = &absence{}
+	e.handleReconnectTimeout()
+
+	require.True(t, e.Log.IsComplete())
+	assertSameGame(t, e.State, replayAll(t, e.Log))
+}
+
+func TestReplay_TamperedLogDiverges(t *testing.T) {
+	e := newReplayTestEngine(t, model.TurnModeAlternating)
+	submit(e, "p1", ws.MsgEndTurn, nil)
+	submit(e, "p2", ws.MsgEndTurn, nil)
+
+	var fireIdx = -1
+	for i, entry := range e.Log.Entries {
+		if entry.Type == LogStructureFire {
+			fireIdx = i
+			break
+		}
+	}
+	require.GreaterOrEqual(t, fireIdx, 0, "the neutral outpost should have fired")
+
+	var fire ws.StructureFiresData
+	require.NoError(t, json.Unmarshal(e.Log.Entries[fireIdx].Data, &fire))
+	fire.Damage += 100
+	e.Log.Entries[fireIdx].Data, _ = json.Marshal(fire)
+
+	replay, err := NewReplay(e.Log)
+	require.NoError(t, err)
+	for {
+		_, err = replay.Next()
+		if err != nil {
+			break
+		}
+	}
+	assert.ErrorIs(t, err, ErrReplayDiverged)
+}
+
+func TestParseActionLog_RoundTrip(t *testing.T) {
+	e := newReplayTestEngine(t, model.TurnModeAlternating)
+	submit(e, "p1", ws.MsgEndTurn, nil)
+
+	records := [][]byte{mustMarshal(t, e.Log.ReplayHeader)}
+	for _, entry := range e.Log.Entries {
+		records = append(records, mustMarshal(t, entry))
+	}
+
+	parsed, err := ParseActionLog(records)
+	require.NoError(t, err)
+	assert.Equal(t, e.Log.GameID, parsed.GameID)
+	assert.Equal(t, e.Log.Seed, parsed.Seed)
+	assert.Len(t, parsed.Entries, len(e.Log.Entries))
+	assert.False(t, parsed.IsComplete())
+}
+
+func findAdjacentEnemy(gs *GameState, troop *model.Troop) *hex.Coord {
+	for _, n := range troop.Hex.Neighbors() {
+		if gs.IsHexOccupiedByEnemy(n, troop.OwnerID) {
+			return &n
+		}
+	}
+	return nil
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// ResolveRules returns the ruleset for a room: base with the room's overrides applied.
+// base itself is never modified; maps that are overridden are copied first.
+// Returns base unchanged when the room has no overrides; otherwise the resolved
+// ruleset's version is base's version suffixed with a hash of the overrides.
+func ResolveRules(base *config.BalanceData, settings model.RoomSettings) (*config.BalanceData, error) {
+	o := settings.Rules
+	if o == nil {
+		return base, nil
+	}
+	if base == nil {
+		return nil, fmt.Errorf("rules: no balance data loaded")
+	}
+	if err := validateOverrides(base, o); err != nil {
+		return nil, err
+	}
+
+	resolved := *base
+	if o.StartingCoins != nil {
+		resolved.Economy.StartingCoins = *o.StartingCoins
+	}
+	if o.PassiveIncome != nil {
+		resolved.Economy.PassiveIncome = *o.PassiveIncome
+	}
+	if o.StructureIncome != nil {
+		resolved.Economy.StructureIncome = *o.StructureIncome
+	}
+	if o.DominanceTurns != nil {
+		resolved.WinCond.DominanceTurnsRequired = *o.DominanceTurns
+	}
+	if o.SuddenDeathTurn != nil {
+		thresholds := make(map[string]int, len(base.SuddenDeath.TurnThresholds)+1)
+		for size, turn := range base.SuddenDeath.TurnThresholds {
+			thresholds[size] = turn
+		}
+		thresholds[string(settings.MapSize)] = *o.SuddenDeathTurn
+		resolved.SuddenDeath.TurnThresholds = thresholds
+	}
+	if len(o.AllowedTroops) > 0 {
+		troops := make(map[string]config.TroopConfig, len(o.AllowedTroops))
+		for _, t := range o.AllowedTroops {
+			troops[string(t)] = base.Troops[string(t)]
+		}
+		resolved.Troops = troops
+	}
+	resolved.Version = overridesVersion(base.Version, o)
+	return &resolved, nil
+}
+
+// overridesVersion stamps a ruleset derived from the base version with the given overrides.
+func overridesVersion(base string, o *model.RuleOverrides) string {
+	data, _ := json.Marshal(o)
+	sum := sha256.Sum256(data)
+	return base + "+" + hex.EncodeToString(sum[:])[:8]
+}
+
+// validateOverrides rejects overrides that would make the game unplayable.
+func validateOverrides(base *config.BalanceData, o *model.RuleOverrides) error {
+	if o.StartingCoins != nil && *o.StartingCoins < 0 {
+		return fmt.Errorf("rules: starting coins must not be negative")
+	}
+	if o.PassiveIncome != nil && *o.PassiveIncome < 0 {
+		return fmt.Errorf("rules: passive income must not be negative")
+	}
+	if o.StructureIncome != nil && *o.StructureIncome < 0 {
+		return fmt.Errorf("rules: structure income must not be negative")
+	}
+	if o.DominanceTurns != nil && *o.DominanceTurns < 1 {
+		return fmt.Errorf("rules: dominance turns must be at least 1")
+	}
+	if o.SuddenDeathTurn != nil && *o.SuddenDeathTurn < 1 {
+		return fmt.Errorf("rules: sudden death turn must be at least 1")
+	}
+	for _, t := range o.AllowedTroops {
+		if _, ok := base.Troops[string(t)]; !ok {
+			return fmt.Errorf("rules: unknown troop type %q", t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestNewGameState_RoomRules(t *testing.T) {
+	NewTestGame() // loads the test balance
+
+	settings := model.RoomSettings{
+		MapSize:  model.MapSizeSmall,
+		TurnMode: model.TurnModeAlternating,
+		Rules: &model.RuleOverrides{
+			StartingCoins:   intPtr(300),
+			DominanceTurns:  intPtr(5),
+			SuddenDeathTurn: intPtr(12),
+		},
+	}
+	gs := NewGameState("custom", settings, model.PlayerState{ID: "p1"}, model.PlayerState{ID: "p2"}, 1)
+
+	assert.Equal(t, 300, gs.Players[0].Coins)
+	assert.Equal(t, 300, gs.Players[1].Coins)
+	assert.Equal(t, 5, DominanceTurnsRequired(gs))
+	assert.Equal(t, 12, SuddenDeathThreshold(gs))
+
+	// Other games keep the server's balance
+	plain := NewTestGame().Build()
+	assert.Equal(t, 1000, plain.Players[0].Coins)
+	assert.Equal(t, 3, DominanceTurnsRequired(plain))
+	assert.Equal(t, 20, SuddenDeathThreshold(plain))
+	assert.Equal(t, 20, CurrentBalance().SuddenDeath.TurnThresholds["small"])
+}
+
+func TestRoomRules_IncomeAndRoster(t *testing.T) {
+	gs := NewTestGame().
+		WithRules(&model.RuleOverrides{
+			PassiveIncome:   intPtr(10),
+			StructureIncome: intPtr(0),
+			AllowedTroops:   []model.TroopType{troopMarine, troopSniper},
+		}).
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureOutpost, "p1", hex.NewCoord(2, -1, -1)).
+		Build()
+
+	passive, structures, total := CalculateIncome(gs, "p1")
+	assert.Equal(t, 10, passive)
+	assert.Equal(t, 0, structures)
+	assert.Equal(t, 10, total)
+
+	assert.Equal(t, []model.TroopType{troopMarine, troopSniper}, TroopTypes(gs))
+	hq := gs.PlayerHQ("p1")
+	result := ExecuteBuy(gs, "p1", troopMech, hq.ID)
+	assert.False(t, result.Ack, "troops outside the room's roster cannot be bought")
+	result = ExecuteBuy(gs, "p1", troopSniper, hq.ID)
+	assert.True(t, result.Ack)
+
+	// The server's roster is untouched
+	assert.Contains(t, CurrentBalance().Troops, string(troopMech))
+}
+
+func TestResolveRules_Invalid(t *testing.T) {
+	NewTestGame()
+
+	cases := map[string]*model.RuleOverrides{
+		"negative coins":    {StartingCoins: intPtr(-1)},
+		"zero dominance":    {DominanceTurns: intPtr(0)},
+		"unknown troop":     {AllowedTroops: []model.TroopType{"dragon"}},
+		"zero sudden death": {SuddenDeathTurn: intPtr(0)},
+	}
+	for name, o := range cases {
+		_, err := ResolveRules(CurrentBalance(), model.RoomSettings{MapSize: model.MapSizeSmall, Rules: o})
+		assert.Error(t, err, name)
+	}
+
+	rules, err := ResolveRules(CurrentBalance(), model.RoomSettings{MapSize: model.MapSizeSmall})
+	require.NoError(t, err)
+	assert.Same(t, CurrentBalance(), rules)
+}
+
+func TestLoadBalance_RunningGamesKeepTheirRules(t *testing.T) {
+	NewTestGame()
+	original := CurrentBalance()
+	t.Cleanup(func() { LoadBalance(original) })
+
+	settings := model.RoomSettings{MapSize: model.MapSizeSmall, TurnMode: model.TurnModeAlternating}
+	before := NewGameState("before", settings, model.PlayerState{ID: "p1"}, model.PlayerState{ID: "p2"}, 1)
+
+	// Reload with a cheaper marine
+	reloaded := *original
+	reloaded.Version = "v2"
+	reloaded.Troops = map[string]config.TroopConfig{}
+	for name, tc := range original.Troops {
+		reloaded.Troops[name] = tc
+	}
+	marine := reloaded.Troops[string(troopMarine)]
+	marine.Cost = 50
+	reloaded.Troops[string(troopMarine)] = marine
+	LoadBalance(&reloaded)
+
+	after := NewGameState("after", settings, model.PlayerState{ID: "p1"}, model.PlayerState{ID: "p2"}, 1)
+	assert.Equal(t, "v2", after.RulesVersion)
+	assert.Equal(t, 50, TroopCost(after, troopMarine))
+
+	assert.Equal(t, original.Version, before.RulesVersion)
+	assert.Equal(t, 100, TroopCost(before, troopMarine))
+
+	// A restored game still plays by the ruleset it started with
+	data, err := before.Serialize()
+	require.NoError(t, err)
+	restored, err := DeserializeGameState(data)
+	require.NoError(t, err)
+	assert.Equal(t, original.Version, restored.RulesVersion)
+	assert.Equal(t, 100, TroopCost(restored, troopMarine))
+}
+
+func TestResolveRules_Version(t *testing.T) {
+	NewTestGame()
+
+	o := &model.RuleOverrides{StartingCoins: intPtr(300)}
+	a, err := ResolveRules(CurrentBalance(), model.RoomSettings{Rules: o})
+	require.NoError(t, err)
+	b, err := ResolveRules(CurrentBalance(), model.RoomSettings{Rules: &model.RuleOverrides{StartingCoins: intPtr(300)}})
+	require.NoError(t, err)
+	c, err := ResolveRules(CurrentBalance(), model.RoomSettings{Rules: &model.RuleOverrides{StartingCoins: intPtr(400)}})
+	require.NoError(t, err)
+
+	assert.Equal(t, a.Version, b.Version, "same overrides, same version")
+	assert.NotEqual(t, a.Version, c.Version)
+	assert.True(t, strings.HasPrefix(a.Version, CurrentBalance().Version+"+"))
+}
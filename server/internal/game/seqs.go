@@ -0,0 +1,102 @@
+package game
+
+import (
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// SeqHistorySize is how many of a player's latest actions the engine remembers the
+// answer to, for clients retrying an action they got no answer for.
+const SeqHistorySize = 64
+
+// seqTracked lists the actions that change the game. Their sequence numbers must
+// increase, and a retried one is answered again instead of being played twice.
+// Queries may be sent again freely and are not tracked.
+var seqTracked = map[string]bool{
+	ws.MsgMove:         true,
+	ws.MsgAttack:       true,
+	ws.MsgBuy:          true,
+	ws.MsgEndTurn:      true,
+	ws.MsgSurrender:    true,
+	ws.MsgEmote:        true,
+	ws.MsgOrderMove:    true,
+	ws.MsgOrderAttack:  true,
+	ws.MsgOrderBuy:     true,
+	ws.MsgCancelOrder:  true,
+	ws.MsgSubmitOrders: true,
+	ws.MsgClientSeed:   true,
+}
+
+// seqHistory remembers, per player, the sequence numbers of the latest actions the
+// engine processed and how it answered them. It belongs to the engine's goroutine.
+type seqHistory struct {
+	size    int
+	players map[string]*playerSeqs
+}
+
+// playerSeqs is one player's latest actions.
+type playerSeqs struct {
+	last    int               // highest sequence number processed
+	replies map[int]*seqReply // answers by sequence number
+	order   []int             // sequence numbers in replies, oldest first
+}
+
+// seqReply is how the engine answered an action. It is unanswered until the action's
+// ACK or NACK has been sent.
+type seqReply struct {
+	answered bool
+	ack      bool
+	code     string
+	message  string
+}
+
+func newSeqHistory(size int) *seqHistory {
+	return &seqHistory{
+		size:    size,
+		players: make(map[string]*playerSeqs),
+	}
+}
+
+// accept registers the player's action with the given sequence number, to be processed.
+// It returns false if the action must not be processed, with the earlier answer if it
+// is a retry, or nil if the sequence number is out of order or too old to remember.
+func (h *seqHistory) accept(playerID string, seq int) (*seqReply, bool) {
+	p := h.players[playerID]
+	if p == nil {
+		p = &playerSeqs{replies: make(map[int]*seqReply)}
+		h.players[playerID] = p
+	}
+	if reply, ok := p.replies[seq]; ok {
+		return reply, false
+	}
+	if seq <= p.last {
+		return nil, false
+	}
+
+	p.last = seq
+	p.replies[seq] = &seqReply{}
+	p.order = append(p.order, seq)
+	if len(p.order) > h.size {
+		delete(p.replies, p.order[0])
+		p.order = p.order[1:]
+	}
+	return nil, true
+}
+
+// answer records the ACK or NACK sent for an accepted action. Only the first counts.
+func (h *seqHistory) answer(playerID string, seq int, ack bool, code, message string) {
+	p := h.players[playerID]
+	if p == nil {
+		return
+	}
+	if reply := p.replies[seq]; reply != nil && !reply.answered {
+		*reply = seqReply{answered: true, ack: ack, code: code, message: message}
+	}
+}
+
+// restart forgets the player's actions if seq does not follow them: the client has
+// started counting again, as it does after a restart.
+func (h *seqHistory) restart(playerID string, seq int) {
+	if p := h.players[playerID]; p != nil && seq <= p.last {
+		delete(h.players, playerID)
+	}
+}
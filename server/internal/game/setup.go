@@ -0,0 +1,29 @@
+package game
+
+import (
+	"github.com/teomiscia/hexbattle/internal/mapgen"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// ApplyMap places a generated map on the state: its terrain, then every structure
+// with stats from the game's ruleset. The first HQ goes to the first player and the
+// second to the second player. newID names each structure.
+func ApplyMap(gs *GameState, m *mapgen.MapResult, newID func() string) error {
+	gs.Terrain = m.Terrain
+	for _, sp := range m.Structures {
+		owner := sp.OwnerID
+		if sp.Type == model.StructureHQ {
+			if gs.PlayerHQ(gs.Players[0].ID) == nil {
+				owner = gs.Players[0].ID
+			} else {
+				owner = gs.Players[1].ID
+			}
+		}
+		s, err := NewStructureFromBalance(gs, newID(), sp.Type, owner, sp.Position)
+		if err != nil {
+			return err
+		}
+		gs.AddStructure(s)
+	}
+	return nil
+}
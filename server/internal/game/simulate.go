@@ -0,0 +1,168 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+// CombatOdds summarises the outcome of many simulated attacks.
+// For attacks on structures, KillChance is the chance of capturing the structure.
+type CombatOdds struct {
+	Trials                int     `json:"trials"`
+	HitChance             float64 `json:"hit_chance"`
+	CritChance            float64 `json:"crit_chance"`
+	FumbleChance          float64 `json:"fumble_chance"`
+	ExpectedDamage        float64 `json:"expected_damage"`
+	KillChance            float64 `json:"kill_chance"`
+	CounterChance         float64 `json:"counter_chance"`
+	ExpectedCounterDamage float64 `json:"expected_counter_damage"`
+	AttackerDeathChance   float64 `json:"attacker_death_chance"`
+}
+
+// Matchup describes a combat between units fresh from the balance data, at full HP.
+// Set Defender for a troop fight or Structure for an attack on a structure.
+type Matchup struct {
+	Attacker        model.TroopType
+	Defender        model.TroopType
+	Structure       model.StructureType
+	AttackerTerrain model.TerrainType
+	DefenderTerrain model.TerrainType
+}
+
+// oddsCounter accumulates simulated results into CombatOdds.
+type oddsCounter struct {
+	trials, hits, crits, fumbles, kills, counters, deaths int
+	damage, counterDamage                                 int
+}
+
+func (c *oddsCounter) odds() CombatOdds {
+	if c.trials == 0 {
+		return CombatOdds{}
+	}
+	n := float64(c.trials)
+	return CombatOdds{
+		Trials:                c.trials,
+		HitChance:             float64(c.hits) / n,
+		CritChance:            float64(c.crits) / n,
+		FumbleChance:          float64(c.fumbles) / n,
+		ExpectedDamage:        float64(c.damage) / n,
+		KillChance:            float64(c.kills) / n,
+		CounterChance:         float64(c.counters) / n,
+		ExpectedCounterDamage: float64(c.counterDamage) / n,
+		AttackerDeathChance:   float64(c.deaths) / n,
+	}
+}
+
+// SimulateTroopCombat resolves the attack trials times with ResolveTroopCombat and
+// returns the odds. The troops are copied for every trial, so their current HP and
+// the terrain they stand on in gs are taken into account and nothing is modified.
+func SimulateTroopCombat(gs *GameState, attacker, defender *model.Troop, trials int, seed int64) CombatOdds {
+	roller := dice.NewRoller(seed)
+	var c oddsCounter
+	for i := 0; i < trials; i++ {
+		a, d := *attacker, *defender
+		result, _ := ResolveTroopCombat(gs, roller, &a, &d)
+
+		c.trials++
+		if result.Hit {
+			c.hits++
+		}
+		if result.Crit {
+			c.crits++
+		}
+		if result.Fumble {
+			c.fumbles++
+		}
+		if result.Killed {
+			c.kills++
+		}
+		if result.HasCounter {
+			c.counters++
+		}
+		if result.AttackerKilled {
+			c.deaths++
+		}
+		c.damage += result.Damage
+		c.counterDamage += result.CounterDamage
+	}
+	return c.odds()
+}
+
+// SimulateStructureAttack resolves the attack trials times with ResolveStructureAttack
+// and returns the odds. The troop and structure are copied for every trial.
+func SimulateStructureAttack(gs *GameState, attacker *model.Troop, structure *model.Structure, trials int, seed int64) CombatOdds {
+	roller := dice.NewRoller(seed)
+	atkModifier := attacker.ATK + TerrainInfo(gs, gs.GetTerrainAt(attacker.Hex)).ATKModifier
+	var c oddsCounter
+	for i := 0; i < trials; i++ {
+		a, s := *attacker, *structure
+		result, _ := ResolveStructureAttack(gs, roller, &a, &s)
+
+		c.trials++
+		// Damage dice roll at least 1, so any damage means the attack hit
+		if result.Damage > 0 {
+			c.hits++
+		}
+		switch result.HitRoll - atkModifier {
+		case 20:
+			c.crits++
+		case 1:
+			c.fumbles++
+		}
+		if result.Captured {
+			c.kills++
+		}
+		c.damage += result.Damage
+	}
+	return c.odds()
+}
+
+// SimulateMatchup simulates a matchup under the given ruleset (the server's balance if nil).
+func SimulateMatchup(rules *config.BalanceData, m Matchup, trials int, seed int64) (CombatOdds, error) {
+	if rules == nil {
+		rules = CurrentBalance()
+	}
+	gs := &GameState{
+		Rules:      rules,
+		Terrain:    make(map[hex.Coord]model.TerrainType),
+		Troops:     make(map[string]*model.Troop),
+		Structures: make(map[string]*model.Structure),
+	}
+
+	atkPos, defPos := hex.NewCoord(0, 0, 0), hex.NewCoord(1, 0, -1)
+	gs.Terrain[atkPos] = terrainOrPlains(m.AttackerTerrain)
+	gs.Terrain[defPos] = terrainOrPlains(m.DefenderTerrain)
+
+	attacker, err := NewTroopFromBalance(gs, "sim_attacker", m.Attacker, "p1", atkPos)
+	if err != nil {
+		return CombatOdds{}, err
+	}
+
+	switch {
+	case m.Defender != "":
+		defender, err := NewTroopFromBalance(gs, "sim_defender", m.Defender, "p2", defPos)
+		if err != nil {
+			return CombatOdds{}, err
+		}
+		return SimulateTroopCombat(gs, attacker, defender, trials, seed), nil
+	case m.Structure != "":
+		structure, err := NewStructureFromBalance(gs, "sim_structure", m.Structure, "p2", defPos)
+		if err != nil {
+			return CombatOdds{}, err
+		}
+		return SimulateStructureAttack(gs, attacker, structure, trials, seed), nil
+	default:
+		return CombatOdds{}, fmt.Errorf("simulate: matchup has no defender")
+	}
+}
+
+func terrainOrPlains(t model.TerrainType) model.TerrainType {
+	if t == "" {
+		return model.TerrainPlains
+	}
+	return t
+}
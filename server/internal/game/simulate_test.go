@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func TestSimulateMatchup_TroopOdds(t *testing.T) {
+	NewTestGame()
+
+	// Marine (ATK 3) vs marine (DEF 14) on plains: hits on a natural 11+, so 50%
+	odds, err := SimulateMatchup(nil, Matchup{Attacker: troopMarine, Defender: troopMarine}, 20000, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 20000, odds.Trials)
+	assert.InDelta(t, 0.50, odds.HitChance, 0.02)
+	assert.InDelta(t, 0.05, odds.CritChance, 0.01)
+	assert.InDelta(t, 0.05, odds.FumbleChance, 0.01)
+	assert.Greater(t, odds.ExpectedDamage, 0.0)
+	assert.InDelta(t, 1-odds.KillChance, odds.CounterChance, 0.0001, "melee vs melee always counters unless the defender dies")
+
+	// Ranged vs ranged only counters on a fumble
+	odds, err = SimulateMatchup(nil, Matchup{Attacker: troopSniper, Defender: troopMech}, 20000, 1)
+	require.NoError(t, err)
+	assert.InDelta(t, odds.FumbleChance, odds.CounterChance, 0.001)
+}
+
+func TestSimulateMatchup_Terrain(t *testing.T) {
+	NewTestGame()
+
+	open, err := SimulateMatchup(nil, Matchup{Attacker: troopMarine, Defender: troopSniper}, 10000, 1)
+	require.NoError(t, err)
+	forest, err := SimulateMatchup(nil, Matchup{Attacker: troopMarine, Defender: troopSniper, DefenderTerrain: model.TerrainForest}, 10000, 1)
+	require.NoError(t, err)
+	assert.Less(t, forest.HitChance, open.HitChance, "forest gives the defender cover")
+}
+
+func TestSimulateStructureAttack_DoesNotModifyUnits(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopMech, hex.NewCoord(0, 0, 0), true).
+		WithStructure(model.StructureOutpost, "p2", hex.NewCoord(1, 0, -1)).
+		Build()
+	mech := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+	outpost := gs.StructureAtHex(hex.NewCoord(1, 0, -1))
+
+	odds := SimulateStructureAttack(gs, mech, outpost, 5000, 1)
+	assert.Greater(t, odds.KillChance, 0.0)
+	assert.Greater(t, odds.CritChance, 0.0)
+	assert.False(t, mech.HasAttacked)
+	assert.Equal(t, outpost.MaxHP, outpost.CurrentHP)
+	assert.Equal(t, "p2", outpost.OwnerID)
+}
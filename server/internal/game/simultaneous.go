@@ -0,0 +1,578 @@
+package game
+
+import (
+	"sort"
+
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+// DodgeATKPenalty is the ATK reduction applied when an attack order's target
+// moved out of the attacked hex into an adjacent one.
+const DodgeATKPenalty = 2
+
+// OrderSet holds one player's queued orders for a simultaneous turn.
+type OrderSet struct {
+	Moves     map[string]hex.Coord // unit_id -> destination
+	Attacks   map[string]hex.Coord // unit_id -> targeted hex
+	Buys      []BuyOrder
+	Submitted bool // player has locked in their orders
+}
+
+// BuyOrder is a queued troop purchase.
+type BuyOrder struct {
+	TroopType   model.TroopType
+	StructureID string
+}
+
+// newOrderSet creates an empty order set.
+func newOrderSet() *OrderSet {
+	return &OrderSet{
+		Moves:   make(map[string]hex.Coord),
+		Attacks: make(map[string]hex.Coord),
+	}
+}
+
+// OrdersFor returns the order set of the given player, creating it if needed.
+// Returns nil if the player is not in the game.
+func (gs *GameState) OrdersFor(playerID string) *OrderSet {
+	idx := gs.PlayerIndex(playerID)
+	if idx < 0 {
+		return nil
+	}
+	if gs.Orders[idx] == nil {
+		gs.Orders[idx] = newOrderSet()
+	}
+	return gs.Orders[idx]
+}
+
+// AllOrdersSubmitted returns true once both players have locked in their orders.
+func (gs *GameState) AllOrdersSubmitted() bool {
+	for i := 0; i < 2; i++ {
+		if gs.Orders[i] == nil || !gs.Orders[i].Submitted {
+			return false
+		}
+	}
+	return true
+}
+
+// ClearOrders discards all queued orders for both players.
+func (gs *GameState) ClearOrders() {
+	gs.Orders = [2]*OrderSet{}
+}
+
+// reservedCoins returns the total cost of the queued buy orders.
+func (o *OrderSet) reservedCoins(gs *GameState) int {
+	total := 0
+	for _, b := range o.Buys {
+		total += TroopCost(gs, b.TroopType)
+	}
+	return total
+}
+
+// --- Order validation ---
+
+// validatePlanning checks the player can still change their orders.
+func validatePlanning(gs *GameState, playerID string) (model.ErrorCode, string) {
+	if !gs.IsSimultaneous() {
+		return model.ErrInvalidMessage, "orders are only used in simultaneous mode"
+	}
+	if gs.Phase != model.PhasePlanning {
+		return model.ErrNotYourTurn, "not in planning phase"
+	}
+	orders := gs.OrdersFor(playerID)
+	if orders == nil {
+		return model.ErrInvalidMessage, "player not found"
+	}
+	if orders.Submitted {
+		return model.ErrOrdersLocked, "orders already submitted"
+	}
+	return "", ""
+}
+
+// validateOrderUnit checks the unit exists, belongs to the player and can act this turn.
+func validateOrderUnit(gs *GameState, playerID string, unitID string) (*model.Troop, model.ErrorCode, string) {
+	troop := gs.GetTroop(unitID)
+	if troop == nil || !troop.IsAlive() {
+		return nil, model.ErrUnitNotFound, "unit not found"
+	}
+	if troop.OwnerID != playerID {
+		return nil, model.ErrUnitNotFound, "unit does not belong to you"
+	}
+	if !troop.IsReady {
+		return nil, model.ErrUnitNotReady, "unit was purchased this turn and cannot act"
+	}
+	return troop, "", ""
+}
+
+// ValidateMoveOrder checks if a move order can be queued.
+// Reachability is checked against the board as it stands during planning.
+func ValidateMoveOrder(gs *GameState, playerID string, unitID string, target hex.Coord) (model.ErrorCode, string) {
+	if code, msg := validatePlanning(gs, playerID); code != "" {
+		return code, msg
+	}
+
+	troop, code, msg := validateOrderUnit(gs, playerID, unitID)
+	if code != "" {
+		return code, msg
+	}
+
+	if !gs.Grid.Contains(target) {
+		return model.ErrInvalidMove, "target hex is out of bounds"
+	}
+	if !gs.IsHexPassable(target) {
+		return model.ErrInvalidMove, "target hex is impassable terrain"
+	}
+	if gs.StructureAtHex(target) != nil {
+		return model.ErrInvalidMove, "target hex is occupied by a structure"
+	}
+	if CanReach(gs, troop, target) < 0 {
+		return model.ErrInvalidMove, "target hex is not reachable within mobility range"
+	}
+
+	return "", ""
+}
+
+// ValidateAttackOrder checks if an attack order can be queued.
+// Range is measured from the unit's queued move destination, if any.
+func ValidateAttackOrder(gs *GameState, playerID string, unitID string, target hex.Coord) (model.ErrorCode, string) {
+	if code, msg := validatePlanning(gs, playerID); code != "" {
+		return code, msg
+	}
+
+	troop, code, msg := validateOrderUnit(gs, playerID, unitID)
+	if code != "" {
+		return code, msg
+	}
+
+	if !gs.Grid.Contains(target) {
+		return model.ErrInvalidAttack, "target hex is out of bounds"
+	}
+
+	from := troop.Hex
+	if dest, ok := gs.OrdersFor(playerID).Moves[unitID]; ok {
+		from = dest
+	}
+	dist := from.Distance(target)
+	if dist < 1 || dist > troop.Range {
+		return model.ErrInvalidAttack, "target is out of attack range"
+	}
+
+	if s := gs.StructureAtHex(target); s != nil && s.IsOwnedBy(playerID) {
+		return model.ErrInvalidAttack, "cannot attack your own structure"
+	}
+
+	return "", ""
+}
+
+// ValidateBuyOrder checks if a buy order can be queued, counting coins
+// already reserved by earlier buy orders this turn.
+func ValidateBuyOrder(gs *GameState, playerID string, troopType model.TroopType, structureID string) (model.ErrorCode, string) {
+	if code, msg := validatePlanning(gs, playerID); code != "" {
+		return code, msg
+	}
+
+	if code, msg := ValidatePurchase(gs, playerID, troopType, structureID); code != "" {
+		return code, msg
+	}
+
+	idx := gs.PlayerIndex(playerID)
+	if gs.Players[idx].Coins-gs.OrdersFor(playerID).reservedCoins(gs) < TroopCost(gs, troopType) {
+		return model.ErrInsufficientFunds, "not enough coins"
+	}
+
+	return "", ""
+}
+
+// --- Order queueing ---
+
+// QueueMoveOrder queues (or replaces) a unit's move order.
+func QueueMoveOrder(gs *GameState, playerID string, unitID string, target hex.Coord) *ActionResult {
+	errCode, errMsg := ValidateMoveOrder(gs, playerID, unitID, target)
+	if errCode != "" {
+		return &ActionResult{
+			Ack:   false,
+			Error: &ws.ErrorData{Code: errCode, Message: errMsg},
+		}
+	}
+
+	gs.OrdersFor(playerID).Moves[unitID] = target
+	return &ActionResult{Ack: true}
+}
+
+// QueueAttackOrder queues (or replaces) a unit's attack order.
+func QueueAttackOrder(gs *GameState, playerID string, unitID string, target hex.Coord) *ActionResult {
+	errCode, errMsg := ValidateAttackOrder(gs, playerID, unitID, target)
+	if errCode != "" {
+		return &ActionResult{
+			Ack:   false,
+			Error: &ws.ErrorData{Code: errCode, Message: errMsg},
+		}
+	}
+
+	gs.OrdersFor(playerID).Attacks[unitID] = target
+	return &ActionResult{Ack: true}
+}
+
+// QueueBuyOrder queues a troop purchase.
+func QueueBuyOrder(gs *GameState, playerID string, troopType model.TroopType, structureID string) *ActionResult {
+	errCode, errMsg := ValidateBuyOrder(gs, playerID, troopType, structureID)
+	if errCode != "" {
+		return &ActionResult{
+			Ack:   false,
+			Error: &ws.ErrorData{Code: errCode, Message: errMsg},
+		}
+	}
+
+	orders := gs.OrdersFor(playerID)
+	orders.Buys = append(orders.Buys, BuyOrder{TroopType: troopType, StructureID: structureID})
+	return &ActionResult{Ack: true}
+}
+
+// CancelOrders withdraws a unit's move and attack orders, or every queued
+// order (including buys) if unitID is empty.
+func CancelOrders(gs *GameState, playerID string, unitID string) *ActionResult {
+	errCode, errMsg := validatePlanning(gs, playerID)
+	if errCode != "" {
+		return &ActionResult{
+			Ack:   false,
+			Error: &ws.ErrorData{Code: errCode, Message: errMsg},
+		}
+	}
+
+	orders := gs.OrdersFor(playerID)
+	if unitID == "" {
+		gs.Orders[gs.PlayerIndex(playerID)] = newOrderSet()
+	} else {
+		delete(orders.Moves, unitID)
+		delete(orders.Attacks, unitID)
+	}
+	return &ActionResult{Ack: true}
+}
+
+// SubmitOrders locks in the player's queued orders for this turn.
+func SubmitOrders(gs *GameState, playerID string) *ActionResult {
+	errCode, errMsg := validatePlanning(gs, playerID)
+	if errCode != "" {
+		return &ActionResult{
+			Ack:   false,
+			Error: &ws.ErrorData{Code: errCode, Message: errMsg},
+		}
+	}
+
+	gs.OrdersFor(playerID).Submitted = true
+
+	return &ActionResult{
+		Ack:        true,
+		Deltas:     []interface{}{&ws.OrdersSubmittedData{PlayerID: playerID}},
+		DeltaTypes: []string{ws.MsgOrdersSubmitted},
+	}
+}
+
+// --- Resolution pipeline ---
+
+// queuedOrder is a move or attack order paired with its unit during resolution.
+type queuedOrder struct {
+	troop  *model.Troop
+	target hex.Coord
+}
+
+// ResolveSimultaneousTurn resolves both players' queued orders:
+// movement first, then attacks, then purchases. Orders are cleared afterwards.
+// It does not start the next turn; see AdvanceSimultaneousTurn.
+func ResolveSimultaneousTurn(gs *GameState, roller *dice.Roller) *ActionResult {
+	for i := 0; i < 2; i++ {
+		if gs.Orders[i] == nil {
+			gs.Orders[i] = newOrderSet()
+		}
+	}
+
+	movement, origins := resolveMovement(gs)
+	attacks := resolveAttacks(gs, roller, origins)
+
+	deltas := []interface{}{movement, attacks}
+	deltaTypes := []string{ws.MsgMovementResolved, ws.MsgAttacksResolved}
+
+	// Purchases resolve last: new troops spawn around the post-combat board.
+	for i := 0; i < 2; i++ {
+		playerID := gs.Players[i].ID
+		for _, buy := range gs.Orders[i].Buys {
+			if code, _ := ValidatePurchase(gs, playerID, buy.TroopType, buy.StructureID); code != "" {
+				continue // spawn lost or funds gone during resolution
+			}
+			result := purchaseTroop(gs, playerID, buy.TroopType, buy.StructureID)
+			if result.Ack {
+				deltas = append(deltas, result.Deltas...)
+				deltaTypes = append(deltaTypes, result.DeltaTypes...)
+			}
+		}
+	}
+
+	gs.ClearOrders()
+	gs.Phase = model.PhaseTurnTransition
+
+	return &ActionResult{
+		Ack:        true,
+		Deltas:     deltas,
+		DeltaTypes: deltaTypes,
+		GameOver:   CheckWinConditions(gs, true),
+	}
+}
+
+// AdvanceSimultaneousTurn starts the next simultaneous turn and reopens planning.
+func AdvanceSimultaneousTurn(gs *GameState, roller *dice.Roller) *ActionResult {
+	gs.TurnNumber++
+	turnStartData := RunSimultaneousTurnStart(gs, roller)
+	gs.Phase = model.PhasePlanning
+
+	// Check win conditions after turn start (sudden death may kill things)
+	gameOver := CheckWinConditions(gs, false)
+
+	return &ActionResult{
+		Ack:        true,
+		Deltas:     []interface{}{turnStartData},
+		DeltaTypes: []string{ws.MsgTurnStart},
+		GameOver:   gameOver,
+	}
+}
+
+// resolveMovement applies all queued moves at once. When several units target
+// the same hex, the one with the highest mobility arrives first and the others
+// are cancelled (a tie cancels everyone). Remaining moves are checked against the
+// board as it was before the phase, without the units that are moving away, so the
+// outcome does not depend on the order moves are looked at. A move that cannot reach
+// its destination is cancelled and its unit stays put, which may in turn block
+// others, until every remaining move is possible.
+// Returns the delta and the pre-move hex of every unit that moved.
+func resolveMovement(gs *GameState) (*ws.MovementResolvedData, map[string]hex.Coord) {
+	gs.Phase = model.PhaseMoveResolution
+
+	data := &ws.MovementResolvedData{
+		Moves:     []ws.TroopMovedData{},
+		Cancelled: []ws.CancelledOrder{},
+	}
+	origins := make(map[string]hex.Coord)
+
+	byTarget := make(map[hex.Coord][]queuedOrder)
+	for i := 0; i < 2; i++ {
+		for unitID, target := range gs.Orders[i].Moves {
+			troop := gs.GetTroop(unitID)
+			if troop == nil || !troop.CanMove() {
+				continue
+			}
+			byTarget[target] = append(byTarget[target], queuedOrder{troop: troop, target: target})
+		}
+	}
+
+	var moves []queuedOrder
+	for _, claims := range byTarget {
+		if len(claims) == 1 {
+			moves = append(moves, claims[0])
+			continue
+		}
+		sortByMobility(claims)
+		winnerTied := claims[0].troop.Mobility == claims[1].troop.Mobility
+		for i, c := range claims {
+			if i == 0 && !winnerTied {
+				moves = append(moves, c)
+				continue
+			}
+			data.Cancelled = append(data.Cancelled, ws.CancelledOrder{UnitID: c.troop.ID, Reason: "conflict"})
+		}
+	}
+
+	costs := make(map[string]int)
+	for {
+		board := boardWithout(gs, moves)
+		var blocked bool
+		kept := moves[:0]
+		for _, m := range moves {
+			cost := CanReach(board, m.troop, m.target)
+			if cost < 0 {
+				data.Cancelled = append(data.Cancelled, ws.CancelledOrder{UnitID: m.troop.ID, Reason: "blocked"})
+				blocked = true
+				continue
+			}
+			costs[m.troop.ID] = cost
+			kept = append(kept, m)
+		}
+		moves = kept
+		if !blocked {
+			break
+		}
+	}
+
+	sortByMobility(moves)
+	for _, m := range moves {
+		cost := costs[m.troop.ID]
+		from := m.troop.Hex
+		m.troop.Hex = m.target
+		m.troop.RemainingMobility -= cost
+		m.troop.HasMoved = true
+		origins[m.troop.ID] = from
+
+		data.Moves = append(data.Moves, ws.TroopMovedData{
+			UnitID:            m.troop.ID,
+			FromQ:             from.Q,
+			FromR:             from.R,
+			FromS:             from.S,
+			ToQ:               m.target.Q,
+			ToR:               m.target.R,
+			ToS:               m.target.S,
+			RemainingMobility: m.troop.RemainingMobility,
+		})
+	}
+
+	sortCancelled(data.Cancelled)
+	return data, origins
+}
+
+// boardWithout returns a copy of the state to check moves against, without the
+// troops that are moving. The copy shares everything else with the state.
+func boardWithout(gs *GameState, moves []queuedOrder) *GameState {
+	moving := make(map[string]bool, len(moves))
+	for _, m := range moves {
+		moving[m.troop.ID] = true
+	}
+	board := *gs
+	board.Troops = make(map[string]*model.Troop, len(gs.Troops))
+	for id, t := range gs.Troops {
+		if !moving[id] {
+			board.Troops[id] = t
+		}
+	}
+	return &board
+}
+
+// resolveAttacks applies all queued attacks as if they happened at once:
+// units killed during this phase still fire, and are only removed at the end.
+// An attack on a hex the target just left still lands on it, with DodgeATKPenalty,
+// if the target moved to an adjacent hex that is within the attacker's range.
+func resolveAttacks(gs *GameState, roller *dice.Roller, origins map[string]hex.Coord) *ws.AttacksResolvedData {
+	gs.Phase = model.PhaseAttackResolution
+
+	data := &ws.AttacksResolvedData{
+		Combats:          []ws.CombatResultData{},
+		StructureAttacks: []ws.StructureAttackedData{},
+		Destroyed:        []ws.TroopDestroyedData{},
+		Cancelled:        []ws.CancelledOrder{},
+	}
+
+	var attacks []queuedOrder
+	for i := 0; i < 2; i++ {
+		for unitID, target := range gs.Orders[i].Attacks {
+			troop := gs.GetTroop(unitID)
+			if troop == nil || !troop.CanAttack() {
+				continue
+			}
+			attacks = append(attacks, queuedOrder{troop: troop, target: target})
+		}
+	}
+	// Deterministic order so a replayed seed produces the same rolls.
+	sort.Slice(attacks, func(i, j int) bool {
+		return attacks[i].troop.ID < attacks[j].troop.ID
+	})
+
+	dead := make(map[string]bool)
+	recordDestroyed := func(destroyed []*ws.TroopDestroyedData) []*ws.TroopDestroyedData {
+		var fresh []*ws.TroopDestroyedData
+		for _, d := range destroyed {
+			if dead[d.UnitID] {
+				continue
+			}
+			dead[d.UnitID] = true
+			fresh = append(fresh, d)
+			data.Destroyed = append(data.Destroyed, *d)
+		}
+		return fresh
+	}
+
+	for _, a := range attacks {
+		attacker := a.troop
+		if !CanAttackTarget(attacker, a.target) {
+			data.Cancelled = append(data.Cancelled, ws.CancelledOrder{UnitID: attacker.ID, Reason: "out_of_range"})
+			continue
+		}
+
+		defender, penalty := findOrderTarget(gs, attacker, a.target, origins)
+		if defender != nil {
+			result, destroyed := resolveTroopCombat(gs, roller, attacker, defender, penalty)
+			destroyed = recordDestroyed(destroyed)
+			trackCombatStats(gs, attacker, defender, result, destroyed)
+			data.Combats = append(data.Combats, *result)
+			attacker.HasMoved = true
+			continue
+		}
+
+		structure := gs.StructureAtHex(a.target)
+		if structure != nil && !structure.IsOwnedBy(attacker.OwnerID) {
+			result, destroyed := ResolveStructureAttack(gs, roller, attacker, structure)
+			recordDestroyed(destroyed)
+			if idx := gs.PlayerIndex(attacker.OwnerID); result.Damage > 0 && idx >= 0 {
+				gs.Stats[idx].TotalDamageDealt += result.Damage
+			}
+			data.StructureAttacks = append(data.StructureAttacks, *result)
+			attacker.HasMoved = true
+			continue
+		}
+
+		data.Cancelled = append(data.Cancelled, ws.CancelledOrder{UnitID: attacker.ID, Reason: "no_target"})
+	}
+
+	for unitID := range dead {
+		gs.RemoveTroop(unitID)
+	}
+
+	return data
+}
+
+// findOrderTarget returns the enemy troop an attack order on target should hit,
+// and the ATK penalty to apply. Returns nil if no troop can be hit. Troops killed
+// earlier in the resolution stay on the board until it ends, but are not hit again.
+func findOrderTarget(gs *GameState, attacker *model.Troop, target hex.Coord, origins map[string]hex.Coord) (*model.Troop, int) {
+	if occupant := gs.TroopAtHex(target); occupant != nil && occupant.IsAlive() {
+		if occupant.OwnerID == attacker.OwnerID {
+			return nil, 0
+		}
+		return occupant, 0
+	}
+
+	// The target may have stepped out of the hex this turn. Unit IDs are sorted so a
+	// replayed seed picks the same one.
+	unitIDs := make([]string, 0, len(origins))
+	for unitID, from := range origins {
+		if from == target {
+			unitIDs = append(unitIDs, unitID)
+		}
+	}
+	sort.Strings(unitIDs)
+	for _, unitID := range unitIDs {
+		t := gs.GetTroop(unitID)
+		if t == nil || !t.IsAlive() || t.OwnerID == attacker.OwnerID {
+			continue
+		}
+		if t.Hex.Distance(target) == 1 && CanAttackTarget(attacker, t.Hex) {
+			return t, DodgeATKPenalty
+		}
+	}
+	return nil, 0
+}
+
+// sortByMobility orders orders fastest unit first, breaking ties by unit ID.
+func sortByMobility(orders []queuedOrder) {
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].troop.Mobility != orders[j].troop.Mobility {
+			return orders[i].troop.Mobility > orders[j].troop.Mobility
+		}
+		return orders[i].troop.ID < orders[j].troop.ID
+	})
+}
+
+// sortCancelled orders cancellation records by unit ID for stable output.
+func sortCancelled(cancelled []ws.CancelledOrder) {
+	sort.Slice(cancelled, func(i, j int) bool {
+		return cancelled[i].UnitID < cancelled[j].UnitID
+	})
+}
@@ -0,0 +1,227 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teomiscia/hexbattle/internal/dice"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+	"github.com/teomiscia/hexbattle/internal/ws"
+)
+
+func TestQueueOrders_RequiresPlanningPhase(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		Build()
+
+	result := QueueMoveOrder(gs, "p1", "unit_0_0_0", hex.NewCoord(1, -1, 0))
+	assert.False(t, result.Ack, "orders are rejected in alternating mode")
+}
+
+func TestSubmitOrders_LocksOrders(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		Build()
+
+	require.True(t, SubmitOrders(gs, "p1").Ack)
+	assert.False(t, gs.AllOrdersSubmitted())
+
+	result := QueueMoveOrder(gs, "p1", "unit_0_0_0", hex.NewCoord(1, -1, 0))
+	assert.False(t, result.Ack)
+	assert.Equal(t, model.ErrOrdersLocked, result.Error.Code)
+
+	require.True(t, SubmitOrders(gs, "p2").Ack)
+	assert.True(t, gs.AllOrdersSubmitted())
+}
+
+func TestQueueBuyOrder_ReservesCoins(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, -5, 5)).
+		WithCoins("p1", 250).
+		Build()
+
+	hqID := gs.PlayerHQ("p1").ID
+	assert.True(t, QueueBuyOrder(gs, "p1", troopMarine, hqID).Ack)
+	assert.True(t, QueueBuyOrder(gs, "p1", troopMarine, hqID).Ack)
+
+	result := QueueBuyOrder(gs, "p1", troopMarine, hqID)
+	assert.False(t, result.Ack)
+	assert.Equal(t, model.ErrInsufficientFunds, result.Error.Code)
+
+	// Coins are only spent at resolution
+	assert.Equal(t, 250, gs.Players[0].Coins)
+	ResolveSimultaneousTurn(gs, dice.NewRoller(42))
+	assert.Equal(t, 50, gs.Players[0].Coins)
+	assert.Len(t, gs.PlayerTroops("p1"), 2)
+}
+
+func TestResolveMovement_ConflictWonByMobility(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithTroop("p1", troopHoverbike, hex.NewCoord(-2, 0, 2), true). // mobility 5
+		WithTroop("p2", troopMarine, hex.NewCoord(2, 0, -2), true).    // mobility 3
+		Build()
+
+	target := hex.NewCoord(0, 0, 0)
+	require.True(t, QueueMoveOrder(gs, "p1", "unit_-2_0_2", target).Ack)
+	require.True(t, QueueMoveOrder(gs, "p2", "unit_2_0_-2", target).Ack)
+
+	result := ResolveSimultaneousTurn(gs, dice.NewRoller(42))
+	require.True(t, result.Ack)
+
+	assert.Equal(t, target, gs.GetTroop("unit_-2_0_2").Hex, "faster unit arrives first")
+	assert.Equal(t, hex.NewCoord(2, 0, -2), gs.GetTroop("unit_2_0_-2").Hex, "slower unit's move is cancelled")
+}
+
+func TestResolveMovement_TiedMobilityCancelsBoth(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithTroop("p1", troopMarine, hex.NewCoord(-2, 0, 2), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(2, 0, -2), true).
+		Build()
+
+	target := hex.NewCoord(0, 0, 0)
+	require.True(t, QueueMoveOrder(gs, "p1", "unit_-2_0_2", target).Ack)
+	require.True(t, QueueMoveOrder(gs, "p2", "unit_2_0_-2", target).Ack)
+
+	movement, _ := resolveMovement(gs)
+
+	assert.Empty(t, movement.Moves)
+	assert.Len(t, movement.Cancelled, 2)
+}
+
+func TestResolveMovement_VacatedHexesAreFree(t *testing.T) {
+	// Whichever of the two units is looked at first, the follower takes the leader's hex
+	for _, tc := range []struct{ leader, follower, away hex.Coord }{
+		{leader: hex.NewCoord(1, -1, 0), follower: hex.NewCoord(0, 0, 0), away: hex.NewCoord(2, -2, 0)},
+		{leader: hex.NewCoord(0, 0, 0), follower: hex.NewCoord(1, -1, 0), away: hex.NewCoord(-1, 1, 0)},
+	} {
+		gs := NewTestGame().
+			WithTurnMode(model.TurnModeSimultaneous).
+			WithTroop("p1", troopMarine, tc.leader, true).
+			WithTroop("p1", troopMarine, tc.follower, true).
+			Build()
+		leader, follower := gs.TroopAtHex(tc.leader), gs.TroopAtHex(tc.follower)
+		away := tc.away
+		// Queued straight in: a move onto a unit's hex is refused when it is queued
+		gs.OrdersFor("p1").Moves[follower.ID] = tc.leader
+		gs.OrdersFor("p1").Moves[leader.ID] = away
+		gs.OrdersFor("p2")
+
+		movement, _ := resolveMovement(gs)
+		assert.Len(t, movement.Moves, 2)
+		assert.Empty(t, movement.Cancelled)
+		assert.Equal(t, away, leader.Hex)
+		assert.Equal(t, tc.leader, follower.Hex)
+	}
+}
+
+func TestResolveMovement_BlockedLeaderBlocksFollower(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopMarine, hex.NewCoord(1, -1, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(3, -3, 0), true).
+		Build()
+	gs.OrdersFor("p1").Moves["unit_0_0_0"] = hex.NewCoord(1, -1, 0)
+	gs.OrdersFor("p1").Moves["unit_1_-1_0"] = hex.NewCoord(2, -2, 0)
+	gs.OrdersFor("p2")
+	// An enemy that stays put ends up on the leader's destination after the orders were queued
+	gs.Troops["unit_3_-3_0"].Hex = hex.NewCoord(2, -2, 0)
+
+	movement, _ := resolveMovement(gs)
+	assert.Empty(t, movement.Moves)
+	assert.Equal(t, []ws.CancelledOrder{
+		{UnitID: "unit_0_0_0", Reason: "blocked"},
+		{UnitID: "unit_1_-1_0", Reason: "blocked"},
+	}, movement.Cancelled)
+	assert.Equal(t, hex.NewCoord(0, 0, 0), gs.GetTroop("unit_0_0_0").Hex, "no two units share a hex")
+}
+
+func TestResolveMovement_PathsCrossingMovedUnits(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithTroop("p1", troopHoverbike, hex.NewCoord(0, -2, 2), true). // mobility 5, resolved first
+		WithTroop("p2", troopMarine, hex.NewCoord(-1, 0, 1), true).    // mobility 3
+		WithTerrain(hex.NewCoord(0, -1, 1), model.TerrainWater).
+		WithTerrain(hex.NewCoord(-1, -1, 2), model.TerrainWater).
+		Build()
+
+	// The marine's only way round the lake goes through where the hoverbike ends up
+	require.True(t, QueueMoveOrder(gs, "p1", "unit_0_-2_2", hex.NewCoord(0, 0, 0)).Ack)
+	require.True(t, QueueMoveOrder(gs, "p2", "unit_-1_0_1", hex.NewCoord(1, -2, 1)).Ack)
+
+	movement, _ := resolveMovement(gs)
+	assert.Empty(t, movement.Cancelled, "units moving in the same phase do not block each other")
+	assert.Len(t, movement.Moves, 2)
+}
+
+func TestResolveAttacks_DodgedTargetTakesPenalty(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithTroop("p1", troopSniper, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(2, -2, 0), true).
+		Build()
+
+	// Sniper fires at the marine's hex while the marine steps to an adjacent hex still in range.
+	require.True(t, QueueAttackOrder(gs, "p1", "unit_0_0_0", hex.NewCoord(2, -2, 0)).Ack)
+	require.True(t, QueueMoveOrder(gs, "p2", "unit_2_-2_0", hex.NewCoord(2, -1, -1)).Ack)
+
+	movement, origins := resolveMovement(gs)
+	require.Len(t, movement.Moves, 1)
+
+	attacks := resolveAttacks(gs, dice.NewRoller(42), origins)
+	require.Len(t, attacks.Combats, 1)
+	assert.Equal(t, "unit_2_-2_0", attacks.Combats[0].DefenderID)
+	assert.Equal(t, DodgeATKPenalty, attacks.Combats[0].ATKPenalty)
+}
+
+func TestResolveAttacks_UnitsKilledStillFire(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithTroop("p1", troopSniper, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopSniper, hex.NewCoord(2, -2, 0), true).
+		Build()
+
+	gs.GetTroop("unit_0_0_0").CurrentHP = 1
+	gs.GetTroop("unit_2_-2_0").CurrentHP = 1
+
+	require.True(t, QueueAttackOrder(gs, "p1", "unit_0_0_0", hex.NewCoord(2, -2, 0)).Ack)
+	require.True(t, QueueAttackOrder(gs, "p2", "unit_2_-2_0", hex.NewCoord(0, 0, 0)).Ack)
+
+	// With seed 2 the first sniper's shot kills the second
+	attacks := resolveAttacks(gs, dice.NewRoller(2), nil)
+	require.NotEmpty(t, attacks.Destroyed)
+	assert.Equal(t, "unit_2_-2_0", attacks.Destroyed[0].UnitID)
+
+	// Both orders resolve even though the first killed the second attacker.
+	require.Len(t, attacks.Combats, 2)
+	assert.Equal(t, "unit_2_-2_0", attacks.Combats[1].AttackerID)
+	assert.Empty(t, attacks.Cancelled)
+}
+
+func TestResolveAttacks_DeadTroopsAreNotHitAgain(t *testing.T) {
+	gs := NewTestGame().
+		WithTurnMode(model.TurnModeSimultaneous).
+		WithTroop("p1", troopSniper, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p1", troopSniper, hex.NewCoord(0, -1, 1), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(2, -2, 0), true).
+		Build()
+	gs.GetTroop("unit_2_-2_0").CurrentHP = 1
+	gs.OrdersFor("p2")
+
+	require.True(t, QueueAttackOrder(gs, "p1", "unit_0_0_0", hex.NewCoord(2, -2, 0)).Ack)
+	require.True(t, QueueAttackOrder(gs, "p1", "unit_0_-1_1", hex.NewCoord(2, -2, 0)).Ack)
+
+	// With seed 4 the first shot kills the marine
+	attacks := resolveAttacks(gs, dice.NewRoller(4), nil)
+	require.Len(t, attacks.Destroyed, 1)
+	require.Len(t, attacks.Combats, 1)
+	assert.Equal(t, "unit_0_-1_1", attacks.Combats[0].AttackerID)
+	assert.Equal(t, []ws.CancelledOrder{{UnitID: "unit_0_0_0", Reason: "no_target"}}, attacks.Cancelled)
+	assert.Equal(t, attacks.Combats[0].Damage, gs.Stats[0].TotalDamageDealt, "no damage is counted on the corpse")
+}
@@ -2,8 +2,12 @@ package game
 
 import (
 	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
 	"time"
 
+	"github.com/teomiscia/hexbattle/internal/config"
 	"github.com/teomiscia/hexbattle/internal/hex"
 	"github.com/teomiscia/hexbattle/internal/model"
 )
@@ -14,7 +18,10 @@ type GameState struct {
 	Phase         model.GamePhase                 `json:"phase"`
 	MapSize       model.MapSize                   `json:"map_size"`
 	TurnMode      model.TurnMode                  `json:"turn_mode"`
-	TurnTimer     int                             `json:"turn_timer"` // seconds per turn
+	FogOfWar      bool                            `json:"fog_of_war"`
+	BotTakeover   bool                            `json:"bot_takeover"`    // a bot plays for disconnected players
+	MoveTimeLimit int                             `json:"move_time_limit"` // seconds per action for bot accounts, 0 for none
+	TurnTimer     int                             `json:"turn_timer"`      // seconds per turn
 	TurnNumber    int                             `json:"turn_number"`
 	ActivePlayer  int                             `json:"active_player"` // 0 or 1 (index into Players)
 	Players       [2]model.PlayerState            `json:"players"`
@@ -22,7 +29,9 @@ type GameState struct {
 	Structures    map[string]*model.Structure     `json:"structures"` // structure_id -> structure
 	Terrain       map[hex.Coord]model.TerrainType `json:"terrain"`    // hex -> terrain type
 	Grid          *hex.Grid                       `json:"-"`          // not serialized, rebuilt from MapSize
-	Seed          int64                           `json:"seed"`
+	Seed          int64                           `json:"-"`          // dice seed, kept secret until game over
+	RollCount     uint64                          `json:"roll_count"` // values drawn from the seeded RNG, synced on snapshot
+	UnitSeq       int                             `json:"unit_seq"`   // last unit number handed out by NextUnitID
 	CreatedAt     time.Time                       `json:"created_at"`
 	TurnStartedAt time.Time                       `json:"turn_started_at"`
 
@@ -31,23 +40,43 @@ type GameState struct {
 	SuddenDeathTurn   int  `json:"sudden_death_turn"`
 	SafeZoneRadius    int  `json:"safe_zone_radius"`
 
+	// Commit-reveal dice seeding: only the commitment is shown to clients while the game runs.
+	SeedCommitment string    `json:"seed_commitment,omitempty"`
+	ServerSeed     string    `json:"-"`
+	ClientSeeds    [2]string `json:"-"`
+
+	// Ruleset the game is played with: the server's balance plus the room's overrides.
+	// Pinned when the game is created, so balance reloads don't affect running games.
+	Rules        *config.BalanceData `json:"rules"`
+	RulesVersion string              `json:"rules_version"`
+
 	// Per-player stats tracked during the game
 	Stats [2]model.GameOverStats `json:"stats"`
 
 	// First turn restriction: player 0 cannot attack on turn 1
 	FirstTurnRestriction bool `json:"first_turn_restriction"`
+
+	// Simultaneous mode: orders queued during the planning phase, indexed like Players.
+	// Not serialized so a player's plan is never sent to the opponent.
+	Orders [2]*OrderSet `json:"-"`
+
+	// Fog of war: terrain each player has ever seen, indexed like Players.
+	Explored [2]map[hex.Coord]bool `json:"explored"`
+	// What each player was last shown; rebuilt on the next full state send.
+	vision [2]*playerVision
 }
 
 // NewGameState creates an empty game state ready for map generation.
+// The game's ruleset is the server's balance with the room's overrides applied.
 func NewGameState(id string, settings model.RoomSettings, p1, p2 model.PlayerState, seed int64) *GameState {
-	p1.Coins = StartingCoins()
-	p2.Coins = StartingCoins()
-
-	return &GameState{
+	gs := &GameState{
 		ID:                   id,
 		Phase:                model.PhaseWaitingForPlayers,
 		MapSize:              settings.MapSize,
 		TurnMode:             settings.TurnMode,
+		FogOfWar:             settings.FogOfWar,
+		BotTakeover:          settings.BotTakeover,
+		MoveTimeLimit:        settings.MoveTimeLimit,
 		TurnTimer:            settings.TurnTimer,
 		TurnNumber:           0,
 		ActivePlayer:         0,
@@ -60,8 +89,22 @@ func NewGameState(id string, settings model.RoomSettings, p1, p2 model.PlayerSta
 		CreatedAt:            time.Now(),
 		SuddenDeathActive:    false,
 		SafeZoneRadius:       settings.MapSize.Radius(),
-		FirstTurnRestriction: true,
+		FirstTurnRestriction: settings.TurnMode != model.TurnModeSimultaneous, // no first-turn advantage when acting at once
+	}
+
+	// Overrides are validated when the room is created; fall back to the server's balance otherwise.
+	if resolved, err := ResolveRules(CurrentBalance(), settings); err == nil {
+		gs.Rules = resolved
+	} else {
+		gs.Rules = CurrentBalance()
 	}
+	if gs.Rules != nil {
+		gs.RulesVersion = gs.Rules.Version
+	}
+
+	gs.Players[0].Coins = StartingCoins(gs)
+	gs.Players[1].Coins = StartingCoins(gs)
+	return gs
 }
 
 // ActivePlayerState returns the state of the player whose turn it is.
@@ -199,7 +242,7 @@ func (gs *GameState) IsHexPassable(pos hex.Coord) bool {
 	if !gs.Grid.Contains(pos) {
 		return false
 	}
-	return model.IsPassable(gs.GetTerrainAt(pos))
+	return TerrainInfo(gs, gs.GetTerrainAt(pos)).Passable
 }
 
 // IsHexOccupiedByEnemy returns true if an enemy troop is on the hex.
@@ -208,6 +251,26 @@ func (gs *GameState) IsHexOccupiedByEnemy(pos hex.Coord, playerID string) bool {
 	return t != nil && t.OwnerID != playerID
 }
 
+// IsSimultaneous returns true if the game uses simultaneous turns.
+func (gs *GameState) IsSimultaneous() bool {
+	return gs.TurnMode == model.TurnModeSimultaneous
+}
+
+// ActionPhase returns the phase in which players act for this game's turn mode.
+func (gs *GameState) ActionPhase() model.GamePhase {
+	if gs.IsSimultaneous() {
+		return model.PhasePlanning
+	}
+	return model.PhasePlayerAction
+}
+
+// NextUnitID returns a new troop ID. IDs are sequential per game so that
+// replaying the same actions produces the same units.
+func (gs *GameState) NextUnitID() string {
+	gs.UnitSeq++
+	return fmt.Sprintf("unit-%d", gs.UnitSeq)
+}
+
 // SwitchActivePlayer toggles the active player index.
 func (gs *GameState) SwitchActivePlayer() {
 	gs.ActivePlayer = 1 - gs.ActivePlayer
@@ -215,20 +278,76 @@ func (gs *GameState) SwitchActivePlayer() {
 
 // Serialize converts the game state to JSON bytes for persistence.
 func (gs *GameState) Serialize() ([]byte, error) {
-	return json.Marshal(gs)
+	return json.Marshal(gameSnapshot{
+		GameState:   gs,
+		Seed:        gs.Seed,
+		ServerSeed:  gs.ServerSeed,
+		ClientSeeds: gs.ClientSeeds,
+	})
+}
+
+// gameSnapshot is the serialized form of a GameState. It adds the seed secrets,
+// which are left out of the state sent to clients.
+type gameSnapshot struct {
+	*GameState
+	Seed        int64     `json:"seed"`
+	ServerSeed  string    `json:"server_seed,omitempty"`
+	ClientSeeds [2]string `json:"client_seeds"`
 }
 
 // DeserializeGameState restores a game state from JSON bytes.
 func DeserializeGameState(data []byte) (*GameState, error) {
 	var gs GameState
-	if err := json.Unmarshal(data, &gs); err != nil {
+	snap := gameSnapshot{GameState: &gs}
+	if err := json.Unmarshal(data, &snap); err != nil {
 		return nil, err
 	}
+	gs.Seed = snap.Seed
+	gs.ServerSeed = snap.ServerSeed
+	gs.ClientSeeds = snap.ClientSeeds
 	// Rebuild non-serialized fields
 	gs.Grid = hex.NewGrid(gs.MapSize.Radius())
 	return &gs, nil
 }
 
+// Clone returns a deep copy of the game state that can be played on without
+// affecting the original. Grid and Rules are shared: neither changes during a game.
+func (gs *GameState) Clone() *GameState {
+	c := *gs
+
+	c.Troops = make(map[string]*model.Troop, len(gs.Troops))
+	for id, t := range gs.Troops {
+		troop := *t
+		c.Troops[id] = &troop
+	}
+	c.Structures = make(map[string]*model.Structure, len(gs.Structures))
+	for id, s := range gs.Structures {
+		structure := *s
+		c.Structures[id] = &structure
+	}
+	c.Terrain = maps.Clone(gs.Terrain)
+
+	for i := 0; i < 2; i++ {
+		c.Explored[i] = maps.Clone(gs.Explored[i])
+		if o := gs.Orders[i]; o != nil {
+			c.Orders[i] = &OrderSet{
+				Moves:     maps.Clone(o.Moves),
+				Attacks:   maps.Clone(o.Attacks),
+				Buys:      slices.Clone(o.Buys),
+				Submitted: o.Submitted,
+			}
+		}
+		if v := gs.vision[i]; v != nil {
+			c.vision[i] = &playerVision{
+				hexes:      maps.Clone(v.hexes),
+				troops:     maps.Clone(v.troops),
+				structures: maps.Clone(v.structures),
+			}
+		}
+	}
+	return &c
+}
+
 // StructureCountOwnedBy returns how many structures the player owns.
 func (gs *GameState) StructureCountOwnedBy(playerID string) int {
 	count := 0
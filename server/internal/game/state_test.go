@@ -0,0 +1,38 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func TestGameState_Clone(t *testing.T) {
+	gs := NewTestGame().
+		WithTroop("p1", troopMarine, hex.NewCoord(0, 0, 0), true).
+		WithTroop("p2", troopMarine, hex.NewCoord(1, 0, -1), true).
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 5, -5)).
+		WithStructure(model.StructureHQ, "p2", hex.NewCoord(0, -5, 5)).
+		Build()
+	marine := gs.TroopAtHex(hex.NewCoord(0, 0, 0))
+
+	clone := gs.Clone()
+	assert.Same(t, gs.Grid, clone.Grid)
+	assert.Same(t, gs.Rules, clone.Rules)
+
+	// Playing on the clone leaves the original untouched
+	result := ExecuteMove(clone, "p1", marine.ID, hex.NewCoord(-1, 0, 1))
+	assert.True(t, result.Ack)
+	result = ExecuteBuy(clone, "p1", troopMarine, clone.PlayerHQ("p1").ID)
+	assert.True(t, result.Ack)
+	clone.PlayerHQ("p2").CurrentHP = 1
+	clone.Terrain[hex.NewCoord(2, 0, -2)] = model.TerrainForest
+
+	assert.Equal(t, hex.NewCoord(0, 0, 0), marine.Hex)
+	assert.False(t, marine.HasMoved)
+	assert.Len(t, gs.Troops, 2)
+	assert.Equal(t, 1000, gs.Players[0].Coins)
+	assert.Equal(t, 20, gs.PlayerHQ("p2").CurrentHP)
+	assert.Equal(t, model.TerrainPlains, gs.GetTerrainAt(hex.NewCoord(2, 0, -2)))
+}
@@ -2,14 +2,13 @@ package game
 
 import (
 	"github.com/teomiscia/hexbattle/internal/hex"
-	"github.com/teomiscia/hexbattle/internal/model"
 	"github.com/teomiscia/hexbattle/internal/ws"
 )
 
 // CheckSuddenDeath checks if sudden death should activate or progress.
 // Returns true if sudden death is active (either just activated or already was).
 func CheckSuddenDeath(gs *GameState) bool {
-	threshold := SuddenDeathThreshold(gs.MapSize)
+	threshold := SuddenDeathThreshold(gs)
 
 	if gs.TurnNumber > threshold {
 		if !gs.SuddenDeathActive {
@@ -26,8 +25,8 @@ func CheckSuddenDeath(gs *GameState) bool {
 // Returns the new safe zone radius.
 func ShrinkSafeZone(gs *GameState) int {
 	shrinkRate := 1
-	if Balance != nil {
-		shrinkRate = Balance.SuddenDeath.ShrinkRate
+	if b := rules(gs); b != nil {
+		shrinkRate = b.SuddenDeath.ShrinkRate
 	}
 
 	gs.SafeZoneRadius -= shrinkRate
@@ -117,7 +116,7 @@ func closestPassableHexInZone(gs *GameState, from hex.Coord, radius int) hex.Coo
 		if !gs.Grid.Contains(c) {
 			continue
 		}
-		if !model.IsPassable(gs.GetTerrainAt(c)) {
+		if !TerrainInfo(gs, gs.GetTerrainAt(c)).Passable {
 			continue
 		}
 		// Don't place on an occupied hex (by troop or another structure)
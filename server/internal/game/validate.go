@@ -1,6 +1,8 @@
 package game
 
 import (
+	"fmt"
+
 	"github.com/teomiscia/hexbattle/internal/hex"
 	"github.com/teomiscia/hexbattle/internal/model"
 )
@@ -166,3 +168,21 @@ func ValidateEndTurn(gs *GameState, playerID string) (model.ErrorCode, string) {
 
 	return "", ""
 }
+
+// ValidateSurrender checks if a player may surrender.
+// Either player may surrender at any point of the game once the minimum turn has passed.
+func ValidateSurrender(gs *GameState, playerID string) (model.ErrorCode, string) {
+	if gs.PlayerIndex(playerID) < 0 {
+		return model.ErrGameNotFound, "you are not a player in this game"
+	}
+
+	if gs.Phase != model.PhasePlayerAction && gs.Phase != model.PhasePlanning {
+		return model.ErrInvalidMessage, "game is not in progress"
+	}
+
+	if gs.TurnNumber <= SurrenderAfterTurn(gs) {
+		return model.ErrSurrenderTooEarly, fmt.Sprintf("surrender is available after turn %d", SurrenderAfterTurn(gs))
+	}
+
+	return "", ""
+}
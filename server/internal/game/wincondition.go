@@ -20,8 +20,9 @@ func CheckWinConditions(gs *GameState, isEndOfRound bool) *ws.GameOverData {
 	}
 
 	// 2. Structure Dominance (checked per full round)
-	// Dominance is checked ONLY after both players have had a turn (after player 1's turn)
-	if isEndOfRound && gs.ActivePlayer == 1 {
+	// Dominance is checked ONLY after both players have had a turn (after player 1's turn).
+	// In simultaneous mode every resolution is a full round.
+	if isEndOfRound && (gs.ActivePlayer == 1 || gs.IsSimultaneous()) {
 		for i := 0; i < 2; i++ {
 			playerID := gs.Players[i].ID
 			owned := gs.StructureCountOwnedBy(playerID)
@@ -33,7 +34,7 @@ func CheckWinConditions(gs *GameState, isEndOfRound bool) *ws.GameOverData {
 				gs.Players[i].DominanceTurnCounter = 0
 			}
 
-			if gs.Players[i].DominanceTurnCounter >= DominanceTurnsRequired() {
+			if gs.Players[i].DominanceTurnCounter >= DominanceTurnsRequired(gs) {
 				return buildGameOver(gs, playerID, model.WinReasonStructureDominance)
 			}
 		}
@@ -48,7 +48,7 @@ func TestCheckWinConditions_StructureDominance(t *testing.T) {
 
 	// Simulate dominance for P1
 	gs.ActivePlayer = 1
-	gs.Players[0].DominanceTurnCounter = DominanceTurnsRequired() - 1
+	gs.Players[0].DominanceTurnCounter = DominanceTurnsRequired(gs) - 1
 
 	gameOver := CheckWinConditions(gs, true)
 	assert.NotNil(t, gameOver)
@@ -78,8 +78,8 @@ func TestCheckWinConditions_SuddenDeathTiebreak_HP(t *testing.T) {
 	gs := NewTestGame().
 		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 0, 0)).
 		WithStructure(model.StructureHQ, "p2", hex.NewCoord(1, -1, 0)).
-		WithTroop("p1", model.TroopMech, hex.NewCoord(0, 1, -1), true).   // 12 HP
-		WithTroop("p2", model.TroopMarine, hex.NewCoord(1, 0, -1), true). // 10 HP
+		WithTroop("p1", troopMech, hex.NewCoord(0, 1, -1), true).   // 12 HP
+		WithTroop("p2", troopMarine, hex.NewCoord(1, 0, -1), true). // 10 HP
 		Build()
 
 	// Both have 1 structure. P1 has more HP (12 vs 10).
@@ -91,3 +91,32 @@ func TestCheckWinConditions_SuddenDeathTiebreak_HP(t *testing.T) {
 	assert.Equal(t, "p1", gameOver.WinnerID)
 	assert.Equal(t, model.WinReasonSuddenDeath, gameOver.Reason)
 }
+
+func TestExecuteSurrender_TooEarly(t *testing.T) {
+	gs := NewTestGame().
+		WithTurn(5).
+		Build()
+
+	result := ExecuteSurrender(gs, "p1")
+	assert.False(t, result.Ack)
+	assert.Equal(t, model.ErrSurrenderTooEarly, result.Error.Code)
+	assert.Equal(t, model.PhasePlayerAction, gs.Phase)
+}
+
+func TestExecuteSurrender_OpponentWins(t *testing.T) {
+	gs := NewTestGame().
+		WithStructure(model.StructureHQ, "p1", hex.NewCoord(0, 0, 0)).
+		WithTurn(6).
+		WithActivePlayer("p1").
+		Build()
+
+	// The inactive player may surrender too
+	result := ExecuteSurrender(gs, "p2")
+	assert.True(t, result.Ack)
+	assert.NotNil(t, result.GameOver)
+	assert.Equal(t, "p1", result.GameOver.WinnerID)
+	assert.Equal(t, model.WinReasonForfeit, result.GameOver.Reason)
+	assert.Equal(t, 6, result.GameOver.Stats["p2"].TurnsPlayed)
+	assert.Equal(t, 1, result.GameOver.Stats["p1"].StructuresHeld)
+	assert.Equal(t, model.PhaseGameOver, gs.Phase)
+}
@@ -3,6 +3,7 @@ package lobby
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/teomiscia/hexbattle/internal/model"
 )
@@ -11,6 +12,19 @@ import (
 type QueueEntry struct {
 	PlayerID string
 	Nickname string
+	JoinedAt time.Time
+}
+
+// QueueStatus is a waiting player's place in the queue.
+type QueueStatus struct {
+	Position      int           // 1 for the next player to be matched
+	QueueSize     int           // players waiting
+	EstimatedWait time.Duration // 0 until a match has been made
+}
+
+// EstimatedWaitSeconds returns the estimated wait rounded to whole seconds.
+func (s QueueStatus) EstimatedWaitSeconds() int {
+	return int(s.EstimatedWait.Round(time.Second) / time.Second)
 }
 
 // MatchResult is returned when two players are matched.
@@ -27,6 +41,21 @@ type MatchmakingQueue struct {
 	mu      sync.Mutex
 	queue   []QueueEntry
 	manager *Manager
+	avgWait time.Duration // moving average of how long matched players waited
+	now     func() time.Time
+
+	// OnMatch is called when two players are matched, to tell them.
+	OnMatch func(result MatchResult)
+
+	// OnQueueStatus is called with a waiting player's place in the queue when they join
+	// it and whenever their place changes.
+	OnQueueStatus func(playerID string, status QueueStatus)
+}
+
+// queueNotice is a queue status to send once the queue is unlocked.
+type queueNotice struct {
+	playerID string
+	status   QueueStatus
 }
 
 // NewMatchmakingQueue creates a new matchmaking queue backed by the given lobby manager.
@@ -34,6 +63,7 @@ func NewMatchmakingQueue(manager *Manager) *MatchmakingQueue {
 	return &MatchmakingQueue{
 		queue:   make([]QueueEntry, 0),
 		manager: manager,
+		now:     time.Now,
 	}
 }
 
@@ -41,17 +71,29 @@ func NewMatchmakingQueue(manager *Manager) *MatchmakingQueue {
 // If another player is already waiting, they are immediately matched and a room is created.
 // Returns a MatchResult if matched, nil if queued.
 func (mq *MatchmakingQueue) Join(playerID, nickname string) (*MatchResult, error) {
+	result, notices, err := mq.join(playerID, nickname)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil && mq.OnMatch != nil {
+		mq.OnMatch(*result)
+	}
+	mq.notify(notices)
+	return result, nil
+}
+
+func (mq *MatchmakingQueue) join(playerID, nickname string) (*MatchResult, []queueNotice, error) {
 	mq.mu.Lock()
 	defer mq.mu.Unlock()
 
 	// Check if player is already in the queue
 	for _, entry := range mq.queue {
 		if entry.PlayerID == playerID {
-			return nil, fmt.Errorf("player already in matchmaking queue")
+			return nil, nil, fmt.Errorf("player already in matchmaking queue")
 		}
 	}
 
-	newEntry := QueueEntry{PlayerID: playerID, Nickname: nickname}
+	newEntry := QueueEntry{PlayerID: playerID, Nickname: nickname, JoinedAt: mq.now()}
 
 	// If someone is already waiting, match them
 	if len(mq.queue) > 0 {
@@ -64,41 +106,101 @@ func (mq *MatchmakingQueue) Join(playerID, nickname string) (*MatchResult, error
 		if err != nil {
 			// Put the waiting player back and return error
 			mq.queue = append([]QueueEntry{waiting}, mq.queue...)
-			return nil, fmt.Errorf("failed to create match room: %w", err)
+			return nil, nil, fmt.Errorf("failed to create match room: %w", err)
 		}
 
-		// Join the new player to the room
-		_, err = mq.manager.JoinRoom(room.Code, newEntry.PlayerID, newEntry.Nickname)
+		// Join the new player to the room. Both players are told by OnMatch, so the
+		// host is not sent OnGuestJoined as well.
+		_, _, err = mq.manager.joinRoom(room.Code, newEntry.PlayerID, newEntry.Nickname)
 		if err != nil {
-			return nil, fmt.Errorf("failed to join match room: %w", err)
+			return nil, nil, fmt.Errorf("failed to join match room: %w", err)
 		}
 
+		mq.recordWait(newEntry.JoinedAt.Sub(waiting.JoinedAt))
 		return &MatchResult{
 			RoomID:   room.ID,
 			RoomCode: room.Code,
 			Player1:  waiting,
 			Player2:  newEntry,
-		}, nil
+		}, mq.statusesLocked(0), nil
 	}
 
 	// No one waiting — add to queue
 	mq.queue = append(mq.queue, newEntry)
-	return nil, nil
+	return nil, mq.statusesLocked(len(mq.queue) - 1), nil
 }
 
 // Leave removes a player from the matchmaking queue.
 // Returns true if the player was found and removed.
 func (mq *MatchmakingQueue) Leave(playerID string) bool {
+	removed, notices := mq.leave(playerID)
+	mq.notify(notices)
+	return removed
+}
+
+func (mq *MatchmakingQueue) leave(playerID string) (bool, []queueNotice) {
 	mq.mu.Lock()
 	defer mq.mu.Unlock()
 
 	for i, entry := range mq.queue {
 		if entry.PlayerID == playerID {
 			mq.queue = append(mq.queue[:i], mq.queue[i+1:]...)
-			return true
+			return true, mq.statusesLocked(i)
 		}
 	}
-	return false
+	return false, nil
+}
+
+// Status returns the player's place in the queue, and false if they are not queued.
+func (mq *MatchmakingQueue) Status(playerID string) (QueueStatus, bool) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	for i, entry := range mq.queue {
+		if entry.PlayerID == playerID {
+			return mq.statusLocked(i), true
+		}
+	}
+	return QueueStatus{}, false
+}
+
+// statusLocked returns the status of the player at index i. Pairs are matched in
+// order, so the estimate grows with each pair ahead. The caller must hold mq.mu.
+func (mq *MatchmakingQueue) statusLocked(i int) QueueStatus {
+	return QueueStatus{
+		Position:      i + 1,
+		QueueSize:     len(mq.queue),
+		EstimatedWait: mq.avgWait * time.Duration(i/2+1),
+	}
+}
+
+// statusesLocked returns the statuses of the players from index from on, whose place
+// has just changed. The caller must hold mq.mu.
+func (mq *MatchmakingQueue) statusesLocked(from int) []queueNotice {
+	var notices []queueNotice
+	for i := from; i < len(mq.queue); i++ {
+		notices = append(notices, queueNotice{playerID: mq.queue[i].PlayerID, status: mq.statusLocked(i)})
+	}
+	return notices
+}
+
+// recordWait adds a matched player's wait to the average, weighting recent matches
+// most. The caller must hold mq.mu.
+func (mq *MatchmakingQueue) recordWait(wait time.Duration) {
+	if mq.avgWait == 0 {
+		mq.avgWait = wait
+		return
+	}
+	mq.avgWait = (3*mq.avgWait + wait) / 4
+}
+
+// notify sends queue statuses. It must be called without mq.mu held.
+func (mq *MatchmakingQueue) notify(notices []queueNotice) {
+	if mq.OnQueueStatus == nil {
+		return
+	}
+	for _, n := range notices {
+		mq.OnQueueStatus(n.playerID, n.status)
+	}
 }
 
 // Size returns the number of players waiting in the queue.
@@ -0,0 +1,107 @@
+package lobby
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestQueue returns a queue on a clock the test moves, and the notifications it sends
+// in order.
+func newTestQueue(t *testing.T) (*MatchmakingQueue, *time.Time, *[]string) {
+	t.Helper()
+	manager := NewManager(time.Minute)
+	t.Cleanup(manager.Stop)
+
+	now := time.Unix(0, 0)
+	mq := NewMatchmakingQueue(manager)
+	mq.now = func() time.Time { return now }
+
+	var sent []string
+	mq.OnMatch = func(result MatchResult) {
+		sent = append(sent, fmt.Sprintf("match %s %s", result.Player1.PlayerID, result.Player2.PlayerID))
+	}
+	mq.OnQueueStatus = func(playerID string, status QueueStatus) {
+		sent = append(sent, fmt.Sprintf("status %s %d/%d %s", playerID, status.Position, status.QueueSize, status.EstimatedWait))
+	}
+	manager.OnGuestJoined = func(room Room) {
+		sent = append(sent, "guest joined "+room.HostPlayerID)
+	}
+	return mq, &now, &sent
+}
+
+func TestMatchmakingQueue_Notifications(t *testing.T) {
+	mq, now, sent := newTestQueue(t)
+
+	result, err := mq.Join("p1", "Alice")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, []string{"status p1 1/1 0s"}, *sent, "no estimate before the first match")
+
+	*now = now.Add(30 * time.Second)
+	result, err = mq.Join("p2", "Bob")
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, "status p1 1/1 0s", (*sent)[0])
+	assert.Equal(t, []string{"match p1 p2"}, (*sent)[1:], "the host hears of the match only once")
+
+	room := mq.Manager().GetByID(result.RoomID)
+	require.NotNil(t, room)
+	assert.True(t, room.HasPlayer("p1"))
+	assert.True(t, room.HasPlayer("p2"))
+
+	// Later players are told how long the last ones waited
+	*sent = nil
+	_, err = mq.Join("p3", "Carol")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status p3 1/1 30s"}, *sent)
+
+	// Leaving tells nobody else when nobody is behind
+	*sent = nil
+	assert.True(t, mq.Leave("p3"))
+	assert.Empty(t, *sent)
+	_, queued := mq.Status("p3")
+	assert.False(t, queued)
+}
+
+func TestMatchmakingQueue_EstimatedWait(t *testing.T) {
+	mq, now, _ := newTestQueue(t)
+
+	match := func(wait time.Duration) {
+		t.Helper()
+		_, err := mq.Join("a", "A")
+		require.NoError(t, err)
+		*now = now.Add(wait)
+		result, err := mq.Join("b", "B")
+		require.NoError(t, err)
+		require.NotNil(t, result)
+	}
+
+	// A moving average that weights recent matches most
+	match(30 * time.Second)
+	match(20 * time.Second)
+
+	_, err := mq.Join("c", "C")
+	require.NoError(t, err)
+	status, ok := mq.Status("c")
+	require.True(t, ok)
+	assert.Equal(t, 1, status.Position)
+	assert.Equal(t, 27500*time.Millisecond, status.EstimatedWait)
+	assert.Equal(t, 28, status.EstimatedWaitSeconds())
+}
+
+func TestMatchmakingQueue_StatusesBehindALeaver(t *testing.T) {
+	mq, _, sent := newTestQueue(t)
+	mq.avgWait = 10 * time.Second
+
+	// The queue only ever holds one player through Join; fill it directly
+	mq.queue = []QueueEntry{{PlayerID: "p1"}, {PlayerID: "p2"}, {PlayerID: "p3"}}
+	assert.True(t, mq.Leave("p1"))
+	assert.Equal(t, []string{
+		"status p2 1/2 10s",
+		"status p3 2/2 10s",
+	}, *sent)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,18 +13,20 @@ import (
 
 // Room represents a game room where two players meet before a game starts.
 type Room struct {
-	Code          string             `json:"code"`
-	ID            string             `json:"id"`
-	HostPlayerID  string             `json:"host_player_id"`
-	HostNickname  string             `json:"host_nickname"`
-	GuestPlayerID string             `json:"guest_player_id,omitempty"`
-	GuestNickname string             `json:"guest_nickname,omitempty"`
-	Settings      model.RoomSettings `json:"settings"`
-	State         model.RoomState    `json:"state"`
-	GameID        string             `json:"game_id,omitempty"`
-	CreatedAt     time.Time          `json:"created_at"`
-	IsBotGame     bool               `json:"is_bot_game,omitempty"`
-	BotDifficulty string             `json:"bot_difficulty,omitempty"` // "easy", "medium", "hard"
+	Code           string             `json:"code"`
+	ID             string             `json:"id"`
+	HostPlayerID   string             `json:"host_player_id"`
+	HostNickname   string             `json:"host_nickname"`
+	GuestPlayerID  string             `json:"guest_player_id,omitempty"`
+	GuestNickname  string             `json:"guest_nickname,omitempty"`
+	Settings       model.RoomSettings `json:"settings"`
+	State          model.RoomState    `json:"state"`
+	GameID         string             `json:"game_id,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	IsBotGame      bool               `json:"is_bot_game,omitempty"`
+	BotDifficulty  string             `json:"bot_difficulty,omitempty"`  // "easy", "medium", "hard", "expert"
+	BotPersonality string             `json:"bot_personality,omitempty"` // name from the personalities file, empty for the default
+	IsChallenge    bool               `json:"is_challenge,omitempty"`    // the guest is a bot account, see CreateChallengeRoom
 }
 
 // IsFull returns true if both players are in the room.
@@ -97,6 +100,10 @@ type Manager struct {
 	byID     map[string]*Room // room_id -> room
 	roomTTL  time.Duration
 	stopChan chan struct{}
+
+	// OnGuestJoined is called with a copy of the room when a guest joins it by code, to
+	// tell the host.
+	OnGuestJoined func(room Room)
 }
 
 // NewManager creates a new lobby manager.
@@ -115,7 +122,68 @@ func NewManager(roomTTL time.Duration) *Manager {
 func (m *Manager) CreateRoom(hostPlayerID, hostNickname string, settings model.RoomSettings) (*Room, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.createRoomLocked(hostPlayerID, hostNickname, settings)
+}
 
+// CreateBotRoom creates a room pre-filled with a bot as the guest player.
+// The room is immediately marked as Ready so the human can join_game right away.
+func (m *Manager) CreateBotRoom(hostPlayerID, hostNickname string, botPlayerID string, botDifficulty, botPersonality string, settings model.RoomSettings) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, err := m.createRoomLocked(hostPlayerID, hostNickname, settings)
+	if err != nil {
+		return nil, err
+	}
+	room.GuestPlayerID = botPlayerID
+	room.GuestNickname = "Bot"
+	room.State = model.RoomReady
+	room.IsBotGame = true
+	room.BotDifficulty = botDifficulty
+	room.BotPersonality = botPersonality
+	return room, nil
+}
+
+// CreateChallengeRoom creates a room with a bot account as the guest player. The room
+// is Ready at once; the game starts when both the challenger and the bot send join_game.
+func (m *Manager) CreateChallengeRoom(hostPlayerID, hostNickname, botPlayerID, botNickname string, settings model.RoomSettings) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, err := m.createRoomLocked(hostPlayerID, hostNickname, settings)
+	if err != nil {
+		return nil, err
+	}
+	room.GuestPlayerID = botPlayerID
+	room.GuestNickname = botNickname
+	room.State = model.RoomReady
+	room.IsChallenge = true
+	return room, nil
+}
+
+// PendingChallenges returns the rooms a bot account has been challenged to whose game is
+// not over, oldest first. A game starts when the challenger joins, so rooms in progress
+// are listed too: the bot may not have joined yet, or may need to join again.
+func (m *Manager) PendingChallenges(botPlayerID string) []*Room {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var rooms []*Room
+	for _, room := range m.byID {
+		if !room.IsChallenge || room.GuestPlayerID != botPlayerID {
+			continue
+		}
+		if room.State == model.RoomReady || room.State == model.RoomGameInProgress {
+			rooms = append(rooms, room)
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].CreatedAt.Before(rooms[j].CreatedAt) })
+	return rooms
+}
+
+// createRoomLocked creates and registers a room waiting for an opponent.
+// The caller must hold m.mu.
+func (m *Manager) createRoomLocked(hostPlayerID, hostNickname string, settings model.RoomSettings) (*Room, error) {
 	// Generate a unique room code (retry on collision)
 	var code string
 	var err error
@@ -153,79 +221,45 @@ func (m *Manager) CreateRoom(hostPlayerID, hostNickname string, settings model.R
 	return room, nil
 }
 
-// CreateBotRoom creates a room pre-filled with a bot as the guest player.
-// The room is immediately marked as Ready so the human can join_game right away.
-func (m *Manager) CreateBotRoom(hostPlayerID, hostNickname string, botPlayerID string, botDifficulty string, settings model.RoomSettings) (*Room, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Generate a unique room code (retry on collision)
-	var code string
-	var err error
-	for attempts := 0; attempts < 10; attempts++ {
-		code, err = generateRoomCode()
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate room code: %w", err)
-		}
-		if _, exists := m.byCode[code]; !exists {
-			break
-		}
-		if attempts == 9 {
-			return nil, fmt.Errorf("failed to generate unique room code after 10 attempts")
-		}
-	}
-
-	roomID, err := generateRoomID()
+// JoinRoom adds a guest player to a room by code.
+func (m *Manager) JoinRoom(code, guestPlayerID, guestNickname string) (*Room, error) {
+	room, joined, err := m.joinRoom(code, guestPlayerID, guestNickname)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate room ID: %w", err)
+		return nil, err
 	}
-
-	room := &Room{
-		Code:          code,
-		ID:            roomID,
-		HostPlayerID:  hostPlayerID,
-		HostNickname:  hostNickname,
-		GuestPlayerID: botPlayerID,
-		GuestNickname: "Bot",
-		Settings:      settings,
-		State:         model.RoomReady,
-		CreatedAt:     time.Now(),
-		IsBotGame:     true,
-		BotDifficulty: botDifficulty,
+	if m.OnGuestJoined != nil {
+		m.OnGuestJoined(joined)
 	}
-
-	m.byCode[code] = room
-	m.byID[roomID] = room
-
 	return room, nil
 }
 
-// JoinRoom adds a guest player to a room by code.
-func (m *Manager) JoinRoom(code, guestPlayerID, guestNickname string) (*Room, error) {
+// joinRoom adds a guest player to a room by code, returning the room and a copy of it
+// taken under the lock.
+func (m *Manager) joinRoom(code, guestPlayerID, guestNickname string) (*Room, Room, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	room, ok := m.byCode[code]
 	if !ok {
-		return nil, fmt.Errorf("room not found")
+		return nil, Room{}, fmt.Errorf("room not found")
 	}
 
 	if room.State != model.RoomWaitingForOpponent {
 		if room.IsFull() {
-			return nil, fmt.Errorf("room is full")
+			return nil, Room{}, fmt.Errorf("room is full")
 		}
-		return nil, fmt.Errorf("room is not accepting players")
+		return nil, Room{}, fmt.Errorf("room is not accepting players")
 	}
 
 	if room.HostPlayerID == guestPlayerID {
-		return nil, fmt.Errorf("cannot join your own room")
+		return nil, Room{}, fmt.Errorf("cannot join your own room")
 	}
 
 	room.GuestPlayerID = guestPlayerID
 	room.GuestNickname = guestNickname
 	room.State = model.RoomReady
 
-	return room, nil
+	return room, *room, nil
 }
 
 // GetByCode returns a room by its code.
@@ -263,6 +297,16 @@ func (m *Manager) SetGameInProgress(roomID, gameID string) {
 	}
 }
 
+// ResetGame makes a room whose game could not be set up Ready again, without a game.
+func (m *Manager) ResetGame(roomID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if room, ok := m.byID[roomID]; ok {
+		room.State = model.RoomReady
+		room.GameID = ""
+	}
+}
+
 // SetGameOver marks a room's game as finished.
 func (m *Manager) SetGameOver(roomID string) {
 	m.mu.Lock()
@@ -331,6 +375,12 @@ func (m *Manager) cleanupExpired() {
 				delete(m.byCode, code)
 				delete(m.byID, room.ID)
 			}
+		case model.RoomReady:
+			// Expire challenges the bot never took up
+			if room.IsChallenge && room.GameID == "" && now.Sub(room.CreatedAt) > m.roomTTL {
+				delete(m.byCode, code)
+				delete(m.byID, room.ID)
+			}
 		case model.RoomGameOver:
 			// Clean up finished game rooms after 60 seconds
 			if now.Sub(room.CreatedAt) > m.roomTTL+60*time.Second {
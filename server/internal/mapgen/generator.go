@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"sort"
 
 	"github.com/teomiscia/hexbattle/internal/config"
 	"github.com/teomiscia/hexbattle/internal/hex"
@@ -44,6 +45,7 @@ func Generate(mapSize model.MapSize, seed int64, balance *config.BalanceData) (*
 		}
 	}
 
+	passable := passableTerrain(balance)
 	rng := rand.New(rand.NewSource(seed))
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
@@ -54,17 +56,17 @@ func Generate(mapSize model.MapSize, seed int64, balance *config.BalanceData) (*
 		hq1, hq2 := PlaceHQs(grid)
 
 		// Ensure HQ hexes and surroundings are passable
-		ensurePassable(terrain, grid, hq1, 2)
-		ensurePassable(terrain, grid, hq2, 2)
+		ensurePassable(terrain, grid, hq1, 2, passable)
+		ensurePassable(terrain, grid, hq2, 2, passable)
 
 		// Apply symmetry
 		ApplySymmetry(terrain, grid)
 
 		// Place neutral structures
-		structPositions := PlaceNeutralStructures(grid, terrain, hq1, hq2, structureCount, rng)
+		structPositions := PlaceNeutralStructures(grid, terrain, hq1, hq2, structureCount, rng, passable)
 
 		// Validate
-		if !ValidateMap(grid, terrain, hq1, hq2, structPositions, minPassableRatio) {
+		if !ValidateMap(grid, terrain, hq1, hq2, structPositions, minPassableRatio, passable) {
 			continue
 		}
 
@@ -111,25 +113,9 @@ func generateTerrain(grid *hex.Grid, seed int64, balance *config.BalanceData) ma
 	terrain := make(map[hex.Coord]model.TerrainType)
 	noise := NewNoiseGenerator(seed, 0.15)
 
-	// Get noise thresholds
-	waterThreshold := 0.15
-	plainsThreshold := 0.55
-	forestThreshold := 0.75
-	hillsThreshold := 0.88
-
-	if balance != nil {
-		if v, ok := balance.MapGen.NoiseThresholds["water"]; ok {
-			waterThreshold = v
-		}
-		if v, ok := balance.MapGen.NoiseThresholds["plains"]; ok {
-			plainsThreshold = v
-		}
-		if v, ok := balance.MapGen.NoiseThresholds["forest"]; ok {
-			forestThreshold = v
-		}
-		if v, ok := balance.MapGen.NoiseThresholds["hills"]; ok {
-			hillsThreshold = v
-		}
+	bands := defaultNoiseBands
+	if balance != nil && len(balance.MapGen.NoiseThresholds) > 0 {
+		bands = noiseBands(balance.MapGen.NoiseThresholds)
 	}
 
 	// Only generate for one half; symmetry will fill the other
@@ -139,7 +125,7 @@ func generateTerrain(grid *hex.Grid, seed int64, balance *config.BalanceData) ma
 		x, y := hexToCartesian(c)
 		value := noise.MultiOctave(x, y)
 
-		t := noiseToTerrain(value, waterThreshold, plainsThreshold, forestThreshold, hillsThreshold)
+		t := noiseToTerrain(value, bands)
 		terrain[c] = t
 	}
 
@@ -149,20 +135,46 @@ func generateTerrain(grid *hex.Grid, seed int64, balance *config.BalanceData) ma
 	return terrain
 }
 
-// noiseToTerrain maps a noise value [0,1] to a terrain type.
-func noiseToTerrain(value, water, plains, forest, hills float64) model.TerrainType {
-	switch {
-	case value < water:
-		return model.TerrainWater
-	case value < plains:
-		return model.TerrainPlains
-	case value < forest:
-		return model.TerrainForest
-	case value < hills:
-		return model.TerrainHills
-	default:
-		return model.TerrainMountains
+// noiseBand assigns a terrain type to noise values below its upper bound.
+type noiseBand struct {
+	Terrain model.TerrainType
+	Max     float64
+}
+
+// defaultNoiseBands is used when the balance file has no noise thresholds.
+var defaultNoiseBands = []noiseBand{
+	{model.TerrainWater, 0.15},
+	{model.TerrainPlains, 0.55},
+	{model.TerrainForest, 0.75},
+	{model.TerrainHills, 0.88},
+	{model.TerrainMountains, 1.0},
+}
+
+// noiseBands turns the balance file's terrain -> upper bound thresholds into
+// bands sorted by bound. Ties are broken by terrain name so maps are reproducible.
+func noiseBands(thresholds map[string]float64) []noiseBand {
+	bands := make([]noiseBand, 0, len(thresholds))
+	for name, max := range thresholds {
+		bands = append(bands, noiseBand{Terrain: model.TerrainType(name), Max: max})
 	}
+	sort.Slice(bands, func(i, j int) bool {
+		if bands[i].Max != bands[j].Max {
+			return bands[i].Max < bands[j].Max
+		}
+		return bands[i].Terrain < bands[j].Terrain
+	})
+	return bands
+}
+
+// noiseToTerrain maps a noise value [0,1] to the first band whose bound exceeds it.
+// Values above every bound fall into the last band.
+func noiseToTerrain(value float64, bands []noiseBand) model.TerrainType {
+	for _, b := range bands {
+		if value < b.Max {
+			return b.Terrain
+		}
+	}
+	return bands[len(bands)-1].Terrain
 }
 
 // hexToCartesian converts cube coordinates to approximate cartesian for noise sampling.
@@ -172,12 +184,27 @@ func hexToCartesian(c hex.Coord) (float64, float64) {
 	return x, y
 }
 
+// passableTerrain returns whether units can enter a terrain type under the balance
+// file's terrain table, the way the game plays it: types the table does not define play as plains.
+func passableTerrain(balance *config.BalanceData) func(model.TerrainType) bool {
+	if balance == nil || len(balance.Terrain) == 0 {
+		return model.IsPassable
+	}
+	return func(t model.TerrainType) bool {
+		tc, ok := balance.Terrain[string(t)]
+		if !ok {
+			tc = balance.Terrain[string(model.TerrainPlains)]
+		}
+		return tc.IsPassable()
+	}
+}
+
 // ensurePassable forces all hexes within the given radius of center to be passable.
-func ensurePassable(terrain map[hex.Coord]model.TerrainType, grid *hex.Grid, center hex.Coord, radius int) {
+func ensurePassable(terrain map[hex.Coord]model.TerrainType, grid *hex.Grid, center hex.Coord, radius int, passable func(model.TerrainType) bool) {
 	hexes := grid.HexesInRange(center, radius)
 	for _, c := range hexes {
 		t, ok := terrain[c]
-		if !ok || !model.IsPassable(t) {
+		if !ok || !passable(t) {
 			terrain[c] = model.TerrainPlains
 		}
 	}
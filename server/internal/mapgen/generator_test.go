@@ -0,0 +1,36 @@
+package mapgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/teomiscia/hexbattle/internal/config"
+	"github.com/teomiscia/hexbattle/internal/hex"
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func TestGenerate_UsesTheBalanceFilesPassability(t *testing.T) {
+	balance, err := config.LoadBalance("../../data/balance.yaml")
+	require.NoError(t, err)
+
+	impassable := false
+	forest := balance.Terrain[string(model.TerrainForest)]
+	forest.Passable = &impassable
+	balance.Terrain[string(model.TerrainForest)] = forest
+	require.True(t, model.IsPassable(model.TerrainForest), "forest is passable by default")
+
+	result, err := Generate(model.MapSizeSmall, 7, balance)
+	require.NoError(t, err)
+
+	grid := hex.NewGrid(model.MapSizeSmall.Radius())
+	for _, hq := range []hex.Coord{result.HQ1, result.HQ2} {
+		for _, c := range grid.HexesInRange(hq, 2) {
+			assert.NotEqual(t, model.TerrainForest, result.Terrain[c], "forest next to the HQ at %v", hq)
+		}
+	}
+	for _, s := range result.Structures {
+		assert.NotEqual(t, model.TerrainForest, result.Terrain[s.Position], "structure on forest at %v", s.Position)
+	}
+}
@@ -20,12 +20,13 @@ func PlaceHQs(grid *hex.Grid) (hex.Coord, hex.Coord) {
 
 // PlaceNeutralStructures places neutral structures on the map with even distribution.
 // count is the total number of neutral structures to place.
+// passable reports whether units can enter a terrain type.
 // Returns the positions for the structures.
-func PlaceNeutralStructures(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, hq2 hex.Coord, count int, rng *rand.Rand) []hex.Coord {
+func PlaceNeutralStructures(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, hq2 hex.Coord, count int, rng *rand.Rand, passable func(model.TerrainType) bool) []hex.Coord {
 	radius := grid.Radius
 
 	// Gather candidate hexes
-	candidates := gatherCandidates(grid, terrain, hq1, hq2)
+	candidates := gatherCandidates(grid, terrain, hq1, hq2, passable)
 	if len(candidates) == 0 {
 		return nil
 	}
@@ -182,11 +183,11 @@ func AssignStructureTypes(positions []hex.Coord) map[hex.Coord]model.StructureTy
 }
 
 // gatherCandidates returns all hexes that are valid candidates for structure placement.
-func gatherCandidates(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, hq2 hex.Coord) []hex.Coord {
+func gatherCandidates(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, hq2 hex.Coord, passable func(model.TerrainType) bool) []hex.Coord {
 	var candidates []hex.Coord
 	for _, c := range grid.AllHexes() {
 		t := terrain[c]
-		if !model.IsPassable(t) {
+		if !passable(t) {
 			continue
 		}
 		// Minimum 3 hexes from any HQ
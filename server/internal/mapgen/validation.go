@@ -5,30 +5,31 @@ import (
 	"github.com/teomiscia/hexbattle/internal/model"
 )
 
-// ValidateMap checks all map constraints. Returns true if the map is valid.
-func ValidateMap(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, hq2 hex.Coord, structurePositions []hex.Coord, minPassableRatio float64) bool {
+// ValidateMap checks all map constraints, with passable reporting whether units can enter
+// a terrain type. Returns true if the map is valid.
+func ValidateMap(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, hq2 hex.Coord, structurePositions []hex.Coord, minPassableRatio float64, passable func(model.TerrainType) bool) bool {
 	// 1. Connectivity: flood-fill from HQ1 reaches HQ2
-	if !checkConnectivity(grid, terrain, hq1, hq2) {
+	if !checkConnectivity(grid, terrain, hq1, hq2, passable) {
 		return false
 	}
 
 	// 2. Structure accessibility: every structure is reachable from both HQs
-	if !checkStructureAccessibility(grid, terrain, hq1, hq2, structurePositions) {
+	if !checkStructureAccessibility(grid, terrain, hq1, hq2, structurePositions, passable) {
 		return false
 	}
 
 	// 3. No isolated regions: single connected component of passable terrain
-	if !checkSingleComponent(grid, terrain) {
+	if !checkSingleComponent(grid, terrain, passable) {
 		return false
 	}
 
 	// 4. Minimum passable ratio
-	if !checkPassableRatio(grid, terrain, minPassableRatio) {
+	if !checkPassableRatio(grid, terrain, minPassableRatio, passable) {
 		return false
 	}
 
 	// 5. HQ safety: no impassable terrain within 2 hexes of either HQ
-	if !checkHQSafety(grid, terrain, hq1) || !checkHQSafety(grid, terrain, hq2) {
+	if !checkHQSafety(grid, terrain, hq1, passable) || !checkHQSafety(grid, terrain, hq2, passable) {
 		return false
 	}
 
@@ -36,15 +37,15 @@ func ValidateMap(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, h
 }
 
 // checkConnectivity does a flood-fill from start and checks if target is reachable.
-func checkConnectivity(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, start, target hex.Coord) bool {
-	visited := floodFill(grid, terrain, start)
+func checkConnectivity(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, start, target hex.Coord, passable func(model.TerrainType) bool) bool {
+	visited := floodFill(grid, terrain, start, passable)
 	return visited[target]
 }
 
 // checkStructureAccessibility checks that every structure is reachable from both HQs.
-func checkStructureAccessibility(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, hq2 hex.Coord, structures []hex.Coord) bool {
-	reachableFromHQ1 := floodFill(grid, terrain, hq1)
-	reachableFromHQ2 := floodFill(grid, terrain, hq2)
+func checkStructureAccessibility(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq1, hq2 hex.Coord, structures []hex.Coord, passable func(model.TerrainType) bool) bool {
+	reachableFromHQ1 := floodFill(grid, terrain, hq1, passable)
+	reachableFromHQ2 := floodFill(grid, terrain, hq2, passable)
 
 	for _, pos := range structures {
 		if !reachableFromHQ1[pos] || !reachableFromHQ2[pos] {
@@ -55,7 +56,7 @@ func checkStructureAccessibility(grid *hex.Grid, terrain map[hex.Coord]model.Ter
 }
 
 // checkSingleComponent verifies there is exactly one connected component of passable terrain.
-func checkSingleComponent(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType) bool {
+func checkSingleComponent(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, passable func(model.TerrainType) bool) bool {
 	// Find the first passable hex
 	var start hex.Coord
 	found := false
@@ -64,7 +65,7 @@ func checkSingleComponent(grid *hex.Grid, terrain map[hex.Coord]model.TerrainTyp
 		if !ok {
 			t = model.TerrainPlains
 		}
-		if model.IsPassable(t) {
+		if passable(t) {
 			start = c
 			found = true
 			break
@@ -74,7 +75,7 @@ func checkSingleComponent(grid *hex.Grid, terrain map[hex.Coord]model.TerrainTyp
 		return false
 	}
 
-	visited := floodFill(grid, terrain, start)
+	visited := floodFill(grid, terrain, start, passable)
 
 	// Count all passable hexes
 	passableCount := 0
@@ -83,7 +84,7 @@ func checkSingleComponent(grid *hex.Grid, terrain map[hex.Coord]model.TerrainTyp
 		if !ok {
 			t = model.TerrainPlains
 		}
-		if model.IsPassable(t) {
+		if passable(t) {
 			passableCount++
 		}
 	}
@@ -92,30 +93,30 @@ func checkSingleComponent(grid *hex.Grid, terrain map[hex.Coord]model.TerrainTyp
 }
 
 // checkPassableRatio verifies that at least the given fraction of hexes are passable.
-func checkPassableRatio(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, minRatio float64) bool {
+func checkPassableRatio(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, minRatio float64, passable func(model.TerrainType) bool) bool {
 	total := grid.HexCount()
-	passable := 0
+	passableCount := 0
 	for _, c := range grid.AllHexes() {
 		t, ok := terrain[c]
 		if !ok {
 			t = model.TerrainPlains
 		}
-		if model.IsPassable(t) {
-			passable++
+		if passable(t) {
+			passableCount++
 		}
 	}
-	return float64(passable)/float64(total) >= minRatio
+	return float64(passableCount)/float64(total) >= minRatio
 }
 
 // checkHQSafety verifies no impassable terrain within 2 hexes of the HQ.
-func checkHQSafety(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq hex.Coord) bool {
+func checkHQSafety(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq hex.Coord, passable func(model.TerrainType) bool) bool {
 	hexesInRange := grid.HexesInRange(hq, 2)
 	for _, c := range hexesInRange {
 		t, ok := terrain[c]
 		if !ok {
 			t = model.TerrainPlains
 		}
-		if !model.IsPassable(t) {
+		if !passable(t) {
 			return false
 		}
 	}
@@ -123,7 +124,7 @@ func checkHQSafety(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, hq h
 }
 
 // floodFill returns all passable hexes reachable from start.
-func floodFill(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, start hex.Coord) map[hex.Coord]bool {
+func floodFill(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, start hex.Coord, passable func(model.TerrainType) bool) map[hex.Coord]bool {
 	visited := make(map[hex.Coord]bool)
 	queue := []hex.Coord{start}
 	visited[start] = true
@@ -140,7 +141,7 @@ func floodFill(grid *hex.Grid, terrain map[hex.Coord]model.TerrainType, start he
 			if !ok {
 				t = model.TerrainPlains
 			}
-			if !model.IsPassable(t) {
+			if !passable(t) {
 				continue
 			}
 			visited[neighbor] = true
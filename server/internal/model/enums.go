@@ -10,6 +10,9 @@ const (
 	PhaseTurnStart         GamePhase = "turn_start"
 	PhaseStructureCombat   GamePhase = "structure_combat"
 	PhasePlayerAction      GamePhase = "player_action"
+	PhasePlanning          GamePhase = "planning"            // simultaneous mode: both players queue orders
+	PhaseMoveResolution    GamePhase = "movement_resolution" // simultaneous mode: queued moves resolve
+	PhaseAttackResolution  GamePhase = "attack_resolution"   // simultaneous mode: queued attacks resolve
 	PhaseTurnTransition    GamePhase = "turn_transition"
 	PhaseGameOver          GamePhase = "game_over"
 )
@@ -19,7 +22,7 @@ type TurnMode string
 
 const (
 	TurnModeAlternating  TurnMode = "alternating"
-	TurnModeSimultaneous TurnMode = "simultaneous" // Phase 2
+	TurnModeSimultaneous TurnMode = "simultaneous"
 )
 
 // MapSize determines the hex grid radius and structure counts.
@@ -31,6 +34,9 @@ const (
 	MapSizeLarge  MapSize = "large"
 )
 
+// MapSizes lists every map size, smallest first.
+var MapSizes = []MapSize{MapSizeSmall, MapSizeMedium, MapSizeLarge}
+
 // MapRadius returns the hex grid radius for this map size.
 func (ms MapSize) Radius() int {
 	switch ms {
@@ -45,16 +51,9 @@ func (ms MapSize) Radius() int {
 	}
 }
 
-// TroopType identifies the four troop types.
+// TroopType names a troop defined in the balance file's troop roster.
 type TroopType string
 
-const (
-	TroopMarine    TroopType = "marine"
-	TroopSniper    TroopType = "sniper"
-	TroopHoverbike TroopType = "hoverbike"
-	TroopMech      TroopType = "mech"
-)
-
 // StructureType identifies the three structure types.
 type StructureType string
 
@@ -64,6 +63,9 @@ const (
 	StructureHQ            StructureType = "hq"
 )
 
+// StructureTypes lists every structure type.
+var StructureTypes = []StructureType{StructureOutpost, StructureCommandCenter, StructureHQ}
+
 // TerrainType identifies the five terrain types.
 type TerrainType string
 
@@ -116,4 +118,7 @@ const (
 	ErrRoomExpired       ErrorCode = "ROOM_EXPIRED"
 	ErrInvalidMessage    ErrorCode = "INVALID_MESSAGE"
 	ErrRateLimited       ErrorCode = "RATE_LIMITED"
+	ErrOrdersLocked      ErrorCode = "ORDERS_LOCKED"
+	ErrSurrenderTooEarly ErrorCode = "SURRENDER_TOO_EARLY"
+	ErrSeqOutOfOrder     ErrorCode = "SEQ_OUT_OF_ORDER"
 )
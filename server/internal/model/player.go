@@ -7,6 +7,7 @@ type PlayerState struct {
 	Coins                int    `json:"coins"`
 	DominanceTurnCounter int    `json:"dominance_turn_counter"`
 	IsDisconnected       bool   `json:"is_disconnected"`
+	IsBot                bool   `json:"is_bot,omitempty"` // an external bot account, see RoomSettings.MoveTimeLimit
 }
 
 // RoomSettings holds the configurable options for a game room.
@@ -14,6 +15,27 @@ type RoomSettings struct {
 	MapSize   MapSize  `json:"map_size"`
 	TurnTimer int      `json:"turn_timer"` // seconds: 60, 90, or 120
 	TurnMode  TurnMode `json:"turn_mode"`
+	FogOfWar  bool     `json:"fog_of_war"`
+	// BotTakeover lets a bot play a disconnected player's turns until they come back,
+	// instead of forfeiting them after a minute. Alternating turns only.
+	BotTakeover bool `json:"bot_takeover,omitempty"`
+	// MoveTimeLimit is the seconds a bot account may take over each action of its
+	// turn before the turn is ended for it. 0 leaves bots only the turn timer.
+	MoveTimeLimit int `json:"move_time_limit,omitempty"`
+
+	// Custom rules for this room; nil plays the server's balance unchanged.
+	Rules *RuleOverrides `json:"rules,omitempty"`
+}
+
+// RuleOverrides adjusts the server's balance for a single room.
+// Nil fields and an empty troop list keep the server's values.
+type RuleOverrides struct {
+	StartingCoins   *int        `json:"starting_coins,omitempty"`
+	PassiveIncome   *int        `json:"passive_income,omitempty"`
+	StructureIncome *int        `json:"structure_income,omitempty"`
+	DominanceTurns  *int        `json:"dominance_turns,omitempty"`   // consecutive turns of structure majority to win
+	SuddenDeathTurn *int        `json:"sudden_death_turn,omitempty"` // turn sudden death starts on the room's map size
+	AllowedTroops   []TroopType `json:"allowed_troops,omitempty"`    // troop types that may be bought
 }
 
 // DefaultRoomSettings returns the Quick Match defaults.
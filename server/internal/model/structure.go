@@ -16,8 +16,12 @@ type Structure struct {
 	DEF       int           `json:"def"`
 	Range     int           `json:"range"`
 	Damage    string        `json:"damage"` // Dice notation
+	Vision    int           `json:"vision"` // Sight radius in hexes (fog of war)
 	Income    int           `json:"income"`
 	CanSpawn  bool          `json:"can_spawn"`
+	// Fogged marks a remembered structure outside the player's sight (fog of war).
+	// Owner and HP are withheld while it is set.
+	Fogged bool `json:"fogged,omitempty"`
 }
 
 // IsNeutral returns true if the structure has no owner.
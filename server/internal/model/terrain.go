@@ -1,5 +1,7 @@
 package model
 
+import "sync/atomic"
+
 // TerrainInfo holds the gameplay properties of a terrain type.
 type TerrainInfo struct {
 	Type         TerrainType `json:"type"`
@@ -9,8 +11,9 @@ type TerrainInfo struct {
 	Passable     bool        `json:"passable"`
 }
 
-// TerrainTable maps terrain types to their properties.
-var TerrainTable = map[TerrainType]TerrainInfo{
+// defaultTerrainTable holds the built-in types, used until SetTerrainTable
+// installs the table from balance data.
+var defaultTerrainTable = map[TerrainType]TerrainInfo{
 	TerrainPlains: {
 		Type:         TerrainPlains,
 		MovementCost: 1,
@@ -48,12 +51,26 @@ var TerrainTable = map[TerrainType]TerrainInfo{
 	},
 }
 
+// terrainTable maps terrain types to their properties. Swapped atomically
+// when balance data is reloaded.
+var terrainTable atomic.Value // map[TerrainType]TerrainInfo
+
+func init() {
+	terrainTable.Store(defaultTerrainTable)
+}
+
+// SetTerrainTable replaces the terrain table with the one from balance data.
+func SetTerrainTable(table map[TerrainType]TerrainInfo) {
+	terrainTable.Store(table)
+}
+
 // GetTerrainInfo returns the terrain info for the given type.
 // Returns plains info as default for unknown types.
 func GetTerrainInfo(t TerrainType) TerrainInfo {
-	info, ok := TerrainTable[t]
+	table := terrainTable.Load().(map[TerrainType]TerrainInfo)
+	info, ok := table[t]
 	if !ok {
-		return TerrainTable[TerrainPlains]
+		return table[TerrainPlains]
 	}
 	return info
 }
@@ -17,6 +17,7 @@ type Troop struct {
 	Mobility          int       `json:"mobility"`
 	Range             int       `json:"range"`
 	Damage            string    `json:"damage"` // Dice notation, e.g. "1D6+1"
+	Vision            int       `json:"vision"` // Sight radius in hexes (fog of war)
 	IsReady           bool      `json:"is_ready"`
 	HasMoved          bool      `json:"has_moved"`
 	HasAttacked       bool      `json:"has_attacked"`
@@ -7,8 +7,6 @@ import (
 	"regexp"
 	"strings"
 	"time"
-
-	"github.com/teomiscia/hexbattle/internal/model"
 )
 
 // Session represents an active player connection.
@@ -17,6 +15,8 @@ type Session struct {
 	Token     string    `json:"token"` // 32-byte hex string
 	Nickname  string    `json:"nickname"`
 	CreatedAt time.Time `json:"created_at"`
+	// IsBot marks a bot account: an external program playing over the WebSocket API.
+	IsBot bool `json:"is_bot"`
 
 	// Current game association (set when player joins a game)
 	GameID   string `json:"-"`
@@ -69,6 +69,17 @@ func NewSession(nickname string) (*Session, error) {
 	}, nil
 }
 
+// NewBotSession creates a session for a bot account. Bots use the same token and
+// WebSocket protocol as guests, and can be challenged by ID.
+func NewBotSession(nickname string) (*Session, error) {
+	session, err := NewSession(nickname)
+	if err != nil {
+		return nil, err
+	}
+	session.IsBot = true
+	return session, nil
+}
+
 // generateUUID creates a UUIDv4.
 func generateUUID() (string, error) {
 	var uuid [16]byte
@@ -97,6 +108,7 @@ type GuestResponse struct {
 	PlayerID string `json:"player_id"`
 	Token    string `json:"token"`
 	Nickname string `json:"nickname"`
+	IsBot    bool   `json:"is_bot,omitempty"`
 }
 
 // ToResponse converts a session to a guest registration response.
@@ -105,6 +117,7 @@ func (s *Session) ToResponse() GuestResponse {
 		PlayerID: s.ID,
 		Token:    s.Token,
 		Nickname: s.Nickname,
+		IsBot:    s.IsBot,
 	}
 }
 
@@ -131,6 +144,3 @@ func GenerateStructureID() string {
 	}
 	return id
 }
-
-// Validate that model package is reachable (compile-time check).
-var _ = model.TroopMarine
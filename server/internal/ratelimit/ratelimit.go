@@ -0,0 +1,105 @@
+// Package ratelimit implements the token buckets that limit how often clients may
+// send messages and call the REST API.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Rule is a token bucket's rate: Rate tokens every Per, holding at most Burst.
+type Rule struct {
+	Rate  int
+	Per   time.Duration
+	Burst int
+}
+
+// Bucket is a token bucket. It starts full; each allowed event takes a token.
+// A Bucket is not safe for concurrent use.
+type Bucket struct {
+	rule   Rule
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a full bucket.
+func NewBucket(rule Rule, now time.Time) *Bucket {
+	return &Bucket{rule: rule, tokens: float64(rule.Burst), last: now}
+}
+
+// Allow takes a token if there is one.
+func (b *Bucket) Allow(now time.Time) bool {
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// full reports whether the bucket has refilled completely, so it can be forgotten.
+func (b *Bucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= float64(b.rule.Burst)
+}
+
+func (b *Bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(b.rule.Rate) * float64(elapsed) / float64(b.rule.Per)
+		if b.tokens > float64(b.rule.Burst) {
+			b.tokens = float64(b.rule.Burst)
+		}
+	}
+	b.last = now
+}
+
+// pruneEvery is how often a Limiter forgets the buckets of keys that have gone quiet.
+const pruneEvery = time.Minute
+
+// Limiter keeps a bucket per key, such as a client IP. It is safe for concurrent use.
+type Limiter struct {
+	rule Rule
+	now  func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*Bucket
+	lastPrune time.Time
+}
+
+// NewLimiter creates a limiter giving every key its own bucket with the rule.
+func NewLimiter(rule Rule) *Limiter {
+	return &Limiter{
+		rule:      rule,
+		now:       time.Now,
+		buckets:   make(map[string]*Bucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// Allow takes a token from the key's bucket if there is one.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastPrune) > pruneEvery {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewBucket(l.rule, now)
+		l.buckets[key] = b
+	}
+	return b.Allow(now)
+}
+
+// prune forgets full buckets: a new one would be the same. The caller must hold l.mu.
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
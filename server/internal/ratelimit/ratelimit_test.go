@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket(t *testing.T) {
+	start := time.Unix(0, 0)
+	b := NewBucket(Rule{Rate: 5, Per: time.Minute, Burst: 2}, start)
+
+	assert.True(t, b.Allow(start))
+	assert.True(t, b.Allow(start))
+	assert.False(t, b.Allow(start), "the burst is spent")
+
+	// One token every 12 seconds
+	assert.False(t, b.Allow(start.Add(11*time.Second)))
+	assert.True(t, b.Allow(start.Add(12*time.Second)))
+	assert.False(t, b.Allow(start.Add(12*time.Second)))
+
+	// Never more than the burst
+	later := start.Add(time.Hour)
+	assert.True(t, b.Allow(later))
+	assert.True(t, b.Allow(later))
+	assert.False(t, b.Allow(later))
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(Rule{Rate: 1, Per: time.Second, Burst: 1})
+	l.now = func() time.Time { return now }
+	l.lastPrune = now
+
+	assert.True(t, l.Allow("1.2.3.4"))
+	assert.False(t, l.Allow("1.2.3.4"))
+	assert.True(t, l.Allow("5.6.7.8"), "every key has its own bucket")
+
+	// Quiet keys are forgotten
+	now = now.Add(2 * pruneEvery)
+	assert.True(t, l.Allow("1.2.3.4"))
+	assert.Len(t, l.buckets, 1)
+}
@@ -19,6 +19,13 @@ type Store interface {
 	// DeleteGameState removes a game state snapshot.
 	DeleteGameState(ctx context.Context, gameID string) error
 
+	// AppendReplay appends a record to a game's replay log and refreshes its TTL.
+	AppendReplay(ctx context.Context, gameID string, record []byte, ttl time.Duration) error
+
+	// LoadReplay retrieves every record of a game's replay log, oldest first.
+	// Returns nil, nil if the log does not exist.
+	LoadReplay(ctx context.Context, gameID string) ([][]byte, error)
+
 	// ListGameIDs returns all active game IDs (keys matching game:*).
 	ListGameIDs(ctx context.Context) ([]string, error)
 
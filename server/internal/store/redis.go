@@ -9,7 +9,10 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-const gameKeyPrefix = "game:"
+const (
+	gameKeyPrefix   = "game:"
+	replayKeyPrefix = "replay:"
+)
 
 // RedisStore implements the Store interface using Redis.
 type RedisStore struct {
@@ -69,6 +72,38 @@ func (s *RedisStore) DeleteGameState(ctx context.Context, gameID string) error {
 	return s.client.Del(ctx, key).Err()
 }
 
+// AppendReplay pushes a record onto a game's replay list and refreshes its TTL.
+func (s *RedisStore) AppendReplay(ctx context.Context, gameID string, record []byte, ttl time.Duration) error {
+	key := replayKeyPrefix + gameID
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, record)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("store: failed to append replay for game %s: %w", gameID, err)
+	}
+	return nil
+}
+
+// LoadReplay retrieves a game's replay records from Redis, oldest first.
+// Returns nil, nil if the key does not exist.
+func (s *RedisStore) LoadReplay(ctx context.Context, gameID string) ([][]byte, error) {
+	key := replayKeyPrefix + gameID
+	values, err := s.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("store: failed to load replay for game %s: %w", gameID, err)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	records := make([][]byte, len(values))
+	for i, v := range values {
+		records[i] = []byte(v)
+	}
+	return records, nil
+}
+
 // ListGameIDs returns all active game IDs by scanning keys matching "game:*".
 func (s *RedisStore) ListGameIDs(ctx context.Context) ([]string, error) {
 	var gameIDs []string
@@ -33,6 +33,7 @@ type Connection struct {
 	closeOnce sync.Once
 	closed    bool
 	mu        sync.RWMutex
+	limits    *messageLimiter
 
 	// Callbacks
 	OnMessage    func(playerID string, env Envelope)
@@ -48,6 +49,7 @@ func NewConnection(ctx context.Context, conn *websocket.Conn, playerID string) *
 		SendChan: make(chan []byte, SendChanSize),
 		ctx:      ctx,
 		cancel:   cancel,
+		limits:   newMessageLimiter(time.Now()),
 	}
 	return c
 }
@@ -108,12 +110,17 @@ func (c *Connection) SendNack(seq int, actionType string, code string, message s
 
 // Close terminates the connection.
 func (c *Connection) Close() {
+	c.closeWith(websocket.StatusNormalClosure, "connection closed")
+}
+
+// closeWith terminates the connection with the given close status.
+func (c *Connection) closeWith(status websocket.StatusCode, reason string) {
 	c.closeOnce.Do(func() {
 		c.mu.Lock()
 		c.closed = true
 		c.mu.Unlock()
 		c.cancel()
-		c.Conn.Close(websocket.StatusNormalClosure, "connection closed")
+		c.Conn.Close(status, reason)
 	})
 }
 
@@ -141,24 +148,53 @@ func (c *Connection) readLoop() {
 			return
 		}
 
-		slog.Debug("websocket raw message",
+		if status, reason, ok := c.receive(data, time.Now()); !ok {
+			c.closeWith(status, reason)
+			return
+		}
+	}
+}
+
+// receive checks an incoming message against the connection's limits and passes it to
+// OnMessage. A message over its limit is NACKed with RATE_LIMITED and never reaches the
+// engine, so the client may send it again with the same seq. It returns false, with the
+// close status and reason, when the connection has broken its limits too often.
+func (c *Connection) receive(data []byte, now time.Time) (websocket.StatusCode, string, bool) {
+	slog.Debug("websocket raw message",
+		"player_id", c.PlayerID,
+		"raw", string(data),
+	)
+
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		slog.Warn("malformed websocket message",
 			"player_id", c.PlayerID,
-			"raw", string(data),
+			"error", err,
 		)
+		c.limits.malformed++
+		if c.limits.malformed >= MaxMalformedMessages {
+			slog.Warn("too many malformed messages, closing connection", "player_id", c.PlayerID)
+			return websocket.StatusPolicyViolation, "malformed messages", false
+		}
+		return 0, "", true
+	}
 
-		var env Envelope
-		if err := json.Unmarshal(data, &env); err != nil {
-			slog.Warn("malformed websocket message",
+	if !c.limits.allow(env.Type, now) {
+		if !c.limits.strike(now) {
+			slog.Warn("rate limits broken repeatedly, closing connection",
 				"player_id", c.PlayerID,
-				"error", err,
+				"type", env.Type,
 			)
-			continue
+			return websocket.StatusPolicyViolation, "rate limited", false
 		}
+		c.SendNack(env.Seq, env.Type, string(model.ErrRateLimited), "too many messages, slow down")
+		return 0, "", true
+	}
 
-		if c.OnMessage != nil {
-			c.OnMessage(c.PlayerID, env)
-		}
+	if c.OnMessage != nil {
+		c.OnMessage(c.PlayerID, env)
 	}
+	return 0, "", true
 }
 
 // writeLoop writes queued messages to the WebSocket.
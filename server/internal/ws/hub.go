@@ -5,14 +5,15 @@ import (
 	"sync"
 )
 
-// Hub manages WebSocket connections for a single game instance.
-// It handles broadcasting messages to both players and direct sends.
+// Hub manages a set of WebSocket connections by player ID: each game's two players,
+// or, for the server-wide lobby hub, every connected player before they join a game.
+// It handles broadcasting messages and direct sends.
 type Hub struct {
 	mu    sync.RWMutex
 	conns map[string]*Connection // player_id -> connection
 }
 
-// NewHub creates a new per-game message hub.
+// NewHub creates a new message hub.
 func NewHub() *Hub {
 	return &Hub{
 		conns: make(map[string]*Connection),
@@ -33,6 +34,16 @@ func (h *Hub) Unregister(playerID string) {
 	delete(h.conns, playerID)
 }
 
+// UnregisterConn removes the connection from the hub if it is still the player's, and
+// not one they have opened since.
+func (h *Hub) UnregisterConn(conn *Connection) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conns[conn.PlayerID] == conn {
+		delete(h.conns, conn.PlayerID)
+	}
+}
+
 // GetConnection returns the connection for a player, or nil if not connected.
 func (h *Hub) GetConnection(playerID string) *Connection {
 	h.mu.RLock()
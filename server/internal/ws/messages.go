@@ -11,9 +11,12 @@ import (
 
 // Envelope is the top-level message wrapper for all WebSocket messages.
 type Envelope struct {
-	Type string          `json:"type"`
-	Seq  int             `json:"seq,omitempty"`
-	Data json.RawMessage `json:"data"`
+	Type string `json:"type"`
+	Seq  int    `json:"seq,omitempty"`
+	// Event numbers the game events the server sends each player, from 1 with no gaps.
+	// Acks, nacks, query replies and emotes are not game events and carry no number.
+	Event int             `json:"event,omitempty"`
+	Data  json.RawMessage `json:"data"`
 }
 
 // NewEnvelope creates a new message envelope with the given type and data.
@@ -43,6 +46,20 @@ func NewEnvelopeWithSeq(msgType string, seq int, data interface{}) ([]byte, erro
 	return json.Marshal(env)
 }
 
+// NewEvent creates a game event envelope with the given event number.
+func NewEvent(msgType string, event int, data interface{}) ([]byte, error) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("ws: failed to marshal message data: %w", err)
+	}
+	env := Envelope{
+		Type:  msgType,
+		Event: event,
+		Data:  dataBytes,
+	}
+	return json.Marshal(env)
+}
+
 // --- Client → Server Message Types ---
 
 const (
@@ -53,7 +70,24 @@ const (
 	MsgBuy       = "buy"
 	MsgEndTurn   = "end_turn"
 	MsgEmote     = "emote"
+	MsgSurrender = "surrender"
 	MsgPong      = "pong"
+
+	// Sent after join_game's game_state, which carries the seed commitment, and before the game starts
+	MsgClientSeed = "client_seed"
+
+	// Queries: answered only to the asking player, never change the game
+	MsgPreviewAttack = "preview_attack"
+	MsgPreviewPath   = "preview_path"  // data: MoveData
+	MsgRequestState  = "request_state" // no data; answered with game_state
+	MsgResync        = "resync"        // data: ResyncData; answered with the missed events or game_state
+
+	// Simultaneous turn mode orders
+	MsgOrderMove    = "order_move"
+	MsgOrderAttack  = "order_attack"
+	MsgOrderBuy     = "order_buy"
+	MsgCancelOrder  = "cancel_order"
+	MsgSubmitOrders = "submit_orders"
 )
 
 // JoinGameData is sent by the client to associate with a game room.
@@ -61,6 +95,16 @@ type JoinGameData struct {
 	RoomID string `json:"room_id"`
 }
 
+// ClientSeedData carries the entropy a player mixes into the dice seed.
+type ClientSeedData struct {
+	ClientSeed string `json:"client_seed"` // empty to add none and not wait for the seed window
+}
+
+// ResyncData asks for the game events after the last one the client has.
+type ResyncData struct {
+	Since int `json:"since"`
+}
+
 // ReconnectData is sent by the client to reconnect to an active game.
 type ReconnectData struct {
 	GameID      string `json:"game_id"`
@@ -89,6 +133,12 @@ type BuyData struct {
 	StructureID string          `json:"structure_id"`
 }
 
+// CancelOrderData is sent by the client to withdraw queued orders.
+// An empty UnitID clears every order the player has queued this turn.
+type CancelOrderData struct {
+	UnitID string `json:"unit_id,omitempty"`
+}
+
 // EmoteData is sent/received for emote messages.
 type EmoteData struct {
 	PlayerID string `json:"player_id,omitempty"`
@@ -114,6 +164,22 @@ const (
 	MsgPing               = "ping"
 	MsgMatchFound         = "match_found"
 	MsgError              = "error"
+
+	// Simultaneous turn mode deltas
+	MsgOrdersSubmitted  = "orders_submitted"
+	MsgMovementResolved = "movement_resolved"
+	MsgAttacksResolved  = "attacks_resolved"
+
+	// Fog of war
+	MsgVisionUpdate = "vision_update"
+
+	// Query replies
+	MsgAttackPreview = "attack_preview"
+	MsgPathPreview   = "path_preview"
+
+	// Lobby notifications, sent before join_game
+	MsgQueueStatus    = "queue_status"
+	MsgOpponentJoined = "opponent_joined"
 )
 
 // AckData acknowledges a client action.
@@ -168,6 +234,58 @@ type CombatResultData struct {
 	CounterDamage  int  `json:"counter_damage,omitempty"`
 	AttackerHP     int  `json:"attacker_hp"`
 	AttackerKilled bool `json:"attacker_killed"`
+	// ATK penalty applied when the target moved out of the attacked hex (simultaneous mode)
+	ATKPenalty int `json:"atk_penalty,omitempty"`
+}
+
+// AttackPreviewData answers a preview_attack query with the odds of an attack,
+// computed from the same modifiers the combat resolution uses. Seq echoes the query.
+type AttackPreviewData struct {
+	Seq        int    `json:"seq"`
+	UnitID     string `json:"unit_id"`
+	TargetID   string `json:"target_id"`
+	TargetKind string `json:"target_kind"` // "troop" or "structure"
+
+	ATKModifier  int     `json:"atk_modifier"`  // attacker ATK + terrain
+	TargetDEF    int     `json:"target_def"`    // target DEF + terrain
+	HitThreshold int     `json:"hit_threshold"` // natural d20 roll needed to hit, 2-20
+	HitChance    float64 `json:"hit_chance"`
+	CritChance   float64 `json:"crit_chance"`
+	FumbleChance float64 `json:"fumble_chance"`
+	DamageMin    int     `json:"damage_min"`
+	DamageMax    int     `json:"damage_max"`
+	CritMin      int     `json:"crit_damage_min"`
+	CritMax      int     `json:"crit_damage_max"`
+	KillChance   float64 `json:"kill_chance"` // capture chance for structures
+
+	// Counterattack; only troops strike back
+	CounterPossible     bool    `json:"counter_possible"`
+	CounterOnFumbleOnly bool    `json:"counter_on_fumble_only,omitempty"`
+	CounterChance       float64 `json:"counter_chance,omitempty"`
+	CounterThreshold    int     `json:"counter_hit_threshold,omitempty"`
+	CounterHitChance    float64 `json:"counter_hit_chance,omitempty"`
+	CounterDamageMin    int     `json:"counter_damage_min,omitempty"`
+	CounterDamageMax    int     `json:"counter_damage_max,omitempty"`
+	CounterKillChance   float64 `json:"counter_kill_chance,omitempty"`
+}
+
+// PathPreviewData answers a preview_path query with the route a troop would take to a hex,
+// over as many turns as it takes. Seq echoes the query.
+type PathPreviewData struct {
+	Seq    int            `json:"seq"`
+	UnitID string         `json:"unit_id"`
+	Steps  []PathStepData `json:"steps"`
+	Cost   int            `json:"cost"`  // total movement cost
+	Turns  int            `json:"turns"` // turns until the target hex is entered
+}
+
+// PathStepData is one hex along a previewed route.
+type PathStepData struct {
+	Q    int `json:"q"`
+	R    int `json:"r"`
+	S    int `json:"s"`
+	Cost int `json:"cost"` // movement cost from the start
+	Turn int `json:"turn"` // 1 is the current turn
 }
 
 // TroopPurchasedData is broadcast when a troop is purchased.
@@ -233,6 +351,15 @@ type SuddenDeathDamage struct {
 	Killed  bool   `json:"killed"`
 }
 
+// PlayerIncome records the income credited to one player at turn start.
+// Used in simultaneous mode, where both players are credited at once.
+type PlayerIncome struct {
+	PlayerID        string `json:"player_id"`
+	IncomeGained    int    `json:"income_gained"`
+	StructureIncome int    `json:"structure_income"`
+	TotalCoins      int    `json:"total_coins"`
+}
+
 // TurnStartData is broadcast when a new turn begins.
 type TurnStartData struct {
 	TurnNumber         int                 `json:"turn_number"`
@@ -244,6 +371,7 @@ type TurnStartData struct {
 	HealedUnits        []HealedUnit        `json:"healed_units"`
 	StructureRegens    []StructureRegen    `json:"structure_regens"`
 	SuddenDeathDamages []SuddenDeathDamage `json:"sudden_death_damage"`
+	Incomes            []PlayerIncome      `json:"incomes,omitempty"` // simultaneous mode only
 }
 
 // GameOverData is broadcast when the game ends.
@@ -251,11 +379,21 @@ type GameOverData struct {
 	WinnerID string                         `json:"winner_id"`
 	Reason   model.WinReason                `json:"reason"`
 	Stats    map[string]model.GameOverStats `json:"stats"` // player_id -> stats
+	Fairness *SeedRevealData                `json:"fairness,omitempty"`
+}
+
+// SeedRevealData discloses the dice seeds at game over so every roll can be verified.
+type SeedRevealData struct {
+	Commitment  string   `json:"commitment"`   // SHA-256 of ServerSeed, published at game start
+	ServerSeed  string   `json:"server_seed"`  // the server's secret seed
+	ClientSeeds []string `json:"client_seeds"` // entropy sent by each player, in player order
+	Seed        int64    `json:"seed"`         // dice seed derived from the seeds above
 }
 
 // PlayerDisconnectedData is broadcast when a player disconnects.
 type PlayerDisconnectedData struct {
-	PlayerID string `json:"player_id"`
+	PlayerID    string `json:"player_id"`
+	BotTakeover bool   `json:"bot_takeover,omitempty"` // a bot plays their turns until they reconnect
 }
 
 // PlayerReconnectedData is broadcast when a player reconnects.
@@ -263,7 +401,66 @@ type PlayerReconnectedData struct {
 	PlayerID string `json:"player_id"`
 }
 
-// MatchFoundData is sent to a waiting player when a match is found.
+// OrdersSubmittedData is broadcast when a player locks in their orders.
+type OrdersSubmittedData struct {
+	PlayerID string `json:"player_id"`
+}
+
+// CancelledOrder records a queued order that could not be carried out during resolution.
+type CancelledOrder struct {
+	UnitID string `json:"unit_id"`
+	Reason string `json:"reason"` // "conflict", "blocked", "out_of_range", "no_target"
+}
+
+// MovementResolvedData is broadcast after all queued moves have resolved.
+type MovementResolvedData struct {
+	Moves     []TroopMovedData `json:"moves"`
+	Cancelled []CancelledOrder `json:"cancelled"`
+}
+
+// AttacksResolvedData is broadcast after all queued attacks have resolved.
+type AttacksResolvedData struct {
+	Combats          []CombatResultData      `json:"combats"`
+	StructureAttacks []StructureAttackedData `json:"structure_attacks"`
+	Destroyed        []TroopDestroyedData    `json:"destroyed"`
+	Cancelled        []CancelledOrder        `json:"cancelled"`
+}
+
+// RevealedHex is a hex seen for the first time, with its terrain.
+type RevealedHex struct {
+	Q       int               `json:"q"`
+	R       int               `json:"r"`
+	S       int               `json:"s"`
+	Terrain model.TerrainType `json:"terrain"`
+}
+
+// VisionUpdateData is sent to one player when their fog of war changes.
+// Spotted troops entered sight, hidden troops left it, and structures
+// carry fresh details when they come into sight or a fogged copy when they leave it.
+type VisionUpdateData struct {
+	Revealed   []RevealedHex     `json:"revealed"`
+	Spotted    []model.Troop     `json:"spotted"`
+	Hidden     []string          `json:"hidden"`
+	Structures []model.Structure `json:"structures"`
+}
+
+// MatchFoundData is sent to both players when matchmaking pairs them.
 type MatchFoundData struct {
-	RoomID string `json:"room_id"`
+	RoomID           string `json:"room_id"`
+	RoomCode         string `json:"room_code"`
+	OpponentNickname string `json:"opponent_nickname"`
+}
+
+// QueueStatusData is sent to a player in the matchmaking queue when they join it and
+// whenever their place changes.
+type QueueStatusData struct {
+	Position             int `json:"position"` // 1 for the next player to be matched
+	QueueSize            int `json:"queue_size"`
+	EstimatedWaitSeconds int `json:"estimated_wait_seconds"` // 0 when there is no estimate yet
+}
+
+// OpponentJoinedData is sent to a room's host when a guest joins it by code.
+type OpponentJoinedData struct {
+	RoomID   string `json:"room_id"`
+	Nickname string `json:"nickname"`
 }
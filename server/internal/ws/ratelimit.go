@@ -0,0 +1,74 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/teomiscia/hexbattle/internal/ratelimit"
+)
+
+// MessageLimits are how often a connection may send each message type. Types not
+// listed share DefaultMessageLimit.
+var MessageLimits = map[string]ratelimit.Rule{
+	MsgMove:          {Rate: 10, Per: time.Second, Burst: 20},
+	MsgAttack:        {Rate: 10, Per: time.Second, Burst: 20},
+	MsgBuy:           {Rate: 10, Per: time.Second, Burst: 20},
+	MsgEndTurn:       {Rate: 10, Per: time.Second, Burst: 20},
+	MsgOrderMove:     {Rate: 10, Per: time.Second, Burst: 20},
+	MsgOrderAttack:   {Rate: 10, Per: time.Second, Burst: 20},
+	MsgOrderBuy:      {Rate: 10, Per: time.Second, Burst: 20},
+	MsgCancelOrder:   {Rate: 10, Per: time.Second, Burst: 20},
+	MsgSubmitOrders:  {Rate: 10, Per: time.Second, Burst: 20},
+	MsgSurrender:     {Rate: 1, Per: time.Second, Burst: 3},
+	MsgJoinGame:      {Rate: 1, Per: time.Second, Burst: 5},
+	MsgReconnect:     {Rate: 1, Per: time.Second, Burst: 5},
+	MsgClientSeed:    {Rate: 1, Per: time.Second, Burst: 3},
+	MsgEmote:         {Rate: 1, Per: 3 * time.Second, Burst: 3},
+	MsgPreviewAttack: {Rate: 20, Per: time.Second, Burst: 40},
+	MsgPreviewPath:   {Rate: 20, Per: time.Second, Burst: 40},
+	MsgRequestState:  {Rate: 10, Per: time.Second, Burst: 20}, // bots ask for the state after each action
+	MsgResync:        {Rate: 2, Per: time.Second, Burst: 5},
+}
+
+// DefaultMessageLimit is shared by the message types without a limit of their own.
+var DefaultMessageLimit = ratelimit.Rule{Rate: 5, Per: time.Second, Burst: 10}
+
+// StrikeLimit is how often a connection may break its limits: every rate-limited
+// message takes a strike, and a connection out of strikes is closed.
+var StrikeLimit = ratelimit.Rule{Rate: 1, Per: 10 * time.Second, Burst: 10}
+
+// MaxMalformedMessages is how many unreadable messages a connection may send before it
+// is closed.
+const MaxMalformedMessages = 5
+
+// messageLimiter holds a connection's buckets. It is only used by the read loop.
+type messageLimiter struct {
+	buckets   map[string]*ratelimit.Bucket // by message type, "" for the shared one
+	strikes   *ratelimit.Bucket
+	malformed int
+}
+
+func newMessageLimiter(now time.Time) *messageLimiter {
+	return &messageLimiter{
+		buckets: make(map[string]*ratelimit.Bucket),
+		strikes: ratelimit.NewBucket(StrikeLimit, now),
+	}
+}
+
+// allow takes a token for a message of the given type.
+func (l *messageLimiter) allow(msgType string, now time.Time) bool {
+	rule, ok := MessageLimits[msgType]
+	if !ok {
+		msgType, rule = "", DefaultMessageLimit
+	}
+	b := l.buckets[msgType]
+	if b == nil {
+		b = ratelimit.NewBucket(rule, now)
+		l.buckets[msgType] = b
+	}
+	return b.Allow(now)
+}
+
+// strike records a rate-limited message and reports whether the connection may go on.
+func (l *messageLimiter) strike(now time.Time) bool {
+	return l.strikes.Allow(now)
+}
@@ -0,0 +1,142 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+
+	"github.com/teomiscia/hexbattle/internal/model"
+)
+
+func TestMessageLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newMessageLimiter(now)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.allow(MsgEmote, now))
+	}
+	assert.False(t, l.allow(MsgEmote, now), "emotes are limited much sooner than moves")
+	assert.True(t, l.allow(MsgMove, now), "every type has its own bucket")
+	assert.True(t, l.allow(MsgEmote, now.Add(3*time.Second)))
+
+	// Types without a limit of their own share one
+	for i := 0; i < DefaultMessageLimit.Burst; i++ {
+		assert.True(t, l.allow("foo", now))
+	}
+	assert.False(t, l.allow("bar", now))
+}
+
+// testConnection returns a connection without a socket that records the messages
+// passed on to OnMessage.
+func testConnection(now time.Time) (*Connection, *[]Envelope) {
+	c := NewConnection(context.Background(), nil, "p1")
+	c.limits = newMessageLimiter(now)
+	var received []Envelope
+	c.OnMessage = func(playerID string, env Envelope) {
+		received = append(received, env)
+	}
+	return c, &received
+}
+
+func message(t *testing.T, msgType string, seq int) []byte {
+	t.Helper()
+	msg, err := NewEnvelopeWithSeq(msgType, seq, struct{}{})
+	require.NoError(t, err)
+	return msg
+}
+
+func TestConnection_RateLimitedActionKeepsItsSeq(t *testing.T) {
+	now := time.Unix(0, 0)
+	c, received := testConnection(now)
+
+	burst := MessageLimits[MsgMove].Burst
+	for seq := 1; seq <= burst; seq++ {
+		_, _, ok := c.receive(message(t, MsgMove, seq), now)
+		require.True(t, ok)
+	}
+	require.Len(t, *received, burst)
+
+	// Over the limit: NACKed, and the engine never sees it
+	_, _, ok := c.receive(message(t, MsgMove, burst+1), now)
+	require.True(t, ok)
+	assert.Len(t, *received, burst)
+
+	var env Envelope
+	require.NoError(t, json.Unmarshal(<-c.SendChan, &env))
+	require.Equal(t, MsgNack, env.Type)
+	var nack NackData
+	require.NoError(t, json.Unmarshal(env.Data, &nack))
+	assert.Equal(t, burst+1, nack.Seq)
+	assert.Equal(t, model.ErrRateLimited, nack.Error.Code)
+
+	// Sent again with the same seq once the limit recovers, it goes through
+	_, _, ok = c.receive(message(t, MsgMove, burst+1), now.Add(100*time.Millisecond))
+	require.True(t, ok)
+	require.Len(t, *received, burst+1)
+	assert.Equal(t, burst+1, (*received)[burst].Seq)
+}
+
+func TestConnection_OrdersForAFullSimultaneousTurnPass(t *testing.T) {
+	now := time.Unix(0, 0)
+	c, received := testConnection(now)
+
+	// A dozen troops each moving and attacking, a few buys, then the submit, all at once
+	var msgs [][]byte
+	seq := 0
+	for _, msgType := range []string{MsgOrderMove, MsgOrderAttack} {
+		for i := 0; i < 12; i++ {
+			seq++
+			msgs = append(msgs, message(t, msgType, seq))
+		}
+	}
+	for i := 0; i < 3; i++ {
+		seq++
+		msgs = append(msgs, message(t, MsgOrderBuy, seq))
+	}
+	seq++
+	msgs = append(msgs, message(t, MsgCancelOrder, seq))
+	seq++
+	msgs = append(msgs, message(t, MsgSubmitOrders, seq))
+
+	for _, msg := range msgs {
+		_, _, ok := c.receive(msg, now)
+		require.True(t, ok)
+	}
+	assert.Len(t, *received, len(msgs))
+	assert.Empty(t, c.SendChan, "nothing was rate limited")
+}
+
+func TestConnection_ClosedAfterTooManyStrikes(t *testing.T) {
+	now := time.Unix(0, 0)
+	c, received := testConnection(now)
+
+	for seq := 1; seq <= MessageLimits[MsgEmote].Burst; seq++ {
+		c.receive(message(t, MsgEmote, seq), now)
+	}
+	for i := 0; i < StrikeLimit.Burst; i++ {
+		_, _, ok := c.receive(message(t, MsgEmote, 10+i), now)
+		require.True(t, ok, "strike %d", i+1)
+	}
+	status, _, ok := c.receive(message(t, MsgEmote, 99), now)
+	assert.False(t, ok)
+	assert.Equal(t, websocket.StatusPolicyViolation, status)
+	assert.Len(t, *received, MessageLimits[MsgEmote].Burst)
+}
+
+func TestConnection_ClosedAfterMalformedMessages(t *testing.T) {
+	c, received := testConnection(time.Unix(0, 0))
+
+	for i := 1; i < MaxMalformedMessages; i++ {
+		_, _, ok := c.receive([]byte("{not json"), time.Unix(0, 0))
+		require.True(t, ok)
+	}
+	status, _, ok := c.receive([]byte("{not json"), time.Unix(0, 0))
+	assert.False(t, ok)
+	assert.Equal(t, websocket.StatusPolicyViolation, status)
+	assert.Empty(t, *received)
+}